@@ -0,0 +1,210 @@
+package parser
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// VerifyBatch verifies Circom Groth16 proofs against the same verification
+// key, as VerifyProofBatch does. publicSignals[i] are the public signals of
+// proofs[i]. Malformed proofs and public signals are reported as invalid.
+func VerifyBatch(vk *CircomVerificationKey, proofs []*CircomProof, publicSignals [][]string) ([]int, error) {
+	if len(proofs) != len(publicSignals) {
+		return nil, newError(ErrInternal, "publicSignals", "expected %d lists of public signals, got %d",
+			len(proofs), len(publicSignals))
+	}
+	gnarkVk, err := ConvertVerificationKey(vk)
+	if err != nil {
+		return nil, err
+	}
+	gnarkProofs := make([]*GnarkProof, len(proofs))
+	for i, proof := range proofs {
+		gnarkProofs[i] = &GnarkProof{VerifyingKey: gnarkVk}
+		if proof == nil || proof.Validate() != nil || checkCurve("proof", proof.Curve, ecc.BN254) != nil {
+			continue
+		}
+		if gnarkProofs[i].Proof, err = convertProof(proof); err != nil {
+			continue
+		}
+		if gnarkProofs[i].PublicInputs, err = ConvertPublicInputsStrict(publicSignals[i]); err != nil {
+			gnarkProofs[i].Proof = nil
+		}
+	}
+	return VerifyProofBatch(gnarkProofs)
+}
+
+// VerifyProofBatch verifies many Groth16 proofs at once, possibly against
+// different verification keys. The N pairing checks are combined with random
+// scalars into a single multi-pairing, with one Miller loop per proof, three
+// per verification key and one final exponentiation. If the batch fails, it is
+// bisected to find the invalid proofs.
+//
+// It returns the indexes of the invalid proofs, in increasing order, and an
+// ErrVerificationFailed error if there is any. Proofs that are malformed, that
+// do not match the number of public inputs of their key or whose points are
+// not in the right subgroups are invalid. Keys are grouped by pointer, so the
+// proofs of a key should share the same *groth16_bn254.VerifyingKey. Keys with
+// commitments are not batched, and their proofs are verified one by one.
+func VerifyProofBatch(proofs []*GnarkProof) ([]int, error) {
+	var invalid, batch []int
+	for i, p := range proofs {
+		switch {
+		case p == nil || p.Proof == nil || p.VerifyingKey == nil ||
+			len(p.PublicInputs) != nbPublicInputs(p.VerifyingKey):
+			invalid = append(invalid, i)
+		case len(p.VerifyingKey.CommitmentKeys) > 0:
+			if ok, _ := VerifyProof(p); !ok {
+				invalid = append(invalid, i)
+			}
+		case !p.Proof.Ar.IsInSubGroup() || !p.Proof.Bs.IsInSubGroup() || !p.Proof.Krs.IsInSubGroup():
+			invalid = append(invalid, i)
+		default:
+			batch = append(batch, i)
+		}
+	}
+	failed, err := bisectBatch(proofs, batch, false)
+	if err != nil {
+		return nil, err
+	}
+	invalid = mergeSorted(invalid, failed)
+	if len(invalid) > 0 {
+		return invalid, newError(ErrVerificationFailed, "", "%d of %d proofs are invalid", len(invalid), len(proofs))
+	}
+	return nil, nil
+}
+
+// bisectBatch returns the indexes of the invalid proofs among proofs[idx].
+// If failed is true, the batch is already known to fail.
+func bisectBatch(proofs []*GnarkProof, idx []int, failed bool) ([]int, error) {
+	if len(idx) == 0 {
+		return nil, nil
+	}
+	if !failed {
+		ok, err := batchPairingCheck(proofs, idx)
+		if err != nil || ok {
+			return nil, err
+		}
+	}
+	if len(idx) == 1 {
+		return idx, nil
+	}
+	mid := len(idx) / 2
+	left, err := bisectBatch(proofs, idx[:mid], false)
+	if err != nil {
+		return nil, err
+	}
+	// If the left half is valid, the right half must contain an invalid proof
+	right, err := bisectBatch(proofs, idx[mid:], len(left) == 0)
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
+
+// batchGroup accumulates the terms of the proofs of a verification key.
+type batchGroup struct {
+	vk   *groth16_bn254.VerifyingKey
+	rSum bn254fr.Element
+	kSum curve.G1Jac
+	cSum curve.G1Jac
+}
+
+// batchPairingCheck checks, for random scalars r_i, that
+//
+//	∏ e(r_i·A_i, B_i) = ∏_vk e(Σ r_i·α, β)·e(Σ r_i·L_i, γ)·e(Σ r_i·C_i, δ)
+//
+// where L_i = K_0 + Σ x_ij·K_j is the public input term of proof i.
+func batchPairingCheck(proofs []*GnarkProof, idx []int) (bool, error) {
+	groups := make(map[*groth16_bn254.VerifyingKey]*batchGroup)
+	var order []*batchGroup
+	P := make([]curve.G1Affine, 0, len(idx)+3)
+	Q := make([]curve.G2Affine, 0, len(idx)+3)
+	for _, i := range idx {
+		p := proofs[i]
+		g, ok := groups[p.VerifyingKey]
+		if !ok {
+			g = &batchGroup{vk: p.VerifyingKey}
+			groups[p.VerifyingKey] = g
+			order = append(order, g)
+		}
+		r, err := randomBatchScalar()
+		if err != nil {
+			return false, err
+		}
+		g.rSum.Add(&g.rSum, &r)
+
+		// r·L_i = MultiExp(K, [r, r·x_i1, ..., r·x_in])
+		scalars := make([]bn254fr.Element, len(p.PublicInputs)+1)
+		scalars[0] = r
+		for j := range p.PublicInputs {
+			scalars[j+1].Mul(&r, &p.PublicInputs[j])
+		}
+		var rL curve.G1Jac
+		if _, err := rL.MultiExp(p.VerifyingKey.G1.K, scalars, ecc.MultiExpConfig{}); err != nil {
+			return false, newError(ErrInternal, "", "failed to compute public input term: %v", err)
+		}
+		g.kSum.AddAssign(&rL)
+
+		var rBig big.Int
+		r.BigInt(&rBig)
+		var rC curve.G1Jac
+		rC.FromAffine(&p.Proof.Krs)
+		rC.ScalarMultiplication(&rC, &rBig)
+		g.cSum.AddAssign(&rC)
+
+		var rA curve.G1Affine
+		rA.ScalarMultiplication(&p.Proof.Ar, &rBig)
+		P = append(P, rA)
+		Q = append(Q, p.Proof.Bs)
+	}
+	for _, g := range order {
+		var rSum big.Int
+		g.rSum.BigInt(&rSum)
+		var alpha, k, c curve.G1Affine
+		alpha.ScalarMultiplication(&g.vk.G1.Alpha, &rSum)
+		alpha.Neg(&alpha)
+		k.FromJacobian(&g.kSum)
+		k.Neg(&k)
+		c.FromJacobian(&g.cSum)
+		c.Neg(&c)
+		P = append(P, alpha, k, c)
+		Q = append(Q, g.vk.G2.Beta, g.vk.G2.Gamma, g.vk.G2.Delta)
+	}
+	ok, err := curve.PairingCheck(P, Q)
+	if err != nil {
+		return false, newError(ErrInternal, "", "failed to compute pairing: %v", err)
+	}
+	return ok, nil
+}
+
+// randomBatchScalar returns a random non-zero 128-bit scalar, which is enough
+// for a soundness error of 2^-128.
+func randomBatchScalar() (bn254fr.Element, error) {
+	var r bn254fr.Element
+	var b [16]byte
+	for r.IsZero() {
+		if _, err := rand.Read(b[:]); err != nil {
+			return r, newError(ErrInternal, "", "failed to generate random scalar: %v", err)
+		}
+		r.SetBytes(b[:])
+	}
+	return r, nil
+}
+
+// mergeSorted merges two increasing lists of indexes.
+func mergeSorted(a, b []int) []int {
+	out := make([]int, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0] < b[0] {
+			out, a = append(out, a[0]), a[1:]
+		} else {
+			out, b = append(out, b[0]), b[1:]
+		}
+	}
+	return append(append(out, a...), b...)
+}
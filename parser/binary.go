@@ -0,0 +1,430 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+)
+
+// Binary encoding of Circom proofs, verification keys and public signals.
+// Each encoding starts with a 4-byte magic string and a version byte. Points
+// use the gnark compressed encoding and integers are big-endian, as in gnark's
+// own serialization:
+//
+//	proof:          magic "c2gp" | version | curve | A | B | C
+//	vk:             magic "c2gv" | version | curve | nPublic (uint32) | alphabeta (bool) |
+//	                alpha | beta | gamma | delta | len(IC) (uint32) | IC...
+//	public signals: magic "c2gs" | version | 0 | len (uint32) | 32-byte values...
+//
+// The curve byte is 0 for a proof without curve field and for public signals,
+// 1 for bn128 and 2 for bls12381. vk_alphabeta_12 is not stored: the flag tells whether to
+// recompute it when decoding. The protocol is not stored either: only Groth16
+// proofs and keys are encoded, and they are decoded with protocol groth16.
+const (
+	binaryVersion = 1
+
+	proofBinaryMagic           = "c2gp"
+	verificationKeyBinaryMagic = "c2gv"
+	publicSignalsBinaryMagic   = "c2gs"
+
+	binaryCurveNone     = 0
+	binaryCurveBN254    = 1
+	binaryCurveBLS12381 = 2
+
+	publicSignalBinarySize = 32
+)
+
+// CircomPublicSignals is a list of public signals, as output by SnarkJS. It
+// only adds the binary encoding methods to []string.
+type CircomPublicSignals []string
+
+// MarshalBinary encodes the proof in the compact binary format. The protocol
+// is normalized to groth16.
+func (p *CircomProof) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := p.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a proof encoded with MarshalBinary.
+func (p *CircomProof) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, "proof", p)
+}
+
+// WriteTo writes the binary encoding of the proof to w. It implements
+// io.WriterTo.
+func (p *CircomProof) WriteTo(w io.Writer) (int64, error) {
+	if err := p.Validate(); err != nil {
+		return 0, err
+	}
+	curveByte, curveID, err := binaryCurve("proof.curve", p.Curve)
+	if err != nil {
+		return 0, err
+	}
+	bw := &binWriter{w: w}
+	bw.header(proofBinaryMagic, curveByte)
+	bw.g1(curveID, "proof.pi_a", p.PiA)
+	bw.g2(curveID, "proof.pi_b", p.PiB)
+	bw.g1(curveID, "proof.pi_c", p.PiC)
+	return bw.n, bw.err
+}
+
+// ReadFrom reads the binary encoding of a proof from r. It implements
+// io.ReaderFrom.
+func (p *CircomProof) ReadFrom(r io.Reader) (int64, error) {
+	br := &binStreamReader{r: r, path: "proof"}
+	curveID, curveName := br.header(proofBinaryMagic)
+	proof := CircomProof{Protocol: "groth16", Curve: curveName}
+	proof.PiA = br.g1(curveID, "proof.pi_a")
+	proof.PiB = br.g2(curveID, "proof.pi_b")
+	proof.PiC = br.g1(curveID, "proof.pi_c")
+	if br.err != nil {
+		return br.n, br.err
+	}
+	*p = proof
+	return br.n, nil
+}
+
+// MarshalBinary encodes the verification key in the compact binary format.
+// The protocol is normalized to groth16 and the curve name to bn128 or
+// bls12381.
+func (vk *CircomVerificationKey) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := vk.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a verification key encoded with MarshalBinary.
+func (vk *CircomVerificationKey) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, "vk", vk)
+}
+
+// WriteTo writes the binary encoding of the verification key to w. It
+// implements io.WriterTo.
+func (vk *CircomVerificationKey) WriteTo(w io.Writer) (int64, error) {
+	if err := vk.Validate(); err != nil {
+		return 0, err
+	}
+	curveByte, curveID, err := binaryCurve("vk.curve", vk.Curve)
+	if err != nil {
+		return 0, err
+	}
+	if curveByte == binaryCurveNone {
+		curveByte = binaryCurveBN254
+	}
+	bw := &binWriter{w: w}
+	bw.header(verificationKeyBinaryMagic, curveByte)
+	bw.u32(uint32(vk.NPublic))
+	if len(vk.VkAlphabeta12) > 0 {
+		bw.write([]byte{1})
+	} else {
+		bw.write([]byte{0})
+	}
+	bw.g1(curveID, "vk.vk_alpha_1", vk.VkAlpha1)
+	bw.g2(curveID, "vk.vk_beta_2", vk.VkBeta2)
+	bw.g2(curveID, "vk.vk_gamma_2", vk.VkGamma2)
+	bw.g2(curveID, "vk.vk_delta_2", vk.VkDelta2)
+	bw.u32(uint32(len(vk.IC)))
+	for i := range vk.IC {
+		bw.g1(curveID, fmt.Sprintf("vk.IC[%d]", i), vk.IC[i])
+	}
+	return bw.n, bw.err
+}
+
+// ReadFrom reads the binary encoding of a verification key from r. It
+// implements io.ReaderFrom.
+func (vk *CircomVerificationKey) ReadFrom(r io.Reader) (int64, error) {
+	br := &binStreamReader{r: r, path: "vk"}
+	curveID, curveName := br.header(verificationKeyBinaryMagic)
+	if br.err == nil && curveName == "" {
+		br.err = newError(ErrWrongCurve, "vk.curve", "missing curve")
+	}
+	key := CircomVerificationKey{Protocol: "groth16", Curve: curveName}
+	key.NPublic = int(br.u32())
+	alphabeta := br.read(1)
+	key.VkAlpha1 = br.g1(curveID, "vk.vk_alpha_1")
+	key.VkBeta2 = br.g2(curveID, "vk.vk_beta_2")
+	key.VkGamma2 = br.g2(curveID, "vk.vk_gamma_2")
+	key.VkDelta2 = br.g2(curveID, "vk.vk_delta_2")
+	nIC := br.u32()
+	if br.err == nil && uint64(nIC) != uint64(key.NPublic)+1 {
+		br.err = newError(ErrNPublicMismatch, "vk.IC", "expected %d points, got %d", key.NPublic+1, nIC)
+	}
+	for i := uint32(0); i < nIC && br.err == nil; i++ {
+		key.IC = append(key.IC, br.g1(curveID, fmt.Sprintf("vk.IC[%d]", i)))
+	}
+	if br.err != nil {
+		return br.n, br.err
+	}
+	switch alphabeta[0] {
+	case 0:
+	case 1:
+		var err error
+		if key.VkAlphabeta12, err = alphabeta12(curveID, key.VkAlpha1, key.VkBeta2); err != nil {
+			return br.n, atPath("vk.vk_alphabeta_12", err)
+		}
+	default:
+		return br.n, newError(ErrMalformedBinary, "vk.vk_alphabeta_12", "invalid flag %d", alphabeta[0])
+	}
+	*vk = key
+	return br.n, nil
+}
+
+// MarshalBinary encodes the public signals in the compact binary format. The
+// signals must be canonical decimal strings of at most 256 bits.
+func (s CircomPublicSignals) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes public signals encoded with MarshalBinary.
+func (s *CircomPublicSignals) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, "publicSignals", s)
+}
+
+// WriteTo writes the binary encoding of the public signals to w. It
+// implements io.WriterTo.
+func (s CircomPublicSignals) WriteTo(w io.Writer) (int64, error) {
+	bound := new(big.Int).Lsh(big.NewInt(1), 8*publicSignalBinarySize)
+	values := make([]byte, 0, len(s)*publicSignalBinarySize)
+	for i, signal := range s {
+		v, err := stringToScalar(signal, bound)
+		if err != nil {
+			return 0, &Error{Kind: ErrInvalidScalar, Path: fmt.Sprintf("publicSignals[%d]", i), Err: err}
+		}
+		values = append(values, v.FillBytes(make([]byte, publicSignalBinarySize))...)
+	}
+	bw := &binWriter{w: w}
+	bw.header(publicSignalsBinaryMagic, binaryCurveNone)
+	bw.u32(uint32(len(s)))
+	bw.write(values)
+	return bw.n, bw.err
+}
+
+// ReadFrom reads the binary encoding of public signals from r. It implements
+// io.ReaderFrom.
+func (s *CircomPublicSignals) ReadFrom(r io.Reader) (int64, error) {
+	br := &binStreamReader{r: r, path: "publicSignals"}
+	br.header(publicSignalsBinaryMagic)
+	n := br.u32()
+	signals := CircomPublicSignals{}
+	for i := uint32(0); i < n && br.err == nil; i++ {
+		if b := br.read(publicSignalBinarySize); b != nil {
+			signals = append(signals, new(big.Int).SetBytes(b).String())
+		}
+	}
+	if br.err != nil {
+		return br.n, br.err
+	}
+	*s = signals
+	return br.n, nil
+}
+
+// unmarshalBinary reads v from data, which must not have trailing bytes. path
+// is the JSON path of v, for errors.
+func unmarshalBinary(data []byte, path string, v io.ReaderFrom) error {
+	r := bytes.NewReader(data)
+	if _, err := v.ReadFrom(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return newError(ErrMalformedBinary, path, "%d trailing bytes", r.Len())
+	}
+	return nil
+}
+
+// binaryCurve returns the curve byte and ID of a Circom curve name. An empty
+// name is only expected in proofs.
+func binaryCurve(path, name string) (byte, ecc.ID, error) {
+	if name == "" {
+		return binaryCurveNone, ecc.BN254, nil
+	}
+	curveID, err := circomCurveID(name)
+	if err != nil {
+		return 0, ecc.UNKNOWN, atPath(path, err)
+	}
+	if curveID == ecc.BLS12_381 {
+		return binaryCurveBLS12381, curveID, nil
+	}
+	return binaryCurveBN254, curveID, nil
+}
+
+// alphabeta12 computes vk_alphabeta_12 for the given curve.
+func alphabeta12(curveID ecc.ID, alpha []string, beta [][]string) ([][][]string, error) {
+	if curveID == ecc.BLS12_381 {
+		a, err := stringToG1BLS12381(alpha)
+		if err != nil {
+			return nil, err
+		}
+		b, err := stringToG2BLS12381(beta)
+		if err != nil {
+			return nil, err
+		}
+		return computeAlphabeta12BLS12381(*a, *b)
+	}
+	a, err := stringToG1(alpha)
+	if err != nil {
+		return nil, err
+	}
+	b, err := stringToG2(beta)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeAlphabeta12(*a, *b)
+}
+
+// binWriter writes big-endian values to w, counting the bytes written. The
+// first error is recorded in err and all subsequent writes are ignored.
+type binWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *binWriter) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *binWriter) u32(v uint32) {
+	w.write(binary.BigEndian.AppendUint32(nil, v))
+}
+
+func (w *binWriter) header(magic string, curveByte byte) {
+	w.write(append([]byte(magic), binaryVersion, curveByte))
+}
+
+func (w *binWriter) g1(curveID ecc.ID, path string, p []string) {
+	if w.err != nil {
+		return
+	}
+	b, err := compressG1(curveID, p)
+	if err != nil {
+		w.err = atPath(path, err)
+		return
+	}
+	w.write(b)
+}
+
+func (w *binWriter) g2(curveID ecc.ID, path string, p [][]string) {
+	if w.err != nil {
+		return
+	}
+	b, err := compressG2(curveID, p)
+	if err != nil {
+		w.err = atPath(path, err)
+		return
+	}
+	w.write(b)
+}
+
+// binStreamReader reads big-endian values from r, counting the bytes read.
+// Like binReader, it records the first error in err and all subsequent reads
+// return zero values. path is the JSON path of the decoded value, for errors.
+type binStreamReader struct {
+	r    io.Reader
+	path string
+	n    int64
+	err  error
+}
+
+// read returns the next n bytes, or nil if they cannot be read.
+func (r *binStreamReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	read, err := io.ReadFull(r.r, b)
+	r.n += int64(read)
+	if err != nil {
+		r.err = &Error{Kind: ErrMalformedBinary, Path: r.path, Err: err}
+		return nil
+	}
+	return b
+}
+
+func (r *binStreamReader) u32() uint32 {
+	b := r.read(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// header reads and checks the magic string and the version, and returns the
+// curve and its Circom name. The name is empty for encodings without curve,
+// which are taken as bn128.
+func (r *binStreamReader) header(magic string) (ecc.ID, string) {
+	b := r.read(len(magic) + 2)
+	if b == nil {
+		return ecc.UNKNOWN, ""
+	}
+	if got := string(b[:len(magic)]); got != magic {
+		r.err = newError(ErrMalformedBinary, r.path, "invalid encoding: expected %q, got %q", magic, got)
+		return ecc.UNKNOWN, ""
+	}
+	if version := b[len(magic)]; version != binaryVersion {
+		r.err = newError(ErrMalformedBinary, r.path, "unsupported encoding version %d", version)
+		return ecc.UNKNOWN, ""
+	}
+	switch b[len(magic)+1] {
+	case binaryCurveNone:
+		return ecc.BN254, ""
+	case binaryCurveBN254:
+		return ecc.BN254, circomCurveName(ecc.BN254)
+	case binaryCurveBLS12381:
+		return ecc.BLS12_381, circomCurveName(ecc.BLS12_381)
+	default:
+		r.err = newError(ErrWrongCurve, "", "unsupported curve %d", b[len(magic)+1])
+		return ecc.UNKNOWN, ""
+	}
+}
+
+func (r *binStreamReader) g1(curveID ecc.ID, path string) []string {
+	size := curve.SizeOfG1AffineCompressed
+	if curveID == ecc.BLS12_381 {
+		size = bls12381.SizeOfG1AffineCompressed
+	}
+	b := r.read(size)
+	if b == nil {
+		return nil
+	}
+	p, err := decompressG1(b)
+	if err != nil {
+		r.err = atPath(path, err)
+	}
+	return p
+}
+
+func (r *binStreamReader) g2(curveID ecc.ID, path string) [][]string {
+	size := curve.SizeOfG2AffineCompressed
+	if curveID == ecc.BLS12_381 {
+		size = bls12381.SizeOfG2AffineCompressed
+	}
+	b := r.read(size)
+	if b == nil {
+		return nil
+	}
+	p, err := decompressG2(b)
+	if err != nil {
+		r.err = atPath(path, err)
+	}
+	return p
+}
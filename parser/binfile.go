@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// binFile holds the sections of a file in the iden3 binary container format,
+// shared by the .zkey, .r1cs and .wtns files produced by Circom and SnarkJS.
+// The layout is: magic (4 bytes), version (uint32), number of sections
+// (uint32) and then, for each section, its type (uint32), its size (uint64)
+// and its content. All integers are little-endian.
+type binFile struct {
+	Version  uint32
+	Sections map[uint32][][]byte
+}
+
+// readBinFile parses the iden3 binary container, checking the magic string
+// and that the version is not greater than maxVersion.
+func readBinFile(data []byte, magic string, maxVersion uint32) (*binFile, error) {
+	r := &binReader{buf: data}
+	if got := string(r.read(4)); got != magic {
+		return nil, fmt.Errorf("invalid file type: expected %q, got %q", magic, got)
+	}
+	version := r.u32()
+	nSections := r.u32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if version == 0 || version > maxVersion {
+		return nil, fmt.Errorf("unsupported %s file version %d", magic, version)
+	}
+	f := &binFile{
+		Version:  version,
+		Sections: make(map[uint32][][]byte),
+	}
+	for i := uint32(0); i < nSections; i++ {
+		sType := r.u32()
+		sSize := r.u64()
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to read header of section %d: %w", i, r.err)
+		}
+		if sSize > uint64(r.remaining()) {
+			return nil, fmt.Errorf("section %d (type %d) is truncated", i, sType)
+		}
+		f.Sections[sType] = append(f.Sections[sType], r.read(int(sSize)))
+	}
+	return f, nil
+}
+
+// section returns the content of the section with the given type, which must
+// be present exactly once.
+func (f *binFile) section(sType uint32) (*binReader, error) {
+	s, ok := f.Sections[sType]
+	if !ok {
+		return nil, fmt.Errorf("missing section %d", sType)
+	}
+	if len(s) != 1 {
+		return nil, fmt.Errorf("section %d is duplicated", sType)
+	}
+	return &binReader{buf: s[0]}, nil
+}
+
+// binSection is a section to be written with writeBinFile.
+type binSection struct {
+	Type uint32
+	Data []byte
+}
+
+// writeBinFile encodes the given sections, in order, in the iden3 binary
+// container format.
+func writeBinFile(magic string, version uint32, sections []binSection) []byte {
+	size := 12
+	for _, s := range sections {
+		size += 12 + len(s.Data)
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, magic...)
+	buf = binary.LittleEndian.AppendUint32(buf, version)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(sections)))
+	for _, s := range sections {
+		buf = binary.LittleEndian.AppendUint32(buf, s.Type)
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(len(s.Data)))
+		buf = append(buf, s.Data...)
+	}
+	return buf
+}
+
+// binReader reads little-endian values from a byte slice. The first error is
+// recorded in err and all subsequent reads return zero values, so callers
+// only need to check err after a batch of reads.
+type binReader struct {
+	buf []byte
+	off int
+	err error
+}
+
+func (r *binReader) remaining() int {
+	return len(r.buf) - r.off
+}
+
+// read returns the next n bytes, or nil if there are not enough of them.
+func (r *binReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > r.remaining() {
+		r.err = fmt.Errorf("unexpected end of data: need %d bytes, have %d", n, r.remaining())
+		return nil
+	}
+	b := r.buf[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *binReader) u32() uint32 {
+	b := r.read(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *binReader) u64() uint64 {
+	b := r.read(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// cstring reads a zero-terminated string.
+func (r *binReader) cstring() string {
+	if r.err != nil {
+		return ""
+	}
+	for i := r.off; i < len(r.buf); i++ {
+		if r.buf[i] == 0 {
+			s := string(r.buf[r.off:i])
+			r.off = i + 1
+			return s
+		}
+	}
+	r.err = fmt.Errorf("unexpected end of data: unterminated string")
+	return ""
+}
+
+// count reads a uint32 element count and checks that at least count elements
+// of elemSize bytes are left, so corrupted counts do not trigger huge
+// allocations.
+func (r *binReader) count(elemSize int) int {
+	n := r.u32()
+	if r.err == nil && uint64(n)*uint64(elemSize) > uint64(r.remaining()) {
+		r.err = fmt.Errorf("invalid element count %d: not enough data", n)
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// bigIntToLEBytes encodes v as a little-endian unsigned integer of size bytes.
+func bigIntToLEBytes(v *big.Int, size int) []byte {
+	b := v.FillBytes(make([]byte, size))
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
+
+// leBytesToBigInt interprets b as a little-endian unsigned integer.
+func leBytesToBigInt(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
@@ -0,0 +1,375 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	bls12381fp "github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	bls12381fr "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	groth16_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+// ConvertCircomToGnarkBLS12381 converts a Circom proof, verification key, and
+// public signals over BLS12-381 (curve "bls12381" in SnarkJS) to the Gnark
+// proof format. The proof can be verified using the VerifyProofBLS12381()
+// function. Public signals are parsed with ConvertPublicInputsStrictBLS12381,
+// after checking the inputs with ValidateTriple.
+func ConvertCircomToGnarkBLS12381(circomVk *CircomVerificationKey,
+	circomProof *CircomProof, circomPublicSignals []string,
+) (*GnarkProofBLS12381, error) {
+	if err := validateTripleShape(circomVk, circomProof, circomPublicSignals); err != nil {
+		return nil, err
+	}
+	if err := checkCurve("vk", circomVk.Curve, ecc.BLS12_381); err != nil {
+		return nil, err
+	}
+	publicInputs, err := ConvertPublicInputsStrictBLS12381(circomPublicSignals)
+	if err != nil {
+		return nil, err
+	}
+	gnarkProof, err := convertProofBLS12381(circomProof)
+	if err != nil {
+		return nil, err
+	}
+	gnarkVk, err := convertVerificationKeyBLS12381(circomVk)
+	if err != nil {
+		return nil, err
+	}
+	return &GnarkProofBLS12381{
+		Proof:        gnarkProof,
+		VerifyingKey: gnarkVk,
+		PublicInputs: publicInputs,
+	}, nil
+}
+
+// ConvertPublicInputsBLS12381 parses an array of strings representing public
+// inputs into a slice of BLS12-381 scalar field elements. As with
+// ConvertPublicInputs, values are reduced modulo the scalar field.
+func ConvertPublicInputsBLS12381(publicSignals []string) ([]bls12381fr.Element, error) {
+	publicInputs := make([]bls12381fr.Element, len(publicSignals))
+	for i, s := range publicSignals {
+		bi, err := stringToBigInt(s)
+		if err != nil {
+			return nil, &Error{Kind: ErrInvalidScalar, Path: fmt.Sprintf("publicSignals[%d]", i), Err: err}
+		}
+		publicInputs[i].SetBigInt(bi)
+	}
+	return publicInputs, nil
+}
+
+// ConvertPublicInputsStrictBLS12381 is the BLS12-381 version of
+// ConvertPublicInputsStrict.
+func ConvertPublicInputsStrictBLS12381(publicSignals []string) ([]bls12381fr.Element, error) {
+	publicInputs := make([]bls12381fr.Element, len(publicSignals))
+	for i, s := range publicSignals {
+		bi, err := stringToScalar(s, bls12381fr.Modulus())
+		if err != nil {
+			return nil, &Error{Kind: ErrInvalidScalar, Path: fmt.Sprintf("publicSignals[%d]", i), Err: err}
+		}
+		publicInputs[i].SetBigInt(bi)
+	}
+	return publicInputs, nil
+}
+
+// ConvertProofBLS12381 converts a BLS12-381 CircomProof into a
+// Gnark-compatible Proof structure.
+func ConvertProofBLS12381(snarkProof *CircomProof) (*groth16_bls12381.Proof, error) {
+	if err := snarkProof.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkCurve("proof", snarkProof.Curve, ecc.BLS12_381); err != nil {
+		return nil, err
+	}
+	return convertProofBLS12381(snarkProof)
+}
+
+// convertProofBLS12381 converts a validated BLS12-381 CircomProof.
+func convertProofBLS12381(snarkProof *CircomProof) (*groth16_bls12381.Proof, error) {
+	arG1, err := stringToG1BLS12381(snarkProof.PiA)
+	if err != nil {
+		return nil, atPath("proof.pi_a", err)
+	}
+	krsG1, err := stringToG1BLS12381(snarkProof.PiC)
+	if err != nil {
+		return nil, atPath("proof.pi_c", err)
+	}
+	bsG2, err := stringToG2BLS12381(snarkProof.PiB)
+	if err != nil {
+		return nil, atPath("proof.pi_b", err)
+	}
+	return &groth16_bls12381.Proof{
+		Ar:  *arG1,
+		Krs: *krsG1,
+		Bs:  *bsG2,
+	}, nil
+}
+
+// ConvertVerificationKeyBLS12381 converts a BLS12-381 CircomVerificationKey
+// into a Gnark-compatible VerifyingKey structure.
+func ConvertVerificationKeyBLS12381(snarkVk *CircomVerificationKey) (*groth16_bls12381.VerifyingKey, error) {
+	if err := snarkVk.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkCurve("vk", snarkVk.Curve, ecc.BLS12_381); err != nil {
+		return nil, err
+	}
+	return convertVerificationKeyBLS12381(snarkVk)
+}
+
+// convertVerificationKeyBLS12381 converts a validated BLS12-381
+// CircomVerificationKey.
+func convertVerificationKeyBLS12381(snarkVk *CircomVerificationKey) (*groth16_bls12381.VerifyingKey, error) {
+	alphaG1, err := stringToG1BLS12381(snarkVk.VkAlpha1)
+	if err != nil {
+		return nil, atPath("vk.vk_alpha_1", err)
+	}
+	betaG2, err := stringToG2BLS12381(snarkVk.VkBeta2)
+	if err != nil {
+		return nil, atPath("vk.vk_beta_2", err)
+	}
+	gammaG2, err := stringToG2BLS12381(snarkVk.VkGamma2)
+	if err != nil {
+		return nil, atPath("vk.vk_gamma_2", err)
+	}
+	deltaG2, err := stringToG2BLS12381(snarkVk.VkDelta2)
+	if err != nil {
+		return nil, atPath("vk.vk_delta_2", err)
+	}
+	G1K := make([]bls12381.G1Affine, len(snarkVk.IC))
+	for i, icPoint := range snarkVk.IC {
+		icG1, err := stringToG1BLS12381(icPoint)
+		if err != nil {
+			return nil, atPath(fmt.Sprintf("vk.IC[%d]", i), err)
+		}
+		G1K[i] = *icG1
+	}
+
+	vk := &groth16_bls12381.VerifyingKey{}
+	vk.G1.Alpha = *alphaG1
+	vk.G1.K = G1K
+	vk.G2.Beta = *betaG2
+	vk.G2.Gamma = *gammaG2
+	vk.G2.Delta = *deltaG2
+	if err := vk.Precompute(); err != nil {
+		return nil, newError(ErrInternal, "", "failed to precompute verification key: %v", err)
+	}
+	return vk, nil
+}
+
+// VerifyProofBLS12381 verifies the BLS12-381 Gnark proof using the provided
+// verification key and public inputs. Errors are reported as in VerifyProof.
+func VerifyProofBLS12381(proof *GnarkProofBLS12381) (bool, error) {
+	if proof == nil || proof.Proof == nil || proof.VerifyingKey == nil {
+		return false, newError(ErrInternal, "", "missing proof or verification key")
+	}
+	if nPublic := nbPublicInputsBLS12381(proof.VerifyingKey); len(proof.PublicInputs) != nPublic {
+		return false, newError(ErrNPublicMismatch, "publicSignals", "expected %d public signals, got %d",
+			nPublic, len(proof.PublicInputs))
+	}
+	err := groth16_bls12381.Verify(proof.Proof, proof.VerifyingKey, proof.PublicInputs)
+	if err != nil {
+		return false, &Error{Kind: ErrVerificationFailed, Err: err}
+	}
+	return true, nil
+}
+
+// nbPublicInputsBLS12381 is nbPublicInputs for BLS12-381 keys.
+func nbPublicInputsBLS12381(vk *groth16_bls12381.VerifyingKey) int {
+	return len(vk.G1.K) - len(vk.PublicAndCommitmentCommitted) - 1
+}
+
+// PublicWitnessFromVectorBLS12381 creates a witness.Witness from a
+// []bls12381fr.Element holding only public inputs.
+func PublicWitnessFromVectorBLS12381(vec []bls12381fr.Element) (witness.Witness, error) {
+	w, err := witness.New(bls12381fr.Modulus())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create witness: %v", err)
+	}
+	ch := make(chan any, len(vec))
+	for _, e := range vec {
+		ch <- e
+	}
+	close(ch)
+	if err := w.Fill(len(vec), 0, ch); err != nil {
+		return nil, fmt.Errorf("failed to fill witness: %v", err)
+	}
+	return w, nil
+}
+
+// convertGnarkToCircomBLS12381 is ConvertGnarkToCircom for BLS12-381 proofs.
+func convertGnarkToCircomBLS12381(proof *groth16_bls12381.Proof, vk *groth16_bls12381.VerifyingKey, publicWitness witness.Witness) (*CircomProof, *CircomVerificationKey, []string, error) {
+	if vk == nil {
+		return nil, nil, nil, fmt.Errorf("VerifyingKey is nil")
+	}
+	vec, ok := publicWitness.Vector().(bls12381fr.Vector)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("expected public witness vector to be of type bls12381fr.Vector, got %T", publicWitness.Vector())
+	}
+
+	circomProof := &CircomProof{
+		PiA:      g1ToCircomStringBLS12381(&proof.Ar),
+		PiB:      g2ToCircomStringBLS12381(&proof.Bs),
+		PiC:      g1ToCircomStringBLS12381(&proof.Krs),
+		Protocol: "groth16",
+		Curve:    "bls12381",
+	}
+
+	ic := make([][]string, len(vk.G1.K))
+	for i := range vk.G1.K {
+		ic[i] = g1ToCircomStringBLS12381(&vk.G1.K[i])
+	}
+	if len(vec) == 0 && len(ic) > 1 {
+		ic = ic[:1]
+	}
+	alphabeta, err := computeAlphabeta12BLS12381(vk.G1.Alpha, vk.G2.Beta)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to compute vk_alphabeta_12: %w", err)
+	}
+	circomVk := &CircomVerificationKey{
+		Protocol:      "groth16",
+		Curve:         "bls12381",
+		NPublic:       len(vec),
+		VkAlpha1:      g1ToCircomStringBLS12381(&vk.G1.Alpha),
+		VkBeta2:       g2ToCircomStringBLS12381(&vk.G2.Beta),
+		VkGamma2:      g2ToCircomStringBLS12381(&vk.G2.Gamma),
+		VkDelta2:      g2ToCircomStringBLS12381(&vk.G2.Delta),
+		IC:            ic,
+		VkAlphabeta12: alphabeta,
+	}
+
+	publicSignals := make([]string, len(vec))
+	for i := range vec {
+		publicSignals[i] = vec[i].BigInt(new(big.Int)).String()
+	}
+	return circomProof, circomVk, publicSignals, nil
+}
+
+// stringToBLS12381Fp parses a decimal or hex string into a BLS12-381 base
+// field element (48 bytes), rejecting values that are not reduced.
+func stringToBLS12381Fp(s string) (bls12381fp.Element, error) {
+	var e bls12381fp.Element
+	if len(s) > maxCoordinateLength {
+		return e, fmt.Errorf("coordinate of %d characters is too long", len(s))
+	}
+	bi, err := stringToBigInt(s)
+	if err != nil {
+		return e, err
+	}
+	if bi.Sign() < 0 || bi.Cmp(bls12381fp.Modulus()) >= 0 {
+		return e, fmt.Errorf("coordinate %s is not a valid field element", s)
+	}
+	e.SetBigInt(bi)
+	return e, nil
+}
+
+// stringToG1BLS12381 parses a SnarkJS BLS12-381 G1 point [x, y, z] in
+// Jacobian coordinates, as stringToG1 does. The point must be on the curve
+// and in the prime order subgroup.
+func stringToG1BLS12381(h []string) (*bls12381.G1Affine, error) {
+	if len(h) != 3 { //nolint:gomnd
+		return nil, newError(ErrInvalidPoint, "", "expected 3 coordinates, got %d", len(h))
+	}
+	var p bls12381.G1Jac
+	for i, c := range []*bls12381fp.Element{&p.X, &p.Y, &p.Z} {
+		var err error
+		if *c, err = stringToBLS12381Fp(h[i]); err != nil {
+			return nil, &Error{Kind: ErrInvalidPoint, Path: fmt.Sprintf("[%d]", i), Err: err}
+		}
+	}
+	g1 := new(bls12381.G1Affine)
+	if p.Z.IsZero() {
+		return g1, nil // point at infinity
+	}
+	g1.FromJacobian(&p)
+	if !g1.IsOnCurve() {
+		return nil, newError(ErrInvalidPoint, "", "not on the curve")
+	}
+	if !g1.IsInSubGroup() {
+		return nil, newError(ErrInvalidPoint, "", "not in the prime order subgroup")
+	}
+	return g1, nil
+}
+
+// stringToG2BLS12381 parses a SnarkJS BLS12-381 G2 point
+// [[x.A0, x.A1], [y.A0, y.A1], z] in Jacobian coordinates, as stringToG2
+// does.
+func stringToG2BLS12381(h [][]string) (*bls12381.G2Affine, error) {
+	if len(h) != 3 { //nolint:gomnd
+		return nil, newError(ErrInvalidPoint, "", "expected 3 coordinates, got %d", len(h))
+	}
+	var p bls12381.G2Jac
+	for i, c := range []*bls12381.E2{&p.X, &p.Y, &p.Z} {
+		if len(h[i]) != 2 { //nolint:gomnd
+			return nil, newError(ErrInvalidPoint, fmt.Sprintf("[%d]", i), "expected 2 elements, got %d", len(h[i]))
+		}
+		for j, a := range []*bls12381fp.Element{&c.A0, &c.A1} {
+			var err error
+			if *a, err = stringToBLS12381Fp(h[i][j]); err != nil {
+				return nil, &Error{Kind: ErrInvalidPoint, Path: fmt.Sprintf("[%d][%d]", i, j), Err: err}
+			}
+		}
+	}
+	g2 := new(bls12381.G2Affine)
+	if p.Z.IsZero() {
+		return g2, nil // point at infinity
+	}
+	g2.FromJacobian(&p)
+	if !g2.IsOnCurve() {
+		return nil, newError(ErrInvalidPoint, "", "not on the curve")
+	}
+	if !g2.IsInSubGroup() {
+		return nil, newError(ErrInvalidPoint, "", "not in the prime order subgroup")
+	}
+	return g2, nil
+}
+
+// g1ToCircomStringBLS12381 converts a BLS12-381 G1Affine point to
+// [ X, Y, "1" ] in decimal, or [ "0", "1", "0" ] for the point at
+// infinity as in SnarkJS.
+func g1ToCircomStringBLS12381(p *bls12381.G1Affine) []string {
+	if p.IsInfinity() {
+		return []string{"0", "1", "0"}
+	}
+	return []string{
+		p.X.BigInt(new(big.Int)).String(),
+		p.Y.BigInt(new(big.Int)).String(),
+		"1",
+	}
+}
+
+// g2ToCircomStringBLS12381 converts a BLS12-381 G2Affine point to
+// [ [ X.A0, X.A1 ], [ Y.A0, Y.A1 ], [ "1", "0" ] ] in decimal, with the
+// SnarkJS encoding of the point at infinity.
+func g2ToCircomStringBLS12381(p *bls12381.G2Affine) [][]string {
+	if p.IsInfinity() {
+		return [][]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+	}
+	return [][]string{
+		{p.X.A0.BigInt(new(big.Int)).String(), p.X.A1.BigInt(new(big.Int)).String()},
+		{p.Y.A0.BigInt(new(big.Int)).String(), p.Y.A1.BigInt(new(big.Int)).String()},
+		{"1", "0"},
+	}
+}
+
+// computeAlphabeta12BLS12381 computes vk_alphabeta_12 = e(vk_alpha_1, vk_beta_2)
+// over BLS12-381, in the layout of ComputeAlphabeta12.
+func computeAlphabeta12BLS12381(alpha bls12381.G1Affine, beta bls12381.G2Affine) ([][][]string, error) {
+	gt, err := bls12381.Pair([]bls12381.G1Affine{alpha}, []bls12381.G2Affine{beta})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute pairing: %v", err)
+	}
+	out := make([][][]string, 2)
+	for i, c := range []*[3][2]*bls12381fp.Element{
+		{{&gt.C0.B0.A0, &gt.C0.B0.A1}, {&gt.C0.B1.A0, &gt.C0.B1.A1}, {&gt.C0.B2.A0, &gt.C0.B2.A1}},
+		{{&gt.C1.B0.A0, &gt.C1.B0.A1}, {&gt.C1.B1.A0, &gt.C1.B1.A1}, {&gt.C1.B2.A0, &gt.C1.B2.A1}},
+	} {
+		out[i] = make([][]string, 3)
+		for j := range c {
+			out[i][j] = []string{c[j][0].BigInt(new(big.Int)).String(), c[j][1].BigInt(new(big.Int)).String()}
+		}
+	}
+	return out, nil
+}
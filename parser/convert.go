@@ -2,30 +2,43 @@ package parser
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
 	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/backend/witness"
 )
 
 // ConvertCircomToGnark converts a Circom proof, verification key, and public
 // signals to the Gnark proof format. The proof can be verified using the
-// VerifyProof() function.
+// VerifyProof() function. Public signals are parsed with
+// ConvertPublicInputsStrict, after checking the inputs with ValidateTriple.
 func ConvertCircomToGnark(circomVk *CircomVerificationKey,
 	circomProof *CircomProof, circomPublicSignals []string,
 ) (*GnarkProof, error) {
+	if err := validateTripleShape(circomVk, circomProof, circomPublicSignals); err != nil {
+		return nil, err
+	}
+	if err := checkCurve("vk", circomVk.Curve, ecc.BN254); err != nil {
+		return nil, err
+	}
+
 	// Convert public signals to field elements
-	publicInputs, err := ConvertPublicInputs(circomPublicSignals)
+	publicInputs, err := ConvertPublicInputsStrict(circomPublicSignals)
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert the proof and verification key to gnark types
-	gnarkProof, err := ConvertProof(circomProof)
+	gnarkProof, err := convertProof(circomProof)
 	if err != nil {
 		return nil, err
 	}
-	gnarkVk, err := ConvertVerificationKey(circomVk)
+	gnarkVk, err := convertVerificationKey(circomVk)
 	if err != nil {
 		return nil, err
 	}
@@ -37,14 +50,92 @@ func ConvertCircomToGnark(circomVk *CircomVerificationKey,
 	}, nil
 }
 
+// ConvertCircomToGnarkGroth16 converts a Circom proof, verification key, and
+// public signals to Gnark's curve-agnostic Groth16 types, dispatching on the
+// curve of the verification key: bn128 (the default) or bls12381. The proof
+// can be verified using groth16.Verify().
+func ConvertCircomToGnarkGroth16(circomVk *CircomVerificationKey,
+	circomProof *CircomProof, circomPublicSignals []string,
+) (groth16.Proof, groth16.VerifyingKey, witness.Witness, error) {
+	curveID, err := circomCurveID(circomVk.Curve)
+	if err != nil {
+		return nil, nil, nil, atPath("vk.curve", err)
+	}
+	if circomProof.Curve != "" {
+		if proofCurveID, err := circomCurveID(circomProof.Curve); err != nil || proofCurveID != curveID {
+			return nil, nil, nil, newError(ErrWrongCurve, "proof.curve", "proof curve %q does not match verification key curve %q", circomProof.Curve, circomVk.Curve)
+		}
+	}
+	switch curveID {
+	case ecc.BLS12_381:
+		gnarkProof, err := ConvertCircomToGnarkBLS12381(circomVk, circomProof, circomPublicSignals)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		publicWitness, err := PublicWitnessFromVectorBLS12381(gnarkProof.PublicInputs)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return gnarkProof.Proof, gnarkProof.VerifyingKey, publicWitness, nil
+	default:
+		gnarkProof, err := ConvertCircomToGnark(circomVk, circomProof, circomPublicSignals)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		publicWitness, err := PublicWitnessFromVector(gnarkProof.PublicInputs)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return gnarkProof.Proof, gnarkProof.VerifyingKey, publicWitness, nil
+	}
+}
+
+// circomCurveID returns the curve of a SnarkJS curve name, which is
+// normalized as ffjavascript does (case and separators are ignored). An
+// empty name stands for bn128.
+func circomCurveID(name string) (ecc.ID, error) {
+	normalized := strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, name)
+	switch normalized {
+	case "", "BN128", "BN254", "ALTBN128":
+		return ecc.BN254, nil
+	case "BLS12381":
+		return ecc.BLS12_381, nil
+	default:
+		return ecc.UNKNOWN, newError(ErrWrongCurve, "", "unsupported curve %q", name)
+	}
+}
+
 // ConvertPublicInputs parses an array of strings representing public inputs
-// into a slice of bn254fr.Element.
+// into a slice of bn254fr.Element. Values are reduced modulo the scalar field,
+// so that x and x + r give the same element: use ConvertPublicInputsStrict to
+// verify proofs.
 func ConvertPublicInputs(publicSignals []string) ([]bn254fr.Element, error) {
 	publicInputs := make([]bn254fr.Element, len(publicSignals))
 	for i, s := range publicSignals {
 		bi, err := stringToBigInt(s)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse public input %d: %v", i, err)
+			return nil, &Error{Kind: ErrInvalidScalar, Path: fmt.Sprintf("publicSignals[%d]", i), Err: err}
+		}
+		publicInputs[i].SetBigInt(bi)
+	}
+	return publicInputs, nil
+}
+
+// ConvertPublicInputsStrict parses an array of strings representing public
+// inputs into a slice of bn254fr.Element, rejecting negative values, values
+// that are not lower than the scalar field modulus, and non-canonical
+// encodings such as leading zeros or hex strings.
+func ConvertPublicInputsStrict(publicSignals []string) ([]bn254fr.Element, error) {
+	publicInputs := make([]bn254fr.Element, len(publicSignals))
+	for i, s := range publicSignals {
+		bi, err := stringToScalar(s, bn254fr.Modulus())
+		if err != nil {
+			return nil, &Error{Kind: ErrInvalidScalar, Path: fmt.Sprintf("publicSignals[%d]", i), Err: err}
 		}
 		publicInputs[i].SetBigInt(bi)
 	}
@@ -53,20 +144,31 @@ func ConvertPublicInputs(publicSignals []string) ([]bn254fr.Element, error) {
 
 // ConvertProof converts a CircomProof into a Gnark-compatible Proof structure.
 func ConvertProof(snarkProof *CircomProof) (*groth16_bn254.Proof, error) {
+	if err := snarkProof.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkCurve("proof", snarkProof.Curve, ecc.BN254); err != nil {
+		return nil, err
+	}
+	return convertProof(snarkProof)
+}
+
+// convertProof converts a validated CircomProof.
+func convertProof(snarkProof *CircomProof) (*groth16_bn254.Proof, error) {
 	// Parse PiA (G1 point)
 	arG1, err := stringToG1(snarkProof.PiA)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert PiA: %v", err)
+		return nil, atPath("proof.pi_a", err)
 	}
 	// Parse PiC (G1 point)
 	krsG1, err := stringToG1(snarkProof.PiC)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert PiC: %v", err)
+		return nil, atPath("proof.pi_c", err)
 	}
 	// Parse PiB (G2 point)
 	bsG2, err := stringToG2(snarkProof.PiB)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert PiB: %v", err)
+		return nil, atPath("proof.pi_b", err)
 	}
 	// Construct the Proof
 	gnarkProof := &groth16_bn254.Proof{
@@ -81,25 +183,36 @@ func ConvertProof(snarkProof *CircomProof) (*groth16_bn254.Proof, error) {
 // ConvertVerificationKey converts a CircomVerificationKey into a
 // Gnark-compatible VerifyingKey structure.
 func ConvertVerificationKey(snarkVk *CircomVerificationKey) (*groth16_bn254.VerifyingKey, error) {
+	if err := snarkVk.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkCurve("vk", snarkVk.Curve, ecc.BN254); err != nil {
+		return nil, err
+	}
+	return convertVerificationKey(snarkVk)
+}
+
+// convertVerificationKey converts a validated CircomVerificationKey.
+func convertVerificationKey(snarkVk *CircomVerificationKey) (*groth16_bn254.VerifyingKey, error) {
 	// Parse vk_alpha_1 (G1 point)
 	alphaG1, err := stringToG1(snarkVk.VkAlpha1)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert VkAlpha1: %v", err)
+		return nil, atPath("vk.vk_alpha_1", err)
 	}
 	// Parse vk_beta_2 (G2 point)
 	betaG2, err := stringToG2(snarkVk.VkBeta2)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert VkBeta2: %v", err)
+		return nil, atPath("vk.vk_beta_2", err)
 	}
 	// Parse vk_gamma_2 (G2 point)
 	gammaG2, err := stringToG2(snarkVk.VkGamma2)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert VkGamma2: %v", err)
+		return nil, atPath("vk.vk_gamma_2", err)
 	}
 	// Parse vk_delta_2 (G2 point)
 	deltaG2, err := stringToG2(snarkVk.VkDelta2)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert VkDelta2: %v", err)
+		return nil, atPath("vk.vk_delta_2", err)
 	}
 
 	// Parse IC (G1 points for public inputs)
@@ -108,7 +221,7 @@ func ConvertVerificationKey(snarkVk *CircomVerificationKey) (*groth16_bn254.Veri
 	for i, icPoint := range snarkVk.IC {
 		icG1, err := stringToG1(icPoint)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert IC[%d]: %v", i, err)
+			return nil, atPath(fmt.Sprintf("vk.IC[%d]", i), err)
 		}
 		G1K[i] = *icG1
 	}
@@ -127,18 +240,54 @@ func ConvertVerificationKey(snarkVk *CircomVerificationKey) (*groth16_bn254.Veri
 
 	// Precompute the necessary values (e, gammaNeg, deltaNeg)
 	if err := vk.Precompute(); err != nil {
-		return nil, fmt.Errorf("failed to precompute verification key: %v", err)
+		return nil, newError(ErrInternal, "", "failed to precompute verification key: %v", err)
 	}
 
 	return vk, nil
 }
 
 // VerifyProof verifies the Gnark proof using the provided verification key and
-// public inputs.
+// public inputs. An invalid proof is reported as ErrVerificationFailed, and
+// malformed inputs as ErrNPublicMismatch or ErrInternal.
 func VerifyProof(proof *GnarkProof) (bool, error) {
+	if proof == nil || proof.Proof == nil || proof.VerifyingKey == nil {
+		return false, newError(ErrInternal, "", "missing proof or verification key")
+	}
+	if nPublic := nbPublicInputs(proof.VerifyingKey); len(proof.PublicInputs) != nPublic {
+		return false, newError(ErrNPublicMismatch, "publicSignals", "expected %d public signals, got %d",
+			nPublic, len(proof.PublicInputs))
+	}
 	err := groth16_bn254.Verify(proof.Proof, proof.VerifyingKey, proof.PublicInputs)
 	if err != nil {
-		return false, fmt.Errorf("proof verification failed: %v", err)
+		return false, &Error{Kind: ErrVerificationFailed, Err: err}
 	}
 	return true, nil
 }
+
+// nbPublicInputs returns the number of public inputs of a verification key:
+// K holds a point for the constant wire, one per public input and, for keys
+// with commitments, one per commitment.
+func nbPublicInputs(vk *groth16_bn254.VerifyingKey) int {
+	return len(vk.G1.K) - len(vk.PublicAndCommitmentCommitted) - 1
+}
+
+// checkCurve checks the curve of a proof or verification key, at the given
+// path. The curve of a proof may be empty, as SnarkJS does not always
+// set it.
+func checkCurve(path, curveName string, want ecc.ID) error {
+	if curveName == "" && path == "proof" {
+		return nil
+	}
+	if id, err := circomCurveID(curveName); err != nil || id != want {
+		return newError(ErrWrongCurve, path+".curve", "unexpected curve %q, expected %s", curveName, circomCurveName(want))
+	}
+	return nil
+}
+
+// circomCurveName returns the SnarkJS name of a curve.
+func circomCurveName(id ecc.ID) string {
+	if id == ecc.BLS12_381 {
+		return "bls12381"
+	}
+	return "bn128"
+}
@@ -0,0 +1,411 @@
+package parser
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+)
+
+// MarshalOption configures the JSON encoding of MarshalCircomProofJSON,
+// MarshalCircomVerificationKeyJSON and MarshalCircomPublicSignalsJSON. By
+// default, the output is indented and uses decimal strings, as SnarkJS does.
+// The Unmarshal functions accept all the encodings.
+type MarshalOption func(*marshalConfig)
+
+type marshalConfig struct {
+	hex        bool
+	compressed bool
+	curveField bool
+	compact    bool
+}
+
+// WithHexCoordinates encodes the coordinates and public signals as
+// 0x-prefixed hex strings instead of decimal strings.
+func WithHexCoordinates() MarshalOption {
+	return func(c *marshalConfig) { c.hex = true }
+}
+
+// WithCompressedPoints encodes each point as a single 0x-prefixed hex string
+// holding its gnark compressed encoding: ["0x..."] for G1 points and
+// [["0x..."]] for G2 points.
+func WithCompressedPoints() MarshalOption {
+	return func(c *marshalConfig) { c.compressed = true }
+}
+
+// WithCurveField sets the curve field of proofs, which SnarkJS omits, to
+// bn128 when it is empty.
+func WithCurveField() MarshalOption {
+	return func(c *marshalConfig) { c.curveField = true }
+}
+
+// WithCompactJSON outputs JSON without indentation.
+func WithCompactJSON() MarshalOption {
+	return func(c *marshalConfig) { c.compact = true }
+}
+
+func newMarshalConfig(opts []MarshalOption) *marshalConfig {
+	c := &marshalConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// marshal encodes v as JSON, indented unless the compact option is set.
+func (c *marshalConfig) marshal(v any) ([]byte, error) {
+	if c.compact {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// number encodes a decimal string as hex if the hex option is set.
+func (c *marshalConfig) number(s string) string {
+	if !c.hex {
+		return s
+	}
+	bi, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return s
+	}
+	return "0x" + bi.Text(16)
+}
+
+func (c *marshalConfig) numbers(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	out := make([]string, len(s))
+	for i := range s {
+		out[i] = c.number(s[i])
+	}
+	return out
+}
+
+func (c *marshalConfig) g1(curveID ecc.ID, p []string) ([]string, error) {
+	if !c.compressed {
+		return c.numbers(p), nil
+	}
+	b, err := compressG1(curveID, p)
+	if err != nil {
+		return nil, err
+	}
+	return []string{"0x" + hex.EncodeToString(b)}, nil
+}
+
+func (c *marshalConfig) g2(curveID ecc.ID, p [][]string) ([][]string, error) {
+	if !c.compressed {
+		if p == nil {
+			return nil, nil
+		}
+		out := make([][]string, len(p))
+		for i := range p {
+			out[i] = c.numbers(p[i])
+		}
+		return out, nil
+	}
+	b, err := compressG2(curveID, p)
+	if err != nil {
+		return nil, err
+	}
+	return [][]string{{"0x" + hex.EncodeToString(b)}}, nil
+}
+
+// compressG1 returns the gnark compressed encoding of a G1 point of the
+// given curve.
+func compressG1(curveID ecc.ID, p []string) ([]byte, error) {
+	if curveID == ecc.BLS12_381 {
+		g1, err := stringToG1BLS12381(p)
+		if err != nil {
+			return nil, err
+		}
+		b := g1.Bytes()
+		return b[:], nil
+	}
+	g1, err := stringToG1(p)
+	if err != nil {
+		return nil, err
+	}
+	b := g1.Bytes()
+	return b[:], nil
+}
+
+// compressG2 returns the gnark compressed encoding of a G2 point of the
+// given curve.
+func compressG2(curveID ecc.ID, p [][]string) ([]byte, error) {
+	if curveID == ecc.BLS12_381 {
+		g2, err := stringToG2BLS12381(p)
+		if err != nil {
+			return nil, err
+		}
+		b := g2.Bytes()
+		return b[:], nil
+	}
+	g2, err := stringToG2(p)
+	if err != nil {
+		return nil, err
+	}
+	b := g2.Bytes()
+	return b[:], nil
+}
+
+// curveID returns the curve of the points of a proof or verification key.
+// Only compressed points depend on the curve, so it is not resolved
+// otherwise.
+func (c *marshalConfig) curveID(path, curveName string) (ecc.ID, error) {
+	if !c.compressed {
+		return ecc.BN254, nil
+	}
+	curveID, err := circomCurveID(curveName)
+	if err != nil {
+		return ecc.UNKNOWN, atPath(path, err)
+	}
+	return curveID, nil
+}
+
+// encodeProof returns a copy of the proof in the configured encoding.
+func (c *marshalConfig) encodeProof(proof *CircomProof) (*CircomProof, error) {
+	if proof == nil {
+		return nil, newError(ErrInternal, "proof", "nil proof")
+	}
+	out := *proof
+	if c.curveField && out.Curve == "" {
+		out.Curve = "bn128"
+	}
+	curveID, err := c.curveID("proof.curve", proof.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if out.PiA, err = c.g1(curveID, proof.PiA); err != nil {
+		return nil, atPath("proof.pi_a", err)
+	}
+	if out.PiB, err = c.g2(curveID, proof.PiB); err != nil {
+		return nil, atPath("proof.pi_b", err)
+	}
+	if out.PiC, err = c.g1(curveID, proof.PiC); err != nil {
+		return nil, atPath("proof.pi_c", err)
+	}
+	return &out, nil
+}
+
+// encodeVerificationKey returns a copy of the verification key in the
+// configured encoding. vk_alphabeta_12 is not a curve point and is never
+// compressed.
+func (c *marshalConfig) encodeVerificationKey(vk *CircomVerificationKey) (*CircomVerificationKey, error) {
+	if vk == nil {
+		return nil, newError(ErrInternal, "vk", "nil verification key")
+	}
+	out := *vk
+	curveID, err := c.curveID("vk.curve", vk.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if out.VkAlpha1, err = c.g1(curveID, vk.VkAlpha1); err != nil {
+		return nil, atPath("vk.vk_alpha_1", err)
+	}
+	if out.VkBeta2, err = c.g2(curveID, vk.VkBeta2); err != nil {
+		return nil, atPath("vk.vk_beta_2", err)
+	}
+	if out.VkGamma2, err = c.g2(curveID, vk.VkGamma2); err != nil {
+		return nil, atPath("vk.vk_gamma_2", err)
+	}
+	if out.VkDelta2, err = c.g2(curveID, vk.VkDelta2); err != nil {
+		return nil, atPath("vk.vk_delta_2", err)
+	}
+	if vk.IC != nil {
+		out.IC = make([][]string, len(vk.IC))
+	}
+	for i := range vk.IC {
+		if out.IC[i], err = c.g1(curveID, vk.IC[i]); err != nil {
+			return nil, atPath(fmt.Sprintf("vk.IC[%d]", i), err)
+		}
+	}
+	if vk.VkAlphabeta12 != nil {
+		out.VkAlphabeta12 = make([][][]string, len(vk.VkAlphabeta12))
+		for i := range vk.VkAlphabeta12 {
+			out.VkAlphabeta12[i] = make([][]string, len(vk.VkAlphabeta12[i]))
+			for j := range vk.VkAlphabeta12[i] {
+				out.VkAlphabeta12[i][j] = c.numbers(vk.VkAlphabeta12[i][j])
+			}
+		}
+	}
+	return &out, nil
+}
+
+// decodeProof converts the points and numbers of an unmarshaled proof to
+// the SnarkJS encoding: compressed points are decompressed to affine
+// coordinates and hex strings are converted to decimal strings.
+func decodeProof(proof *CircomProof) error {
+	var err error
+	if proof.PiA, err = decodeG1(proof.PiA); err != nil {
+		return atPath("proof.pi_a", err)
+	}
+	if proof.PiB, err = decodeG2(proof.PiB); err != nil {
+		return atPath("proof.pi_b", err)
+	}
+	if proof.PiC, err = decodeG1(proof.PiC); err != nil {
+		return atPath("proof.pi_c", err)
+	}
+	return nil
+}
+
+// decodeVerificationKey converts the points and numbers of an unmarshaled
+// verification key to the SnarkJS encoding, as decodeProof does.
+func decodeVerificationKey(vk *CircomVerificationKey) error {
+	var err error
+	if vk.VkAlpha1, err = decodeG1(vk.VkAlpha1); err != nil {
+		return atPath("vk.vk_alpha_1", err)
+	}
+	for _, p := range []struct {
+		path  string
+		point *[][]string
+	}{
+		{"vk.vk_beta_2", &vk.VkBeta2},
+		{"vk.vk_gamma_2", &vk.VkGamma2},
+		{"vk.vk_delta_2", &vk.VkDelta2},
+	} {
+		if *p.point, err = decodeG2(*p.point); err != nil {
+			return atPath(p.path, err)
+		}
+	}
+	for i := range vk.IC {
+		if vk.IC[i], err = decodeG1(vk.IC[i]); err != nil {
+			return atPath(fmt.Sprintf("vk.IC[%d]", i), err)
+		}
+	}
+	for i := range vk.VkAlphabeta12 {
+		for j := range vk.VkAlphabeta12[i] {
+			decodeNumbers(vk.VkAlphabeta12[i][j])
+		}
+	}
+	return nil
+}
+
+// decodeG1 decompresses a G1 point encoded as ["0x..."], or converts the hex
+// coordinates of a point to decimal. The curve is given by the length of the
+// compressed encoding.
+func decodeG1(p []string) ([]string, error) {
+	if len(p) != 1 || !strings.HasPrefix(p[0], "0x") {
+		decodeNumbers(p)
+		return p, nil
+	}
+	b, err := decodeHexBytes(p[0])
+	if err != nil {
+		return nil, err
+	}
+	return decompressG1(b)
+}
+
+// decompressG1 decodes a compressed G1 point, of the curve given by its
+// length, into its Circom representation.
+func decompressG1(b []byte) ([]string, error) {
+	switch len(b) {
+	case curve.SizeOfG1AffineCompressed:
+		var g1 curve.G1Affine
+		if _, err := g1.SetBytes(b); err != nil {
+			return nil, newError(ErrInvalidPoint, "", "failed to decompress point: %v", err)
+		}
+		return g1ToCircomString(&g1)
+	case bls12381.SizeOfG1AffineCompressed:
+		var g1 bls12381.G1Affine
+		if _, err := g1.SetBytes(b); err != nil {
+			return nil, newError(ErrInvalidPoint, "", "failed to decompress point: %v", err)
+		}
+		return g1ToCircomStringBLS12381(&g1), nil
+	default:
+		return nil, newError(ErrInvalidPoint, "", "invalid compressed G1 point of %d bytes", len(b))
+	}
+}
+
+// decodeG2 decompresses a G2 point encoded as [["0x..."]], or converts the
+// hex coordinates of a point to decimal.
+func decodeG2(p [][]string) ([][]string, error) {
+	if len(p) != 1 || len(p[0]) != 1 || !strings.HasPrefix(p[0][0], "0x") {
+		for _, c := range p {
+			decodeNumbers(c)
+		}
+		return p, nil
+	}
+	b, err := decodeHexBytes(p[0][0])
+	if err != nil {
+		return nil, err
+	}
+	return decompressG2(b)
+}
+
+// decompressG2 decodes a compressed G2 point, of the curve given by its
+// length, into its Circom representation.
+func decompressG2(b []byte) ([][]string, error) {
+	switch len(b) {
+	case curve.SizeOfG2AffineCompressed:
+		var g2 curve.G2Affine
+		if _, err := g2.SetBytes(b); err != nil {
+			return nil, newError(ErrInvalidPoint, "", "failed to decompress point: %v", err)
+		}
+		return g2ToCircomString(&g2)
+	case bls12381.SizeOfG2AffineCompressed:
+		var g2 bls12381.G2Affine
+		if _, err := g2.SetBytes(b); err != nil {
+			return nil, newError(ErrInvalidPoint, "", "failed to decompress point: %v", err)
+		}
+		return g2ToCircomStringBLS12381(&g2), nil
+	default:
+		return nil, newError(ErrInvalidPoint, "", "invalid compressed G2 point of %d bytes", len(b))
+	}
+}
+
+// decodeHexBytes decodes a 0x-prefixed hex string.
+func decodeHexBytes(s string) ([]byte, error) {
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, newError(ErrInvalidPoint, "", "invalid compressed point: %v", err)
+	}
+	return b, nil
+}
+
+// decodeNumbers converts the 0x-prefixed hex strings of s to decimal strings
+// in place. Invalid strings are left as they are, to be reported on
+// conversion.
+func decodeNumbers(s []string) {
+	for i, v := range s {
+		if len(v) > 2 && len(v) <= maxCoordinateLength && v[:2] == "0x" {
+			if bi, ok := new(big.Int).SetString(v[2:], 16); ok && bi.Sign() >= 0 {
+				s[i] = bi.String()
+			}
+		}
+	}
+}
+
+// decodePublicSignals converts hex public signals to decimal strings in
+// place. Hex signals are only accepted in the canonical form output by
+// WithHexCoordinates: all the signals 0x-prefixed, in lowercase and without
+// leading zeros, so that each field element still has a single encoding.
+func decodePublicSignals(s []string) error {
+	hexSignals := false
+	for _, v := range s {
+		if strings.HasPrefix(v, "0x") {
+			hexSignals = true
+			break
+		}
+	}
+	if !hexSignals {
+		return nil
+	}
+	for i, v := range s {
+		digits := strings.TrimPrefix(v, "0x")
+		if digits == v || len(v) > maxCoordinateLength || !isDigits(digits, "0123456789abcdef") ||
+			(len(digits) > 1 && digits[0] == '0') {
+			return newError(ErrInvalidScalar, fmt.Sprintf("publicSignals[%d]", i),
+				"%q is not a canonical hex number, as all the public signals must be", v)
+		}
+		bi, _ := new(big.Int).SetString(digits, 16)
+		s[i] = bi.String()
+	}
+	return nil
+}
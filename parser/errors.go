@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors returned by the parsing, conversion and verification
+// functions. They are wrapped in an *Error, and can be matched with
+// errors.Is.
+var (
+	// ErrMalformedJSON is returned when the JSON data cannot be decoded.
+	ErrMalformedJSON = errors.New("malformed JSON")
+	// ErrMalformedBinary is returned when binary data, such as the binary
+	// encoding of a proof or Solidity calldata, cannot be decoded.
+	ErrMalformedBinary = errors.New("malformed binary data")
+	// ErrUnknownArtifact is returned when the kind of an artifact cannot be
+	// detected.
+	ErrUnknownArtifact = errors.New("unknown artifact")
+	// ErrWrongProtocol is returned for a proof or a verification key of an
+	// unexpected protocol.
+	ErrWrongProtocol = errors.New("wrong protocol")
+	// ErrWrongCurve is returned for a proof or a verification key of an
+	// unexpected or unsupported curve.
+	ErrWrongCurve = errors.New("wrong curve")
+	// ErrInvalidPoint is returned for a curve point that is malformed, not
+	// reduced, not on the curve or not in the prime order subgroup.
+	ErrInvalidPoint = errors.New("invalid point")
+	// ErrInvalidScalar is returned for a public signal or a proof evaluation
+	// that is malformed or not a scalar field element.
+	ErrInvalidScalar = errors.New("invalid scalar")
+	// ErrNPublicMismatch is returned when the number of public signals does
+	// not match the verification key.
+	ErrNPublicMismatch = errors.New("number of public signals mismatch")
+	// ErrUnknownKey is returned when a verification key is not registered
+	// in a VerifierRegistry.
+	ErrUnknownKey = errors.New("unknown verification key")
+	// ErrVerificationFailed is returned when well-formed inputs do not
+	// verify.
+	ErrVerificationFailed = errors.New("proof verification failed")
+	// ErrVerifierMismatch is returned by a DifferentialVerifier when the
+	// verifiers disagree.
+	ErrVerifierMismatch = errors.New("verifiers disagree")
+	// ErrInternal is returned for any other failure.
+	ErrInternal = errors.New("internal error")
+)
+
+// Error is the error returned by the parsing, conversion and verification
+// functions. Kind is one of the Err* variables above, and Path is the JSON
+// path of the offending field, such as vk.IC[3][1] or proof.pi_b[0][1], if
+// any.
+type Error struct {
+	Kind error
+	Path string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the kind and the cause of the error, so that errors.Is
+// matches both.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// newError returns an *Error of the given kind and path, with a formatted
+// cause.
+func newError(kind error, path string, format string, args ...any) *Error {
+	return &Error{Kind: kind, Path: path, Err: fmt.Errorf(format, args...)}
+}
+
+// atPath prefixes the path of err with path, so that the helpers can report
+// paths relative to the value they parse, e.g. "[1]" for a coordinate of a
+// point. Errors that are not an *Error are reported as ErrInternal.
+func atPath(path string, err error) error {
+	if e, ok := err.(*Error); ok {
+		return &Error{Kind: e.Kind, Path: path + e.Path, Err: e.Err}
+	}
+	return &Error{Kind: ErrInternal, Path: path, Err: err}
+}
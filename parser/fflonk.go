@@ -0,0 +1,469 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// fflonkVerifyingKey is a SnarkJS FFLONK verification key decoded into curve
+// points and field elements.
+type fflonkVerifyingKey struct {
+	nPublic int
+	power   int
+	k1, k2  bn254fr.Element
+	omega   bn254fr.Element // ω, root of unity of the domain
+	w3      bn254fr.Element // cube root of unity
+	w4      bn254fr.Element // 4th root of unity
+	w8      bn254fr.Element // 8th root of unity
+	wr      bn254fr.Element // cube root of ω
+
+	c0 curve.G1Affine
+	x2 curve.G2Affine
+}
+
+// fflonkProof is a SnarkJS FFLONK proof decoded into curve points and field
+// elements.
+type fflonkProof struct {
+	c1, c2, w1, w2 curve.G1Affine
+
+	ql, qr, qm, qo, qc bn254fr.Element
+	s1, s2, s3         bn254fr.Element
+	a, b, c            bn254fr.Element
+	z, zw, t1w, t2w    bn254fr.Element
+}
+
+// fflonkChallenges holds the Fiat-Shamir challenges of an FFLONK proof, and
+// the opening points derived from them.
+type fflonkChallenges struct {
+	beta, gamma, alpha, y bn254fr.Element
+	xiSeed, xi, xin, zh   bn254fr.Element
+
+	h0w8 [8]bn254fr.Element // 8th roots of ξ, opening points of C0
+	h1w4 [4]bn254fr.Element // 4th roots of ξ, opening points of C1
+	h2w3 [3]bn254fr.Element // cube roots of ξ, opening points of C2
+	h3w3 [3]bn254fr.Element // cube roots of ξω, opening points of C2
+}
+
+// VerifyFflonkProof verifies a SnarkJS FFLONK proof over bn254 with the given
+// verification key and public signals, as `snarkjs fflonk verify` does. It
+// returns an error if the inputs are malformed or the proof is invalid.
+//
+// The inv evaluation of the proof, which the Solidity verifier uses to batch
+// its inversions, is parsed but not needed here.
+func VerifyFflonkProof(circomVk *CircomFflonkVerificationKey, circomProof *CircomFflonkProof, publicSignals []string) (bool, error) {
+	vk, err := convertFflonkVerificationKey(circomVk)
+	if err != nil {
+		return false, err
+	}
+	proof, err := convertFflonkProof(circomProof)
+	if err != nil {
+		return false, err
+	}
+	if len(publicSignals) != vk.nPublic {
+		return false, newError(ErrNPublicMismatch, "publicSignals", "expected %d public signals, got %d", vk.nPublic, len(publicSignals))
+	}
+	publicInputs, err := ConvertPublicInputsStrict(publicSignals)
+	if err != nil {
+		return false, err
+	}
+	if err := verifyFflonk(vk, proof, publicInputs); err != nil {
+		return false, &Error{Kind: ErrVerificationFailed, Err: err}
+	}
+	return true, nil
+}
+
+// convertFflonkVerificationKey decodes a CircomFflonkVerificationKey and
+// checks its roots of unity.
+func convertFflonkVerificationKey(circomVk *CircomFflonkVerificationKey) (*fflonkVerifyingKey, error) {
+	if circomVk.Protocol != "fflonk" {
+		return nil, newError(ErrWrongProtocol, "vk.protocol", "unexpected protocol %q, expected fflonk", circomVk.Protocol)
+	}
+	if err := checkCurve("vk", circomVk.Curve, ecc.BN254); err != nil {
+		return nil, err
+	}
+	if circomVk.NPublic < 0 {
+		return nil, newError(ErrNPublicMismatch, "vk.nPublic", "invalid number of public signals %d", circomVk.NPublic)
+	}
+	if circomVk.Power < 1 || circomVk.Power > 28 { // 2-adicity of the bn254 scalar field
+		return nil, newError(ErrInvalidScalar, "vk.power", "invalid domain power %d", circomVk.Power)
+	}
+	vk := &fflonkVerifyingKey{nPublic: circomVk.NPublic, power: circomVk.Power}
+	for _, s := range []struct {
+		name string
+		in   string
+		out  *bn254fr.Element
+	}{
+		{"k1", circomVk.K1, &vk.k1},
+		{"k2", circomVk.K2, &vk.k2},
+		{"w", circomVk.W, &vk.omega},
+		{"w3", circomVk.W3, &vk.w3},
+		{"w4", circomVk.W4, &vk.w4},
+		{"w8", circomVk.W8, &vk.w8},
+		{"wr", circomVk.Wr, &vk.wr},
+	} {
+		var err error
+		if *s.out, err = stringToFr(s.in); err != nil {
+			return nil, &Error{Kind: ErrInvalidScalar, Path: "vk." + s.name, Err: err}
+		}
+	}
+
+	// ω, w4 and w8 are primitive roots of unity of order 2^k when their
+	// 2^(k-1) power is -1; w3 is a primitive cube root of unity, and wr³ = ω.
+	var minusOne, one, tmp bn254fr.Element
+	one.SetOne()
+	minusOne.Neg(&one)
+	isPrimitive := func(w bn254fr.Element, log int) bool {
+		for i := 0; i < log-1; i++ {
+			w.Square(&w)
+		}
+		return w.Equal(&minusOne)
+	}
+	if !isPrimitive(vk.omega, vk.power) {
+		return nil, newError(ErrInvalidScalar, "vk.w", "w is not a primitive root of unity of order 2^%d", vk.power)
+	}
+	if !isPrimitive(vk.w4, 2) {
+		return nil, newError(ErrInvalidScalar, "vk.w4", "w4 is not a primitive 4th root of unity")
+	}
+	if !isPrimitive(vk.w8, 3) {
+		return nil, newError(ErrInvalidScalar, "vk.w8", "w8 is not a primitive 8th root of unity")
+	}
+	tmp.Square(&vk.w3).Mul(&tmp, &vk.w3)
+	if vk.w3.Equal(&one) || !tmp.Equal(&one) {
+		return nil, newError(ErrInvalidScalar, "vk.w3", "w3 is not a primitive cube root of unity")
+	}
+	tmp.Square(&vk.wr).Mul(&tmp, &vk.wr)
+	if !tmp.Equal(&vk.omega) {
+		return nil, newError(ErrInvalidScalar, "vk.wr", "wr is not a cube root of w")
+	}
+
+	c0, err := stringToG1(circomVk.C0)
+	if err != nil {
+		return nil, atPath("vk.C0", err)
+	}
+	vk.c0 = *c0
+	x2, err := stringToG2(circomVk.X2)
+	if err != nil {
+		return nil, atPath("vk.X_2", err)
+	}
+	vk.x2 = *x2
+	return vk, nil
+}
+
+// convertFflonkProof decodes a CircomFflonkProof.
+func convertFflonkProof(circomProof *CircomFflonkProof) (*fflonkProof, error) {
+	if circomProof.Protocol != "" && circomProof.Protocol != "fflonk" {
+		return nil, newError(ErrWrongProtocol, "proof.protocol", "unexpected protocol %q, expected fflonk", circomProof.Protocol)
+	}
+	proof := &fflonkProof{}
+	pols := &circomProof.Polynomials
+	for _, p := range []struct {
+		name string
+		in   []string
+		out  *curve.G1Affine
+	}{
+		{"C1", pols.C1, &proof.c1},
+		{"C2", pols.C2, &proof.c2},
+		{"W1", pols.W1, &proof.w1},
+		{"W2", pols.W2, &proof.w2},
+	} {
+		g1, err := stringToG1(p.in)
+		if err != nil {
+			return nil, atPath("proof.polynomials."+p.name, err)
+		}
+		*p.out = *g1
+	}
+	evals := &circomProof.Evaluations
+	for _, s := range []struct {
+		name string
+		in   string
+		out  *bn254fr.Element
+	}{
+		{"ql", evals.Ql, &proof.ql},
+		{"qr", evals.Qr, &proof.qr},
+		{"qm", evals.Qm, &proof.qm},
+		{"qo", evals.Qo, &proof.qo},
+		{"qc", evals.Qc, &proof.qc},
+		{"s1", evals.S1, &proof.s1},
+		{"s2", evals.S2, &proof.s2},
+		{"s3", evals.S3, &proof.s3},
+		{"a", evals.A, &proof.a},
+		{"b", evals.B, &proof.b},
+		{"c", evals.C, &proof.c},
+		{"z", evals.Z, &proof.z},
+		{"zw", evals.Zw, &proof.zw},
+		{"t1w", evals.T1w, &proof.t1w},
+		{"t2w", evals.T2w, &proof.t2w},
+	} {
+		var err error
+		if *s.out, err = stringToFr(s.in); err != nil {
+			return nil, &Error{Kind: ErrInvalidScalar, Path: "proof.evaluations." + s.name, Err: err}
+		}
+	}
+	if _, err := stringToFr(evals.Inv); err != nil {
+		return nil, &Error{Kind: ErrInvalidScalar, Path: "proof.evaluations.inv", Err: err}
+	}
+	return proof, nil
+}
+
+// verifyFflonk implements the verifier of the SnarkJS FFLONK protocol
+// (fflonk_verify.js). The proof commits to
+//
+//	C0(X) = QL(X⁸) + X·QR(X⁸) + X²·QO(X⁸) + X³·QM(X⁸) + X⁴·QC(X⁸) + X⁵·S1(X⁸) + X⁶·S2(X⁸) + X⁷·S3(X⁸)
+//	C1(X) = A(X⁴) + X·B(X⁴) + X²·C(X⁴) + X³·T0(X⁴)
+//	C2(X) = Z(X³) + X·T1(X³) + X²·T2(X³)
+//
+// opened with a single batched KZG proof (W1, W2) at the 8th roots of ξ for
+// C0, the 4th roots of ξ for C1, and the cube roots of ξ and ξω for C2.
+func verifyFflonk(vk *fflonkVerifyingKey, proof *fflonkProof, publicInputs []bn254fr.Element) error {
+	ch, err := fflonkComputeChallenges(vk, proof, publicInputs)
+	if err != nil {
+		return err
+	}
+	l := lagrangeEvaluations(vk.nPublic, vk.power, &vk.omega, &ch.xi, &ch.zh)
+
+	// PI(ξ) = -Σ wᵢ·Lᵢ(ξ)
+	var pi, tmp bn254fr.Element
+	for i := range publicInputs {
+		tmp.Mul(&publicInputs[i], &l[i+1])
+		pi.Sub(&pi, &tmp)
+	}
+	var invZh bn254fr.Element
+	invZh.Inverse(&ch.zh)
+
+	// r0: interpolation of C0 on the 8th roots of ξ
+	var r0 bn254fr.Element
+	{
+		coeffs := []*bn254fr.Element{&proof.ql, &proof.qr, &proof.qo, &proof.qm, &proof.qc, &proof.s1, &proof.s2, &proof.s3}
+		values := make([]bn254fr.Element, len(ch.h0w8))
+		for i := range ch.h0w8 {
+			values[i] = evalFolded(coeffs, &ch.h0w8[i])
+		}
+		r0 = interpolateAt(ch.h0w8[:], values, &ch.y)
+	}
+
+	// r1: interpolation of C1 on the 4th roots of ξ, with
+	// T0(ξ) = (qL·a + qR·b + qM·a·b + qO·c + qC + PI(ξ)) / Zh(ξ)
+	var r1 bn254fr.Element
+	{
+		var t0 bn254fr.Element
+		t0.Mul(&proof.ql, &proof.a)
+		tmp.Mul(&proof.qr, &proof.b)
+		t0.Add(&t0, &tmp)
+		tmp.Mul(&proof.qm, &proof.a).Mul(&tmp, &proof.b)
+		t0.Add(&t0, &tmp)
+		tmp.Mul(&proof.qo, &proof.c)
+		t0.Add(&t0, &tmp).Add(&t0, &proof.qc).Add(&t0, &pi).Mul(&t0, &invZh)
+
+		coeffs := []*bn254fr.Element{&proof.a, &proof.b, &proof.c, &t0}
+		values := make([]bn254fr.Element, len(ch.h1w4))
+		for i := range ch.h1w4 {
+			values[i] = evalFolded(coeffs, &ch.h1w4[i])
+		}
+		r1 = interpolateAt(ch.h1w4[:], values, &ch.y)
+	}
+
+	// r2: interpolation of C2 on the cube roots of ξ and ξω, with
+	// T1(ξ) = (z(ξ)-1)·L₁(ξ) / Zh(ξ) and
+	// T2(ξ) = ((a+βξ+γ)(b+βk₁ξ+γ)(c+βk₂ξ+γ)·z(ξ) - (a+βs₁+γ)(b+βs₂+γ)(c+βs₃+γ)·z(ξω)) / Zh(ξ)
+	var r2 bn254fr.Element
+	{
+		var one, t1, t2, betaxi, e1, e2 bn254fr.Element
+		one.SetOne()
+		t1.Sub(&proof.z, &one).Mul(&t1, &l[1]).Mul(&t1, &invZh)
+
+		betaxi.Mul(&ch.beta, &ch.xi)
+		e1.Add(&proof.a, &betaxi).Add(&e1, &ch.gamma)
+		tmp.Mul(&betaxi, &vk.k1).Add(&tmp, &proof.b).Add(&tmp, &ch.gamma)
+		e1.Mul(&e1, &tmp)
+		tmp.Mul(&betaxi, &vk.k2).Add(&tmp, &proof.c).Add(&tmp, &ch.gamma)
+		e1.Mul(&e1, &tmp).Mul(&e1, &proof.z)
+		e2.Mul(&ch.beta, &proof.s1).Add(&e2, &proof.a).Add(&e2, &ch.gamma)
+		tmp.Mul(&ch.beta, &proof.s2).Add(&tmp, &proof.b).Add(&tmp, &ch.gamma)
+		e2.Mul(&e2, &tmp)
+		tmp.Mul(&ch.beta, &proof.s3).Add(&tmp, &proof.c).Add(&tmp, &ch.gamma)
+		e2.Mul(&e2, &tmp).Mul(&e2, &proof.zw)
+		t2.Sub(&e1, &e2).Mul(&t2, &invZh)
+
+		points := make([]bn254fr.Element, 0, 6)
+		points = append(append(points, ch.h2w3[:]...), ch.h3w3[:]...)
+		values := make([]bn254fr.Element, 0, 6)
+		for i := range ch.h2w3 {
+			values = append(values, evalFolded([]*bn254fr.Element{&proof.z, &t1, &t2}, &ch.h2w3[i]))
+		}
+		for i := range ch.h3w3 {
+			values = append(values, evalFolded([]*bn254fr.Element{&proof.zw, &proof.t1w, &proof.t2w}, &ch.h3w3[i]))
+		}
+		r2 = interpolateAt(points, values, &ch.y)
+	}
+
+	// Vanishing polynomials of the opening sets at y:
+	// Z_S0(y) = y⁸-ξ, Z_S1(y) = y⁴-ξ, Z_S2(y) = (y³-ξ)(y³-ξω)
+	var y3, y4, zs0, zs1, zs2, xiw bn254fr.Element
+	y3.Square(&ch.y).Mul(&y3, &ch.y)
+	y4.Square(&ch.y).Square(&y4)
+	zs0.Square(&y4).Sub(&zs0, &ch.xi)
+	zs1.Sub(&y4, &ch.xi)
+	xiw.Mul(&ch.xi, &vk.omega)
+	zs2.Sub(&y3, &ch.xi)
+	tmp.Sub(&y3, &xiw)
+	zs2.Mul(&zs2, &tmp)
+	if zs1.IsZero() || zs2.IsZero() {
+		return fmt.Errorf("invalid challenge y")
+	}
+
+	// F = C0 + α·Z_S0(y)/Z_S1(y)·C1 + α²·Z_S0(y)/Z_S2(y)·C2
+	var q1, q2 bn254fr.Element
+	q1.Div(&zs0, &zs1).Mul(&q1, &ch.alpha)
+	q2.Div(&zs0, &zs2).Mul(&q2, &ch.alpha).Mul(&q2, &ch.alpha)
+	var f curve.G1Jac
+	f.FromAffine(&vk.c0)
+	addScaledG1(&f, &proof.c1, &q1)
+	addScaledG1(&f, &proof.c2, &q2)
+
+	// E = (r0 + q1·r1 + q2·r2)·G1 and J = Z_S0(y)·W1
+	var e bn254fr.Element
+	e.Mul(&q1, &r1).Add(&e, &r0)
+	tmp.Mul(&q2, &r2)
+	e.Add(&e, &tmp)
+	_, _, g1Gen, g2Gen := curve.Generators()
+
+	// e(-(F - E - J + y·W2), [1]₂) · e(W2, [x]₂) = 1
+	var a1 curve.G1Jac
+	a1.Set(&f)
+	e.Neg(&e)
+	addScaledG1(&a1, &g1Gen, &e)
+	zs0.Neg(&zs0)
+	addScaledG1(&a1, &proof.w1, &zs0)
+	addScaledG1(&a1, &proof.w2, &ch.y)
+
+	var a1Aff curve.G1Affine
+	a1Aff.FromJacobian(&a1)
+	a1Aff.Neg(&a1Aff)
+	ok, err := curve.PairingCheck([]curve.G1Affine{a1Aff, proof.w2}, []curve.G2Affine{g2Gen, vk.x2})
+	if err != nil {
+		return fmt.Errorf("failed to compute pairing: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("pairing check failed")
+	}
+	return nil
+}
+
+// fflonkComputeChallenges derives the challenges of the proof with the
+// SnarkJS Keccak-256 transcript, and the opening points from ξ = xiSeed²⁴.
+func fflonkComputeChallenges(vk *fflonkVerifyingKey, proof *fflonkProof, publicInputs []bn254fr.Element) (*fflonkChallenges, error) {
+	ch := &fflonkChallenges{}
+	t := &keccakTranscript{}
+
+	// Round 2: β and γ
+	t.addPoint(&vk.c0)
+	for i := range publicInputs {
+		t.addScalar(&publicInputs[i])
+	}
+	t.addPoint(&proof.c1)
+	ch.beta = t.challenge()
+
+	t.reset()
+	t.addScalar(&ch.beta)
+	ch.gamma = t.challenge()
+
+	// Round 3: ξ seed
+	t.reset()
+	t.addScalar(&ch.gamma)
+	t.addPoint(&proof.c2)
+	ch.xiSeed = t.challenge()
+	if ch.xiSeed.IsZero() {
+		return nil, fmt.Errorf("invalid challenge xi")
+	}
+
+	// h0 = xiSeed³, h1 = xiSeed⁶, h2 = xiSeed⁸, h3 = xiSeed⁸·wr and
+	// ξ = xiSeed²⁴, so that h0⁸ = h1⁴ = h2³ = ξ and h3³ = ξω.
+	var xiSeed2 bn254fr.Element
+	xiSeed2.Square(&ch.xiSeed)
+	ch.h0w8[0].Mul(&xiSeed2, &ch.xiSeed)
+	for i := 1; i < len(ch.h0w8); i++ {
+		ch.h0w8[i].Mul(&ch.h0w8[i-1], &vk.w8)
+	}
+	ch.h1w4[0].Square(&ch.h0w8[0])
+	for i := 1; i < len(ch.h1w4); i++ {
+		ch.h1w4[i].Mul(&ch.h1w4[i-1], &vk.w4)
+	}
+	ch.h2w3[0].Mul(&ch.h1w4[0], &xiSeed2)
+	ch.h3w3[0].Mul(&ch.h2w3[0], &vk.wr)
+	for i := 1; i < len(ch.h2w3); i++ {
+		ch.h2w3[i].Mul(&ch.h2w3[i-1], &vk.w3)
+		ch.h3w3[i].Mul(&ch.h3w3[i-1], &vk.w3)
+	}
+	ch.xi.Square(&ch.h2w3[0]).Mul(&ch.xi, &ch.h2w3[0])
+
+	// Round 4: α
+	t.reset()
+	t.addScalar(&ch.xiSeed)
+	for _, eval := range []*bn254fr.Element{
+		&proof.ql, &proof.qr, &proof.qm, &proof.qo, &proof.qc, &proof.s1, &proof.s2, &proof.s3,
+		&proof.a, &proof.b, &proof.c, &proof.z, &proof.zw, &proof.t1w, &proof.t2w,
+	} {
+		t.addScalar(eval)
+	}
+	ch.alpha = t.challenge()
+
+	// Round 5: y
+	t.reset()
+	t.addScalar(&ch.alpha)
+	t.addPoint(&proof.w1)
+	ch.y = t.challenge()
+
+	// ξⁿ and the vanishing polynomial ξⁿ-1
+	ch.xin.Set(&ch.xi)
+	for i := 0; i < vk.power; i++ {
+		ch.xin.Square(&ch.xin)
+	}
+	var one bn254fr.Element
+	one.SetOne()
+	ch.zh.Sub(&ch.xin, &one)
+	if ch.zh.IsZero() {
+		return nil, fmt.Errorf("invalid challenge xi")
+	}
+	return ch, nil
+}
+
+// evalFolded evaluates Σ xⁱ·coeffs[i], the value at x of a polynomial
+// C(X) = Σ Xⁱ·Pᵢ(Xᵏ) from the evaluations Pᵢ(xᵏ).
+func evalFolded(coeffs []*bn254fr.Element, x *bn254fr.Element) bn254fr.Element {
+	var res bn254fr.Element
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		res.Mul(&res, x).Add(&res, coeffs[i])
+	}
+	return res
+}
+
+// interpolateAt evaluates at x the polynomial of degree < len(points) taking
+// the given values at the given distinct points, in Lagrange form.
+func interpolateAt(points, values []bn254fr.Element, x *bn254fr.Element) bn254fr.Element {
+	dens := make([]bn254fr.Element, len(points))
+	nums := make([]bn254fr.Element, len(points))
+	var tmp bn254fr.Element
+	for i := range points {
+		dens[i].SetOne()
+		nums[i].SetOne()
+		for j := range points {
+			if i == j {
+				continue
+			}
+			tmp.Sub(&points[i], &points[j])
+			dens[i].Mul(&dens[i], &tmp)
+			tmp.Sub(x, &points[j])
+			nums[i].Mul(&nums[i], &tmp)
+		}
+	}
+	dens = bn254fr.BatchInvert(dens)
+	var res bn254fr.Element
+	for i := range points {
+		tmp.Mul(&nums[i], &dens[i]).Mul(&tmp, &values[i])
+		res.Add(&res, &tmp)
+	}
+	return res
+}
@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381fp "github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"golang.org/x/crypto/sha3"
+)
+
+// FingerprintHash is the hash function of a verification key fingerprint.
+type FingerprintHash int
+
+// Hash functions supported by Fingerprint.
+const (
+	// FingerprintSHA256 hashes the coordinates with SHA-256.
+	FingerprintSHA256 FingerprintHash = iota
+	// FingerprintKeccak256 hashes the coordinates with Keccak-256, as
+	// keccak256(abi.encodePacked(...)) does in Solidity.
+	FingerprintKeccak256
+	// FingerprintPoseidon hashes the coordinates with the circomlib Poseidon
+	// hash over the BN254 scalar field, for use in circuits.
+	FingerprintPoseidon
+)
+
+// String returns the name of the hash function.
+func (h FingerprintHash) String() string {
+	switch h {
+	case FingerprintSHA256:
+		return "sha256"
+	case FingerprintKeccak256:
+		return "keccak256"
+	case FingerprintPoseidon:
+		return "poseidon"
+	default:
+		return "unknown"
+	}
+}
+
+// Fingerprint returns a canonical identifier of the verification key: the
+// hash of the affine coordinates of vk_alpha_1, vk_beta_2, vk_gamma_2,
+// vk_delta_2 and IC, in this order. It does not depend on the JSON encoding
+// of the key nor on vk_alphabeta_12, and matches VerifyingKeyFingerprint for
+// the converted key.
+//
+// The coordinates are big-endian integers of the size of the base field (32
+// bytes for bn128, 48 bytes for bls12381), and G2 coordinates are written
+// imaginary part first, as the EVM precompiles expect. SHA-256 and Keccak-256
+// hash their concatenation. Poseidon splits each coordinate into 128-bit
+// limbs, most significant first, and folds them as h = Poseidon(h, limb),
+// starting from h = 0; the result is the big-endian encoding of h.
+func (vk *CircomVerificationKey) Fingerprint(h FingerprintHash) ([32]byte, error) {
+	curveID, err := circomCurveID(vk.Curve)
+	if err != nil {
+		return [32]byte{}, atPath("vk.curve", err)
+	}
+	if curveID == ecc.BLS12_381 {
+		return fingerprintBLS12381(vk, h)
+	}
+	gnarkVk, err := convertVerificationKey(vk)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return VerifyingKeyFingerprint(gnarkVk, h)
+}
+
+// VerifyingKeyFingerprint returns the fingerprint of a gnark BN254 verifying
+// key, as computed by CircomVerificationKey.Fingerprint. Keys with
+// commitments have no Circom equivalent and are rejected.
+func VerifyingKeyFingerprint(vk *groth16_bn254.VerifyingKey, h FingerprintHash) ([32]byte, error) {
+	if vk == nil {
+		return [32]byte{}, newError(ErrInternal, "", "nil verifying key")
+	}
+	if len(vk.CommitmentKeys) > 0 || len(vk.PublicAndCommitmentCommitted) > 0 {
+		return [32]byte{}, newError(ErrInternal, "vk", "verifying keys with commitments are not supported")
+	}
+	var coords []*big.Int
+	g1 := func(p *curve.G1Affine) {
+		coords = append(coords, p.X.BigInt(new(big.Int)), p.Y.BigInt(new(big.Int)))
+	}
+	g2 := func(p *curve.G2Affine) {
+		coords = append(coords,
+			p.X.A1.BigInt(new(big.Int)), p.X.A0.BigInt(new(big.Int)),
+			p.Y.A1.BigInt(new(big.Int)), p.Y.A0.BigInt(new(big.Int)))
+	}
+	g1(&vk.G1.Alpha)
+	g2(&vk.G2.Beta)
+	g2(&vk.G2.Gamma)
+	g2(&vk.G2.Delta)
+	for i := range vk.G1.K {
+		g1(&vk.G1.K[i])
+	}
+	return fingerprint(h, fp.Bytes, coords)
+}
+
+func fingerprintBLS12381(vk *CircomVerificationKey, h FingerprintHash) ([32]byte, error) {
+	var coords []*big.Int
+	g1 := func(path string, s []string) error {
+		p, err := stringToG1BLS12381(s)
+		if err != nil {
+			return atPath(path, err)
+		}
+		coords = append(coords, p.X.BigInt(new(big.Int)), p.Y.BigInt(new(big.Int)))
+		return nil
+	}
+	g2 := func(path string, s [][]string) error {
+		p, err := stringToG2BLS12381(s)
+		if err != nil {
+			return atPath(path, err)
+		}
+		coords = append(coords,
+			p.X.A1.BigInt(new(big.Int)), p.X.A0.BigInt(new(big.Int)),
+			p.Y.A1.BigInt(new(big.Int)), p.Y.A0.BigInt(new(big.Int)))
+		return nil
+	}
+	if err := g1("vk.vk_alpha_1", vk.VkAlpha1); err != nil {
+		return [32]byte{}, err
+	}
+	if err := g2("vk.vk_beta_2", vk.VkBeta2); err != nil {
+		return [32]byte{}, err
+	}
+	if err := g2("vk.vk_gamma_2", vk.VkGamma2); err != nil {
+		return [32]byte{}, err
+	}
+	if err := g2("vk.vk_delta_2", vk.VkDelta2); err != nil {
+		return [32]byte{}, err
+	}
+	for i := range vk.IC {
+		if err := g1(fmt.Sprintf("vk.IC[%d]", i), vk.IC[i]); err != nil {
+			return [32]byte{}, err
+		}
+	}
+	return fingerprint(h, bls12381fp.Bytes, coords)
+}
+
+// fingerprint hashes the coordinates, each of size bytes, with h.
+func fingerprint(h FingerprintHash, size int, coords []*big.Int) ([32]byte, error) {
+	var out [32]byte
+	switch h {
+	case FingerprintSHA256, FingerprintKeccak256:
+		data := make([]byte, 0, size*len(coords))
+		for _, c := range coords {
+			data = append(data, c.FillBytes(make([]byte, size))...)
+		}
+		if h == FingerprintSHA256 {
+			return sha256.Sum256(data), nil
+		}
+		hasher := sha3.NewLegacyKeccak256()
+		hasher.Write(data)
+		copy(out[:], hasher.Sum(nil))
+		return out, nil
+	case FingerprintPoseidon:
+		const limbSize = 16
+		var acc bn254fr.Element
+		for _, c := range coords {
+			b := c.FillBytes(make([]byte, size))
+			for i := 0; i < size; i += limbSize {
+				var limb bn254fr.Element
+				limb.SetBytes(b[i : i+limbSize])
+				var err error
+				if acc, err = poseidonHash(acc, limb); err != nil {
+					return out, newError(ErrInternal, "", "failed to compute Poseidon hash: %v", err)
+				}
+			}
+		}
+		return acc.Bytes(), nil
+	default:
+		return out, newError(ErrInternal, "", "unsupported fingerprint hash %d", h)
+	}
+}
@@ -1,18 +1,39 @@
-// Package parser provides functions to parse Circom/SnarkJS Groth16 proofs
-// and verification keys and convert them into Gnark-compatible structures for verification.
+// Package parser provides functions to parse Circom/SnarkJS Groth16, PLONK and
+// FFLONK proofs and verification keys and convert them into Gnark-compatible structures for verification.
 package parser
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"strconv"
+	"strings"
 )
 
+// jsonError reports a decoding error of the value at path as
+// ErrMalformedJSON, with the path of the offending field when known.
+func jsonError(path string, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		for _, f := range strings.Split(typeErr.Field, ".") {
+			if _, err := strconv.Atoi(f); err == nil {
+				path += "[" + f + "]"
+			} else {
+				path += "." + f
+			}
+		}
+	}
+	return &Error{Kind: ErrMalformedJSON, Path: path, Err: err}
+}
+
 // UnmarshalCircomProofJSON parses the JSON-encoded proof data into a SnarkJSProof struct.
 func UnmarshalCircomProofJSON(data []byte) (*CircomProof, error) {
 	var proof CircomProof
 	err := json.Unmarshal(data, &proof)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse proof JSON: %v", err)
+		return nil, jsonError("proof", err)
+	}
+	if err := decodeProof(&proof); err != nil {
+		return nil, err
 	}
 	return &proof, nil
 }
@@ -22,33 +43,125 @@ func UnmarshalCircomVerificationKeyJSON(data []byte) (*CircomVerificationKey, er
 	var vk CircomVerificationKey
 	err := json.Unmarshal(data, &vk)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse verification key JSON: %v", err)
+		return nil, jsonError("vk", err)
+	}
+	if err := decodeVerificationKey(&vk); err != nil {
+		return nil, err
 	}
 	return &vk, nil
 }
 
 // UnmarshalCircomPublicSignalsJSON parses the JSON-encoded public signals data into a slice of strings.
+// Hex signals, as output by WithHexCoordinates, are converted to decimal; they
+// must be canonical and cannot be mixed with decimal signals, or an
+// ErrInvalidScalar error is returned.
 func UnmarshalCircomPublicSignalsJSON(data []byte) ([]string, error) {
 	// Parse public signals
 	var publicSignals []string
 	if err := json.Unmarshal(data, &publicSignals); err != nil {
-		return nil, fmt.Errorf("error parsing public signals: %w", err)
+		return nil, jsonError("publicSignals", err)
+	}
+	if err := decodePublicSignals(publicSignals); err != nil {
+		return nil, err
 	}
 	return publicSignals, nil
 }
 
 // MarshalCircomProofJSON marshals the given CircomProof into pretty‑printed JSON.
-func MarshalCircomProofJSON(proof *CircomProof) ([]byte, error) {
-	return json.MarshalIndent(proof, "", "  ")
+// The encoding can be changed with the MarshalOption options. Without
+// options, the value is marshaled as is; with options, it must not be nil.
+func MarshalCircomProofJSON(proof *CircomProof, opts ...MarshalOption) ([]byte, error) {
+	if len(opts) == 0 {
+		return json.MarshalIndent(proof, "", "  ")
+	}
+	c := newMarshalConfig(opts)
+	encoded, err := c.encodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	return c.marshal(encoded)
 }
 
 // MarshalCircomVerificationKeyJSON marshals the given CircomVerificationKey into pretty‑printed JSON.
-func MarshalCircomVerificationKeyJSON(vk *CircomVerificationKey) ([]byte, error) {
-	return json.MarshalIndent(vk, "", "  ")
+// The encoding can be changed with the MarshalOption options. Without
+// options, the value is marshaled as is; with options, it must not be nil.
+func MarshalCircomVerificationKeyJSON(vk *CircomVerificationKey, opts ...MarshalOption) ([]byte, error) {
+	if len(opts) == 0 {
+		return json.MarshalIndent(vk, "", "  ")
+	}
+	c := newMarshalConfig(opts)
+	encoded, err := c.encodeVerificationKey(vk)
+	if err != nil {
+		return nil, err
+	}
+	return c.marshal(encoded)
 }
 
 // MarshalCircomPublicSignalsJSON marshals the given public signals (slice of strings)
-// into pretty‑printed JSON.
-func MarshalCircomPublicSignalsJSON(publicSignals []string) ([]byte, error) {
-	return json.MarshalIndent(publicSignals, "", "  ")
+// into pretty‑printed JSON. The hex and compact MarshalOption options apply.
+func MarshalCircomPublicSignalsJSON(publicSignals []string, opts ...MarshalOption) ([]byte, error) {
+	c := newMarshalConfig(opts)
+	return c.marshal(c.numbers(publicSignals))
+}
+
+// UnmarshalCircomPlonkProofJSON parses the JSON-encoded PLONK proof data into a CircomPlonkProof struct.
+func UnmarshalCircomPlonkProofJSON(data []byte) (*CircomPlonkProof, error) {
+	var proof CircomPlonkProof
+	err := json.Unmarshal(data, &proof)
+	if err != nil {
+		return nil, jsonError("proof", err)
+	}
+	return &proof, nil
+}
+
+// UnmarshalCircomPlonkVerificationKeyJSON parses the JSON-encoded PLONK verification key data into a
+// CircomPlonkVerificationKey struct.
+func UnmarshalCircomPlonkVerificationKeyJSON(data []byte) (*CircomPlonkVerificationKey, error) {
+	var vk CircomPlonkVerificationKey
+	err := json.Unmarshal(data, &vk)
+	if err != nil {
+		return nil, jsonError("vk", err)
+	}
+	return &vk, nil
+}
+
+// MarshalCircomPlonkProofJSON marshals the given CircomPlonkProof into pretty‑printed JSON.
+func MarshalCircomPlonkProofJSON(proof *CircomPlonkProof) ([]byte, error) {
+	return json.MarshalIndent(proof, "", "  ")
+}
+
+// MarshalCircomPlonkVerificationKeyJSON marshals the given CircomPlonkVerificationKey into pretty‑printed JSON.
+func MarshalCircomPlonkVerificationKeyJSON(vk *CircomPlonkVerificationKey) ([]byte, error) {
+	return json.MarshalIndent(vk, "", "  ")
+}
+
+// UnmarshalCircomFflonkProofJSON parses the JSON-encoded FFLONK proof data into a CircomFflonkProof struct.
+func UnmarshalCircomFflonkProofJSON(data []byte) (*CircomFflonkProof, error) {
+	var proof CircomFflonkProof
+	err := json.Unmarshal(data, &proof)
+	if err != nil {
+		return nil, jsonError("proof", err)
+	}
+	return &proof, nil
+}
+
+// UnmarshalCircomFflonkVerificationKeyJSON parses the JSON-encoded FFLONK verification key data into a
+// CircomFflonkVerificationKey struct.
+func UnmarshalCircomFflonkVerificationKeyJSON(data []byte) (*CircomFflonkVerificationKey, error) {
+	var vk CircomFflonkVerificationKey
+	err := json.Unmarshal(data, &vk)
+	if err != nil {
+		return nil, jsonError("vk", err)
+	}
+	return &vk, nil
+}
+
+// MarshalCircomFflonkProofJSON marshals the given CircomFflonkProof into pretty‑printed JSON.
+func MarshalCircomFflonkProofJSON(proof *CircomFflonkProof) ([]byte, error) {
+	return json.MarshalIndent(proof, "", "  ")
+}
+
+// MarshalCircomFflonkVerificationKeyJSON marshals the given CircomFflonkVerificationKey into pretty‑printed JSON.
+func MarshalCircomFflonkVerificationKeyJSON(vk *CircomFflonkVerificationKey) ([]byte, error) {
+	return json.MarshalIndent(vk, "", "  ")
 }
@@ -0,0 +1,308 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"strings"
+)
+
+// ArtifactKind is the kind of a Circom or SnarkJS artifact.
+type ArtifactKind int
+
+// Kinds of artifacts recognized by ParseArtifact.
+const (
+	ArtifactUnknown ArtifactKind = iota
+	ArtifactProof
+	ArtifactVerificationKey
+	ArtifactPlonkProof
+	ArtifactPlonkVerificationKey
+	ArtifactFflonkProof
+	ArtifactFflonkVerificationKey
+	ArtifactPublicSignals
+	ArtifactZKey
+	ArtifactR1CS
+	ArtifactWitness
+)
+
+// String returns the name of the artifact kind.
+func (k ArtifactKind) String() string {
+	switch k {
+	case ArtifactProof:
+		return "groth16 proof"
+	case ArtifactVerificationKey:
+		return "groth16 verification key"
+	case ArtifactPlonkProof:
+		return "plonk proof"
+	case ArtifactPlonkVerificationKey:
+		return "plonk verification key"
+	case ArtifactFflonkProof:
+		return "fflonk proof"
+	case ArtifactFflonkVerificationKey:
+		return "fflonk verification key"
+	case ArtifactPublicSignals:
+		return "public signals"
+	case ArtifactZKey:
+		return "zkey"
+	case ArtifactR1CS:
+		return "r1cs"
+	case ArtifactWitness:
+		return "wtns"
+	default:
+		return "unknown"
+	}
+}
+
+// Artifact is a Circom or SnarkJS artifact loaded by ParseArtifact. Only the
+// field matching Kind is set. Protocol and Curve are those of the artifact,
+// when known.
+type Artifact struct {
+	Kind     ArtifactKind
+	Protocol string
+	Curve    string
+
+	Proof                 *CircomProof
+	VerificationKey       *CircomVerificationKey
+	PlonkProof            *CircomPlonkProof
+	PlonkVerificationKey  *CircomPlonkVerificationKey
+	FflonkProof           *CircomFflonkProof
+	FflonkVerificationKey *CircomFflonkVerificationKey
+	PublicSignals         []string
+	ZKey                  *CircomZKey
+	R1CS                  *CircomR1CS
+	Witness               *CircomWitness
+}
+
+// LoadArtifact reads an artifact from r and parses it with ParseArtifact.
+func LoadArtifact(r io.Reader) (*Artifact, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return ParseArtifact(data)
+}
+
+// LoadArtifactFS reads the artifact at the given path of fsys and parses it
+// with ParseArtifact.
+func LoadArtifactFS(fsys fs.FS, name string) (*Artifact, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return ParseArtifact(data)
+}
+
+// ParseArtifact detects the kind of an artifact and parses it. Binary .zkey,
+// .r1cs and .wtns files, and the binary encodings of proofs, verification keys
+// and public signals, are recognized by their magic string. JSON proofs and
+// verification keys are recognized by their protocol field, or by their
+// fields when it is missing, and a JSON array is taken as public signals.
+//
+// JSON numbers and 0x-prefixed hex strings are accepted in place of decimal
+// strings, and are converted to decimal strings, as SnarkJS outputs them.
+// Compressed points, as written with WithCompressedPoints, are decompressed.
+// Public signals only get their JSON numbers converted: hex signals are left
+// to UnmarshalCircomPublicSignalsJSON, which only accepts them in canonical
+// form and not mixed with decimal ones.
+func ParseArtifact(data []byte) (*Artifact, error) {
+	if len(data) >= 4 { //nolint:gomnd
+		var err error
+		a := &Artifact{Protocol: "groth16", Curve: "bn128"}
+		switch string(data[:4]) {
+		case "zkey":
+			a.Kind = ArtifactZKey
+			a.ZKey, err = UnmarshalCircomZKey(data)
+		case "r1cs":
+			a.Kind, a.Protocol = ArtifactR1CS, ""
+			a.R1CS, err = UnmarshalCircomR1CS(data)
+		case "wtns":
+			a.Kind, a.Protocol = ArtifactWitness, ""
+			a.Witness, err = UnmarshalCircomWitness(data)
+		case proofBinaryMagic:
+			a.Kind, a.Proof = ArtifactProof, new(CircomProof)
+			err = a.Proof.UnmarshalBinary(data)
+			a.Curve = a.Proof.Curve
+		case verificationKeyBinaryMagic:
+			a.Kind, a.VerificationKey = ArtifactVerificationKey, new(CircomVerificationKey)
+			err = a.VerificationKey.UnmarshalBinary(data)
+			a.Curve = a.VerificationKey.Curve
+		case publicSignalsBinaryMagic:
+			var signals CircomPublicSignals
+			a.Kind, a.Protocol, a.Curve = ArtifactPublicSignals, "", ""
+			err = signals.UnmarshalBinary(data)
+			a.PublicSignals = signals
+		}
+		if a.Kind != ArtifactUnknown {
+			if err != nil {
+				return nil, err
+			}
+			return a, nil
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, jsonError("artifact", err)
+	}
+	var err error
+	if signals, ok := value.([]any); ok {
+		value, err = normalizeNumbers(signals, "artifact")
+	} else {
+		value, err = normalizeJSON(value, "artifact")
+	}
+	if err != nil {
+		return nil, err
+	}
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return nil, newError(ErrInternal, "", "failed to encode artifact: %v", err)
+	}
+
+	if _, ok := value.([]any); ok {
+		a := &Artifact{Kind: ArtifactPublicSignals}
+		a.PublicSignals, err = UnmarshalCircomPublicSignalsJSON(normalized)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return nil, newError(ErrUnknownArtifact, "", "unexpected JSON value")
+	}
+	a := &Artifact{Kind: detectJSONArtifact(obj)}
+	a.Protocol, _ = obj["protocol"].(string)
+	a.Curve, _ = obj["curve"].(string)
+	switch a.Kind {
+	case ArtifactProof:
+		a.Proof, err = UnmarshalCircomProofJSON(normalized)
+	case ArtifactVerificationKey:
+		a.VerificationKey, err = UnmarshalCircomVerificationKeyJSON(normalized)
+	case ArtifactPlonkProof:
+		a.PlonkProof, err = UnmarshalCircomPlonkProofJSON(normalized)
+	case ArtifactPlonkVerificationKey:
+		a.PlonkVerificationKey, err = UnmarshalCircomPlonkVerificationKeyJSON(normalized)
+	case ArtifactFflonkProof:
+		a.FflonkProof, err = UnmarshalCircomFflonkProofJSON(normalized)
+	case ArtifactFflonkVerificationKey:
+		a.FflonkVerificationKey, err = UnmarshalCircomFflonkVerificationKeyJSON(normalized)
+	default:
+		return nil, newError(ErrUnknownArtifact, "", "unrecognized JSON object")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// detectJSONArtifact returns the kind of a JSON proof or verification key,
+// from its protocol field or, when missing, from the fields specific to each
+// kind.
+func detectJSONArtifact(obj map[string]any) ArtifactKind {
+	has := func(key string) bool {
+		_, ok := obj[key]
+		return ok
+	}
+	protocol, _ := obj["protocol"].(string)
+	switch {
+	case (protocol == "groth16" || protocol == "") && has("pi_a"):
+		return ArtifactProof
+	case (protocol == "groth16" || protocol == "") && has("vk_alpha_1"):
+		return ArtifactVerificationKey
+	case (protocol == "plonk" || protocol == "") && has("Wxi"):
+		return ArtifactPlonkProof
+	case (protocol == "plonk" || protocol == "") && has("Qm"):
+		return ArtifactPlonkVerificationKey
+	case (protocol == "fflonk" || protocol == "") && has("polynomials"):
+		return ArtifactFflonkProof
+	case (protocol == "fflonk" || protocol == "") && has("C0"):
+		return ArtifactFflonkVerificationKey
+	default:
+		return ArtifactUnknown
+	}
+}
+
+// normalizeJSON converts the JSON numbers and the 0x-prefixed hex strings of
+// a decoded JSON value into decimal strings. The integer fields nPublic and
+// power are kept as numbers, or converted to numbers if they are decimal
+// strings. The path of the value is used in errors.
+func normalizeJSON(value any, path string) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, e := range v {
+			if k == "nPublic" || k == "power" {
+				if n, ok := e.(string); ok {
+					if !isDigits(n, "0123456789") {
+						return nil, newError(ErrMalformedJSON, path+"."+k, "invalid integer %q", n)
+					}
+					v[k] = json.Number(n)
+				}
+				continue
+			}
+			n, err := normalizeJSON(e, path+"."+k)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = n
+		}
+		return v, nil
+	case []any:
+		if s, ok := lone(v); ok && strings.HasPrefix(s, "0x") {
+			// A lone string may be a compressed point, which is decoded
+			// by the Unmarshal functions along with hex strings
+			return v, nil
+		}
+		for i, e := range v {
+			n, err := normalizeJSON(e, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = n
+		}
+		return v, nil
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return nil, newError(ErrMalformedJSON, path, "%s is not an integer", v)
+		}
+		return v.String(), nil
+	case string:
+		if len(v) > 2 && len(v) <= maxCoordinateLength && v[:2] == "0x" {
+			if bi, ok := new(big.Int).SetString(v[2:], 16); ok {
+				return bi.String(), nil
+			}
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// normalizeNumbers converts the JSON numbers of an array into decimal
+// strings, and leaves its other elements unchanged.
+func normalizeNumbers(v []any, path string) ([]any, error) {
+	for i, e := range v {
+		if _, ok := e.(json.Number); !ok {
+			continue
+		}
+		n, err := normalizeJSON(e, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// lone returns the string of a single-element array.
+func lone(v []any) (string, bool) {
+	if len(v) != 1 {
+		return "", false
+	}
+	s, ok := v[0].(string)
+	return s, ok
+}
@@ -0,0 +1,398 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+)
+
+// plonkVerifyingKey is a SnarkJS PLONK verification key decoded into curve
+// points and field elements.
+type plonkVerifyingKey struct {
+	nPublic int
+	power   int
+	k1, k2  bn254fr.Element
+	omega   bn254fr.Element
+
+	qm, ql, qr, qo, qc curve.G1Affine
+	s1, s2, s3         curve.G1Affine
+	x2                 curve.G2Affine
+}
+
+// plonkProof is a SnarkJS PLONK proof decoded into curve points and field
+// elements.
+type plonkProof struct {
+	a, b, c, z    curve.G1Affine
+	t1, t2, t3    curve.G1Affine
+	wxi, wxiw     curve.G1Affine
+	evalA, evalB  bn254fr.Element
+	evalC, evalZw bn254fr.Element
+	evalS1        bn254fr.Element
+	evalS2        bn254fr.Element
+}
+
+// plonkChallenges holds the Fiat-Shamir challenges of a PLONK proof, and
+// some values derived from them.
+type plonkChallenges struct {
+	beta, gamma, alpha, xi, u bn254fr.Element
+	v                         [6]bn254fr.Element // v[1]..v[5]
+	xin, zh                   bn254fr.Element    // ξⁿ and ξⁿ-1
+}
+
+// VerifyPlonkProof verifies a SnarkJS PLONK proof over bn254 with the given
+// verification key and public signals, as `snarkjs plonk verify` does. It
+// returns an error if the inputs are malformed or the proof is invalid.
+func VerifyPlonkProof(circomVk *CircomPlonkVerificationKey, circomProof *CircomPlonkProof, publicSignals []string) (bool, error) {
+	vk, err := convertPlonkVerificationKey(circomVk)
+	if err != nil {
+		return false, err
+	}
+	proof, err := convertPlonkProof(circomProof)
+	if err != nil {
+		return false, err
+	}
+	if len(publicSignals) != vk.nPublic {
+		return false, newError(ErrNPublicMismatch, "publicSignals", "expected %d public signals, got %d", vk.nPublic, len(publicSignals))
+	}
+	publicInputs, err := ConvertPublicInputsStrict(publicSignals)
+	if err != nil {
+		return false, err
+	}
+	if err := verifyPlonk(vk, proof, publicInputs); err != nil {
+		return false, &Error{Kind: ErrVerificationFailed, Err: err}
+	}
+	return true, nil
+}
+
+// convertPlonkVerificationKey decodes a CircomPlonkVerificationKey.
+func convertPlonkVerificationKey(circomVk *CircomPlonkVerificationKey) (*plonkVerifyingKey, error) {
+	if circomVk.Protocol != "plonk" {
+		return nil, newError(ErrWrongProtocol, "vk.protocol", "unexpected protocol %q, expected plonk", circomVk.Protocol)
+	}
+	if err := checkCurve("vk", circomVk.Curve, ecc.BN254); err != nil {
+		return nil, err
+	}
+	if circomVk.NPublic < 0 {
+		return nil, newError(ErrNPublicMismatch, "vk.nPublic", "invalid number of public signals %d", circomVk.NPublic)
+	}
+	if circomVk.Power < 1 || circomVk.Power > 28 { // 2-adicity of the bn254 scalar field
+		return nil, newError(ErrInvalidScalar, "vk.power", "invalid domain power %d", circomVk.Power)
+	}
+	vk := &plonkVerifyingKey{nPublic: circomVk.NPublic, power: circomVk.Power}
+	var err error
+	if vk.omega, err = fft.Generator(1 << circomVk.Power); err != nil {
+		return nil, newError(ErrInternal, "", "failed to compute the root of unity: %v", err)
+	}
+	w, err := stringToFr(circomVk.W)
+	if err != nil {
+		return nil, &Error{Kind: ErrInvalidScalar, Path: "vk.w", Err: err}
+	}
+	if !w.Equal(&vk.omega) {
+		return nil, newError(ErrInvalidScalar, "vk.w", "%s is not the root of unity of the domain of size 2^%d", circomVk.W, circomVk.Power)
+	}
+	for _, s := range []struct {
+		name string
+		in   string
+		out  *bn254fr.Element
+	}{
+		{"k1", circomVk.K1, &vk.k1},
+		{"k2", circomVk.K2, &vk.k2},
+	} {
+		if *s.out, err = stringToFr(s.in); err != nil {
+			return nil, &Error{Kind: ErrInvalidScalar, Path: "vk." + s.name, Err: err}
+		}
+	}
+	for _, p := range []struct {
+		name string
+		in   []string
+		out  *curve.G1Affine
+	}{
+		{"Qm", circomVk.Qm, &vk.qm},
+		{"Ql", circomVk.Ql, &vk.ql},
+		{"Qr", circomVk.Qr, &vk.qr},
+		{"Qo", circomVk.Qo, &vk.qo},
+		{"Qc", circomVk.Qc, &vk.qc},
+		{"S1", circomVk.S1, &vk.s1},
+		{"S2", circomVk.S2, &vk.s2},
+		{"S3", circomVk.S3, &vk.s3},
+	} {
+		g1, err := stringToG1(p.in)
+		if err != nil {
+			return nil, atPath("vk."+p.name, err)
+		}
+		*p.out = *g1
+	}
+	x2, err := stringToG2(circomVk.X2)
+	if err != nil {
+		return nil, atPath("vk.X_2", err)
+	}
+	vk.x2 = *x2
+	return vk, nil
+}
+
+// convertPlonkProof decodes a CircomPlonkProof.
+func convertPlonkProof(circomProof *CircomPlonkProof) (*plonkProof, error) {
+	if circomProof.Protocol != "" && circomProof.Protocol != "plonk" {
+		return nil, newError(ErrWrongProtocol, "proof.protocol", "unexpected protocol %q, expected plonk", circomProof.Protocol)
+	}
+	proof := &plonkProof{}
+	for _, p := range []struct {
+		name string
+		in   []string
+		out  *curve.G1Affine
+	}{
+		{"A", circomProof.A, &proof.a},
+		{"B", circomProof.B, &proof.b},
+		{"C", circomProof.C, &proof.c},
+		{"Z", circomProof.Z, &proof.z},
+		{"T1", circomProof.T1, &proof.t1},
+		{"T2", circomProof.T2, &proof.t2},
+		{"T3", circomProof.T3, &proof.t3},
+		{"Wxi", circomProof.Wxi, &proof.wxi},
+		{"Wxiw", circomProof.Wxiw, &proof.wxiw},
+	} {
+		g1, err := stringToG1(p.in)
+		if err != nil {
+			return nil, atPath("proof."+p.name, err)
+		}
+		*p.out = *g1
+	}
+	for _, s := range []struct {
+		name string
+		in   string
+		out  *bn254fr.Element
+	}{
+		{"eval_a", circomProof.EvalA, &proof.evalA},
+		{"eval_b", circomProof.EvalB, &proof.evalB},
+		{"eval_c", circomProof.EvalC, &proof.evalC},
+		{"eval_s1", circomProof.EvalS1, &proof.evalS1},
+		{"eval_s2", circomProof.EvalS2, &proof.evalS2},
+		{"eval_zw", circomProof.EvalZw, &proof.evalZw},
+	} {
+		var err error
+		if *s.out, err = stringToFr(s.in); err != nil {
+			return nil, &Error{Kind: ErrInvalidScalar, Path: "proof." + s.name, Err: err}
+		}
+	}
+	return proof, nil
+}
+
+// stringToFr parses a decimal or hex string into a scalar field element,
+// rejecting values that are not reduced.
+func stringToFr(s string) (bn254fr.Element, error) {
+	var e bn254fr.Element
+	bi, err := stringToBigInt(s)
+	if err != nil {
+		return e, err
+	}
+	if bi.Sign() < 0 || bi.Cmp(bn254fr.Modulus()) >= 0 {
+		return e, fmt.Errorf("value %s is not a valid field element", s)
+	}
+	e.SetBigInt(bi)
+	return e, nil
+}
+
+// verifyPlonk implements the verifier of the SnarkJS PLONK protocol
+// (plonk_verify.js): it computes the challenges, the linearisation
+// commitment D, and checks the batched KZG openings at ξ and ξω with a
+// single pairing.
+func verifyPlonk(vk *plonkVerifyingKey, proof *plonkProof, publicInputs []bn254fr.Element) error {
+	ch := plonkComputeChallenges(vk, proof, publicInputs)
+	l := lagrangeEvaluations(vk.nPublic, vk.power, &vk.omega, &ch.xi, &ch.zh)
+
+	// PI(ξ) = -Σ wᵢ·Lᵢ(ξ)
+	var pi, tmp bn254fr.Element
+	for i := range publicInputs {
+		tmp.Mul(&publicInputs[i], &l[i+1])
+		pi.Sub(&pi, &tmp)
+	}
+
+	// r₀ = PI(ξ) - L₁(ξ)·α² - α·(ā+β·s̄₁+γ)(b̄+β·s̄₂+γ)(c̄+γ)·z̄ω
+	var alpha2, e2, e3a, e3b, e3c, e3, r0 bn254fr.Element
+	alpha2.Square(&ch.alpha)
+	e2.Mul(&l[1], &alpha2)
+	e3a.Mul(&ch.beta, &proof.evalS1).Add(&e3a, &proof.evalA).Add(&e3a, &ch.gamma)
+	e3b.Mul(&ch.beta, &proof.evalS2).Add(&e3b, &proof.evalB).Add(&e3b, &ch.gamma)
+	e3c.Add(&proof.evalC, &ch.gamma)
+	e3.Mul(&e3a, &e3b).Mul(&e3, &e3c).Mul(&e3, &proof.evalZw).Mul(&e3, &ch.alpha)
+	r0.Sub(&pi, &e2).Sub(&r0, &e3)
+
+	// D = ā·b̄·Qm + ā·Ql + b̄·Qr + c̄·Qo + Qc
+	//   + ((ā+βξ+γ)(b̄+βk₁ξ+γ)(c̄+βk₂ξ+γ)·α + L₁(ξ)·α² + u)·Z
+	//   - (ā+β·s̄₁+γ)(b̄+β·s̄₂+γ)·α·β·z̄ω·S3
+	//   - (ξⁿ-1)·(T1 + ξⁿ·T2 + ξ²ⁿ·T3)
+	var ab bn254fr.Element
+	ab.Mul(&proof.evalA, &proof.evalB)
+	var d, p curve.G1Jac
+	d.FromAffine(&vk.qc)
+	addScaledG1(&d, &vk.qm, &ab)
+	addScaledG1(&d, &vk.ql, &proof.evalA)
+	addScaledG1(&d, &vk.qr, &proof.evalB)
+	addScaledG1(&d, &vk.qo, &proof.evalC)
+
+	var betaxi, d2a1, d2a2, d2a3, d2 bn254fr.Element
+	betaxi.Mul(&ch.beta, &ch.xi)
+	d2a1.Add(&proof.evalA, &betaxi).Add(&d2a1, &ch.gamma)
+	d2a2.Mul(&betaxi, &vk.k1).Add(&d2a2, &proof.evalB).Add(&d2a2, &ch.gamma)
+	d2a3.Mul(&betaxi, &vk.k2).Add(&d2a3, &proof.evalC).Add(&d2a3, &ch.gamma)
+	d2.Mul(&d2a1, &d2a2).Mul(&d2, &d2a3).Mul(&d2, &ch.alpha).Add(&d2, &e2).Add(&d2, &ch.u)
+	addScaledG1(&d, &proof.z, &d2)
+
+	var d3 bn254fr.Element
+	d3.Mul(&e3a, &e3b).Mul(&d3, &ch.alpha).Mul(&d3, &ch.beta).Mul(&d3, &proof.evalZw).Neg(&d3)
+	addScaledG1(&d, &vk.s3, &d3)
+
+	var xin2 bn254fr.Element
+	xin2.Square(&ch.xin)
+	p.FromAffine(&proof.t1)
+	addScaledG1(&p, &proof.t2, &ch.xin)
+	addScaledG1(&p, &proof.t3, &xin2)
+	p.ScalarMultiplication(&p, ch.zh.BigInt(new(big.Int)))
+	d.SubAssign(&p)
+
+	// F = D + v₁·A + v₂·B + v₃·C + v₄·S1 + v₅·S2
+	f := d
+	addScaledG1(&f, &proof.a, &ch.v[1])
+	addScaledG1(&f, &proof.b, &ch.v[2])
+	addScaledG1(&f, &proof.c, &ch.v[3])
+	addScaledG1(&f, &vk.s1, &ch.v[4])
+	addScaledG1(&f, &vk.s2, &ch.v[5])
+
+	// E = (-r₀ + v₁·ā + v₂·b̄ + v₃·c̄ + v₄·s̄₁ + v₅·s̄₂ + u·z̄ω)·G1
+	var e bn254fr.Element
+	e.Neg(&r0)
+	for i, eval := range []*bn254fr.Element{&proof.evalA, &proof.evalB, &proof.evalC, &proof.evalS1, &proof.evalS2} {
+		tmp.Mul(&ch.v[i+1], eval)
+		e.Add(&e, &tmp)
+	}
+	tmp.Mul(&ch.u, &proof.evalZw)
+	e.Add(&e, &tmp)
+
+	// e(-(Wξ + u·Wξω), [x]₂) · e(ξ·Wξ + u·ξ·ω·Wξω + F - E, [1]₂) = 1
+	var a1, b1 curve.G1Jac
+	a1.FromAffine(&proof.wxi)
+	addScaledG1(&a1, &proof.wxiw, &ch.u)
+	var s bn254fr.Element
+	s.Mul(&ch.u, &ch.xi).Mul(&s, &vk.omega)
+	b1.FromAffine(&proof.wxi)
+	b1.ScalarMultiplication(&b1, ch.xi.BigInt(new(big.Int)))
+	addScaledG1(&b1, &proof.wxiw, &s)
+	b1.AddAssign(&f)
+	_, _, g1Gen, g2Gen := curve.Generators()
+	e.Neg(&e)
+	addScaledG1(&b1, &g1Gen, &e)
+
+	var a1Aff, b1Aff curve.G1Affine
+	a1Aff.FromJacobian(&a1)
+	a1Aff.Neg(&a1Aff)
+	b1Aff.FromJacobian(&b1)
+	ok, err := curve.PairingCheck([]curve.G1Affine{a1Aff, b1Aff}, []curve.G2Affine{vk.x2, g2Gen})
+	if err != nil {
+		return fmt.Errorf("failed to compute pairing: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("pairing check failed")
+	}
+	return nil
+}
+
+// plonkComputeChallenges derives the challenges of the proof with the
+// SnarkJS Keccak-256 transcript.
+func plonkComputeChallenges(vk *plonkVerifyingKey, proof *plonkProof, publicInputs []bn254fr.Element) *plonkChallenges {
+	ch := &plonkChallenges{}
+	t := &keccakTranscript{}
+
+	// Round 2: β and γ
+	for _, p := range []*curve.G1Affine{&vk.qm, &vk.ql, &vk.qr, &vk.qo, &vk.qc, &vk.s1, &vk.s2, &vk.s3} {
+		t.addPoint(p)
+	}
+	for i := range publicInputs {
+		t.addScalar(&publicInputs[i])
+	}
+	t.addPoint(&proof.a)
+	t.addPoint(&proof.b)
+	t.addPoint(&proof.c)
+	ch.beta = t.challenge()
+
+	t.reset()
+	t.addScalar(&ch.beta)
+	ch.gamma = t.challenge()
+
+	// Round 3: α
+	t.reset()
+	t.addScalar(&ch.beta)
+	t.addScalar(&ch.gamma)
+	t.addPoint(&proof.z)
+	ch.alpha = t.challenge()
+
+	// Round 4: ξ
+	t.reset()
+	t.addScalar(&ch.alpha)
+	t.addPoint(&proof.t1)
+	t.addPoint(&proof.t2)
+	t.addPoint(&proof.t3)
+	ch.xi = t.challenge()
+
+	// Round 5: v
+	t.reset()
+	t.addScalar(&ch.xi)
+	for _, eval := range []*bn254fr.Element{&proof.evalA, &proof.evalB, &proof.evalC, &proof.evalS1, &proof.evalS2, &proof.evalZw} {
+		t.addScalar(eval)
+	}
+	ch.v[1] = t.challenge()
+	for i := 2; i < len(ch.v); i++ {
+		ch.v[i].Mul(&ch.v[i-1], &ch.v[1])
+	}
+
+	// u, for the batched opening
+	t.reset()
+	t.addPoint(&proof.wxi)
+	t.addPoint(&proof.wxiw)
+	ch.u = t.challenge()
+
+	// ξⁿ and the vanishing polynomial ξⁿ-1
+	ch.xin.Set(&ch.xi)
+	for i := 0; i < vk.power; i++ {
+		ch.xin.Square(&ch.xin)
+	}
+	var one bn254fr.Element
+	one.SetOne()
+	ch.zh.Sub(&ch.xin, &one)
+	return ch
+}
+
+// lagrangeEvaluations returns Lᵢ(ξ) = ωⁱ⁻¹·(ξⁿ-1) / (n·(ξ-ωⁱ⁻¹)) for
+// i = 1..max(1, nPublic), with n = 2^power and zh = ξⁿ-1. The slice is
+// indexed from 1, as in SnarkJS.
+func lagrangeEvaluations(nPublic, power int, omega, xi, zh *bn254fr.Element) []bn254fr.Element {
+	count := max(1, nPublic)
+	l := make([]bn254fr.Element, count+1)
+	var n, w, num bn254fr.Element
+	n.SetUint64(1 << power)
+	w.SetOne()
+	dens := make([]bn254fr.Element, count)
+	for i := range dens {
+		dens[i].Sub(xi, &w).Mul(&dens[i], &n)
+		w.Mul(&w, omega)
+	}
+	dens = bn254fr.BatchInvert(dens)
+	w.SetOne()
+	for i := 1; i <= count; i++ {
+		num.Mul(&w, zh)
+		l[i].Mul(&num, &dens[i-1])
+		w.Mul(&w, omega)
+	}
+	return l
+}
+
+// addScaledG1 sets acc = acc + s·p.
+func addScaledG1(acc *curve.G1Jac, p *curve.G1Affine, s *bn254fr.Element) {
+	var tmp curve.G1Jac
+	tmp.FromAffine(p)
+	tmp.ScalarMultiplication(&tmp, s.BigInt(new(big.Int)))
+	acc.AddAssign(&tmp)
+}
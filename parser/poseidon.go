@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// poseidonPartialRounds is the number of partial rounds of the circomlib
+// Poseidon hash for 1 to 16 inputs (t = 2 to 17). All widths use 8 full
+// rounds.
+var poseidonPartialRounds = []int{56, 57, 56, 60, 60, 63, 64, 63, 60, 66, 60, 65, 70, 60, 64, 68}
+
+const poseidonFullRounds = 8
+
+// poseidonParams holds the round constants and the MDS matrix of the Poseidon
+// permutation of a given width.
+type poseidonParams struct {
+	rc  []bn254fr.Element
+	mds [][]bn254fr.Element
+}
+
+var (
+	poseidonMu    sync.Mutex
+	poseidonCache = map[int]*poseidonParams{}
+)
+
+// poseidonHash computes the circomlib Poseidon hash of 1 to 16 scalar field
+// elements.
+func poseidonHash(inputs ...bn254fr.Element) (bn254fr.Element, error) {
+	if len(inputs) == 0 || len(inputs) > len(poseidonPartialRounds) {
+		return bn254fr.Element{}, fmt.Errorf("invalid number of Poseidon inputs %d", len(inputs))
+	}
+	t := len(inputs) + 1
+	nRoundsP := poseidonPartialRounds[t-2]
+	params := poseidonParamsFor(t, nRoundsP)
+
+	state := make([]bn254fr.Element, t)
+	copy(state[1:], inputs)
+	next := make([]bn254fr.Element, t)
+	for r := 0; r < poseidonFullRounds+nRoundsP; r++ {
+		for i := range state {
+			state[i].Add(&state[i], &params.rc[r*t+i])
+		}
+		if r < poseidonFullRounds/2 || r >= poseidonFullRounds/2+nRoundsP {
+			for i := range state {
+				poseidonSbox(&state[i])
+			}
+		} else {
+			poseidonSbox(&state[0])
+		}
+		for i := range next {
+			next[i].SetZero()
+			for j := range state {
+				var m bn254fr.Element
+				m.Mul(&params.mds[i][j], &state[j])
+				next[i].Add(&next[i], &m)
+			}
+		}
+		state, next = next, state
+	}
+	return state[0], nil
+}
+
+// poseidonSbox computes x^5 in place.
+func poseidonSbox(x *bn254fr.Element) {
+	var x2 bn254fr.Element
+	x2.Square(x)
+	x2.Square(&x2)
+	x.Mul(x, &x2)
+}
+
+// poseidonParamsFor returns the parameters of width t, generating them on
+// first use.
+func poseidonParamsFor(t, nRoundsP int) *poseidonParams {
+	poseidonMu.Lock()
+	defer poseidonMu.Unlock()
+	if p, ok := poseidonCache[t]; ok {
+		return p
+	}
+	p := generatePoseidonParams(t, nRoundsP)
+	poseidonCache[t] = p
+	return p
+}
+
+// generatePoseidonParams derives the Poseidon parameters with the Grain LFSR
+// of the reference implementation (generate_parameters_grain.sage), as done
+// for circomlib: round constants are sampled by rejection, and the MDS matrix
+// is the Cauchy matrix 1/(x_i + y_j) of the next 2t sampled values.
+func generatePoseidonParams(t, nRoundsP int) *poseidonParams {
+	const fieldSize = 254
+	g := newGrainLFSR(fieldSize, t, poseidonFullRounds, nRoundsP)
+	modulus := bn254fr.Modulus()
+
+	params := &poseidonParams{rc: make([]bn254fr.Element, (poseidonFullRounds+nRoundsP)*t)}
+	for i := range params.rc {
+		for {
+			v := g.bigInt(fieldSize)
+			if v.Cmp(modulus) < 0 {
+				params.rc[i].SetBigInt(v)
+				break
+			}
+		}
+	}
+	xy := make([]bn254fr.Element, 2*t)
+	for i := range xy {
+		xy[i].SetBigInt(g.bigInt(fieldSize))
+	}
+	params.mds = make([][]bn254fr.Element, t)
+	for i := range params.mds {
+		params.mds[i] = make([]bn254fr.Element, t)
+		for j := range params.mds[i] {
+			params.mds[i][j].Add(&xy[i], &xy[t+j])
+			params.mds[i][j].Inverse(&params.mds[i][j])
+		}
+	}
+	return params
+}
+
+// grainLFSR is the 80-bit Grain LFSR used to derive the Poseidon parameters.
+type grainLFSR struct {
+	state [80]byte
+}
+
+func newGrainLFSR(fieldSize, t, nRoundsF, nRoundsP int) *grainLFSR {
+	g := &grainLFSR{}
+	i := 0
+	push := func(v, n int) {
+		for b := n - 1; b >= 0; b-- {
+			g.state[i] = byte(v>>b) & 1
+			i++
+		}
+	}
+	push(1, 2) // prime field
+	push(0, 4) // x^alpha S-box
+	push(fieldSize, 12)
+	push(t, 12)
+	push(nRoundsF, 10)
+	push(nRoundsP, 10)
+	push(1<<30-1, 30)
+	for j := 0; j < 160; j++ {
+		g.step()
+	}
+	return g
+}
+
+func (g *grainLFSR) step() byte {
+	s := &g.state
+	bit := s[62] ^ s[51] ^ s[38] ^ s[23] ^ s[13] ^ s[0]
+	copy(s[:], s[1:])
+	s[79] = bit
+	return bit
+}
+
+// bit returns the next output bit: bits are drawn in pairs, and the second
+// one is output only if the first one is set.
+func (g *grainLFSR) bit() byte {
+	for {
+		if g.step() == 1 {
+			return g.step()
+		}
+		g.step()
+	}
+}
+
+// bigInt returns the integer formed by the next n output bits, most
+// significant first.
+func (g *grainLFSR) bigInt(n int) *big.Int {
+	v := new(big.Int)
+	for i := 0; i < n; i++ {
+		v.Lsh(v, 1)
+		if g.bit() == 1 {
+			v.SetBit(v, 0, 1)
+		}
+	}
+	return v
+}
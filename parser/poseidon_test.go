@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"testing"
+
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// TestPoseidonHash checks poseidonHash against the circomlib test vectors
+// (circomlibjs test/poseidon.js).
+func TestPoseidonHash(t *testing.T) {
+	for _, tc := range []struct {
+		inputs []uint64
+		want   string
+	}{
+		{[]uint64{1}, "18586133768512220936620570745912940619677854269274689475585506675881198879027"},
+		{[]uint64{1, 2}, "7853200120776062878684798364095072458815029376092732009249414926327459813530"},
+	} {
+		inputs := make([]bn254fr.Element, len(tc.inputs))
+		for i, v := range tc.inputs {
+			inputs[i].SetUint64(v)
+		}
+		got, err := poseidonHash(inputs...)
+		if err != nil {
+			t.Fatalf("Poseidon(%v): %v", tc.inputs, err)
+		}
+		if got.String() != tc.want {
+			t.Errorf("Poseidon(%v): expected %s, got %s", tc.inputs, tc.want, got.String())
+		}
+	}
+	if _, err := poseidonHash(); err == nil {
+		t.Errorf("expected error for no inputs")
+	}
+	if _, err := poseidonHash(make([]bn254fr.Element, 17)...); err == nil {
+		t.Errorf("expected error for 17 inputs")
+	}
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// PrecomputedVerifyingKey is a BN254 verifying key with the fixed parts of the
+// pairing check precomputed: e(α, β), and the Miller loop lines of -γ and -δ.
+// Verifying a proof then only needs the Miller loop of (A, B) and the fixed
+// argument Miller loops of (L, -γ) and (C, -δ). It is safe for concurrent
+// use.
+type PrecomputedVerifyingKey struct {
+	VerifyingKey *groth16_bn254.VerifyingKey
+
+	alphaBeta curve.GT
+	lines     [][2][len(curve.LoopCounter)]curve.LineEvaluationAff // of -γ and -δ
+}
+
+// NewPrecomputedVerifyingKey precomputes the fixed parts of the pairing check
+// of the verifying key. The key must not be modified afterwards.
+func NewPrecomputedVerifyingKey(vk *groth16_bn254.VerifyingKey) (*PrecomputedVerifyingKey, error) {
+	if vk == nil {
+		return nil, newError(ErrInternal, "", "nil verifying key")
+	}
+	alphaBeta, err := curve.Pair([]curve.G1Affine{vk.G1.Alpha}, []curve.G2Affine{vk.G2.Beta})
+	if err != nil {
+		return nil, newError(ErrInternal, "", "failed to compute e(alpha, beta): %v", err)
+	}
+	var gammaNeg, deltaNeg curve.G2Affine
+	gammaNeg.Neg(&vk.G2.Gamma)
+	deltaNeg.Neg(&vk.G2.Delta)
+	return &PrecomputedVerifyingKey{
+		VerifyingKey: vk,
+		alphaBeta:    alphaBeta,
+		lines: [][2][len(curve.LoopCounter)]curve.LineEvaluationAff{
+			curve.PrecomputeLines(gammaNeg),
+			curve.PrecomputeLines(deltaNeg),
+		},
+	}, nil
+}
+
+// ConvertPrecomputedVerificationKey converts a Circom verification key, as
+// ConvertVerificationKey does, and precomputes it.
+func ConvertPrecomputedVerificationKey(vk *CircomVerificationKey) (*PrecomputedVerifyingKey, error) {
+	gnarkVk, err := ConvertVerificationKey(vk)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrecomputedVerifyingKey(gnarkVk)
+}
+
+// Verify verifies a proof against the precomputed key. It returns the same
+// results as VerifyProof. Keys with commitments are verified with
+// VerifyProof.
+func (pvk *PrecomputedVerifyingKey) Verify(proof *groth16_bn254.Proof, publicInputs []bn254fr.Element) (bool, error) {
+	vk := pvk.VerifyingKey
+	if len(vk.CommitmentKeys) > 0 {
+		return VerifyProof(&GnarkProof{Proof: proof, VerifyingKey: vk, PublicInputs: publicInputs})
+	}
+	if proof == nil {
+		return false, newError(ErrInternal, "", "missing proof")
+	}
+	if len(publicInputs) != len(vk.G1.K)-1 {
+		return false, newError(ErrNPublicMismatch, "publicSignals", "expected %d public signals, got %d",
+			len(vk.G1.K)-1, len(publicInputs))
+	}
+	if !proof.Ar.IsInSubGroup() || !proof.Bs.IsInSubGroup() || !proof.Krs.IsInSubGroup() {
+		return false, newError(ErrVerificationFailed, "proof", "points are not in the correct subgroup")
+	}
+
+	// L = K_0 + Σ x_i·K_i
+	var kSum curve.G1Jac
+	if _, err := kSum.MultiExp(vk.G1.K[1:], publicInputs, ecc.MultiExpConfig{}); err != nil {
+		return false, newError(ErrInternal, "", "failed to compute public input term: %v", err)
+	}
+	kSum.AddMixed(&vk.G1.K[0])
+	var kSumAff curve.G1Affine
+	kSumAff.FromJacobian(&kSum)
+
+	// e(A, B)·e(L, -γ)·e(C, -δ) == e(α, β). MillerLoopFixedQ overwrites the
+	// lines with their evaluations, so it gets a copy.
+	lines := make([][2][len(curve.LoopCounter)]curve.LineEvaluationAff, len(pvk.lines))
+	copy(lines, pvk.lines)
+	fixed, err := curve.MillerLoopFixedQ([]curve.G1Affine{kSumAff, proof.Krs}, lines)
+	if err != nil {
+		return false, newError(ErrInternal, "", "failed to compute Miller loop: %v", err)
+	}
+	ab, err := curve.MillerLoop([]curve.G1Affine{proof.Ar}, []curve.G2Affine{proof.Bs})
+	if err != nil {
+		return false, newError(ErrInternal, "", "failed to compute Miller loop: %v", err)
+	}
+	fixed.Mul(&fixed, &ab)
+	if result := curve.FinalExponentiation(&fixed); !result.Equal(&pvk.alphaBeta) {
+		return false, newError(ErrVerificationFailed, "", "pairing doesn't match")
+	}
+	return true, nil
+}
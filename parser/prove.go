@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// ProveCircom computes a Groth16 proof for a Circom circuit from its SnarkJS
+// proving key and a full witness (see UnmarshalCircomWitness), as
+// `snarkjs groth16 prove` does. It returns the proof and the public signals
+// in the SnarkJS format, which can be verified with VerifyProof after
+// ConvertCircomToGnark, or with the verification key of the zkey.
+//
+// The proof is computed with gnark's Groth16 algorithm over the converted
+// proving key. As the zkey does not hold the C matrix, its evaluations are
+// computed as A·B, so the witness is not checked against the circuit: an
+// invalid witness produces an invalid proof.
+func ProveCircom(zkey *CircomZKey, w *CircomWitness) (*CircomProof, []string, error) {
+	proof, err := proveCircom(zkey, w)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicSignals, err := w.PublicSignals(zkey.NPublic)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	piA, err := g1ToCircomString(&proof.Ar)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert proof.Ar: %w", err)
+	}
+	piB, err := g2ToCircomString(&proof.Bs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert proof.Bs: %w", err)
+	}
+	piC, err := g1ToCircomString(&proof.Krs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert proof.Krs: %w", err)
+	}
+	return &CircomProof{
+		PiA:      piA,
+		PiB:      piB,
+		PiC:      piC,
+		Protocol: "groth16",
+		Curve:    "bn128",
+	}, publicSignals, nil
+}
+
+// proveCircom follows groth16_bn254.Prove, computing the wire values of A
+// and B from the zkey coefficients instead of solving a constraint system.
+func proveCircom(zkey *CircomZKey, w *CircomWitness) (*groth16_bn254.Proof, error) {
+	if zkey == nil || zkey.ProvingKey == nil {
+		return nil, fmt.Errorf("nil proving key")
+	}
+	if w == nil {
+		return nil, fmt.Errorf("nil witness")
+	}
+	if len(w.Values) != zkey.NVars {
+		return nil, fmt.Errorf("witness has %d values, but the zkey expects %d", len(w.Values), zkey.NVars)
+	}
+	if err := w.checkNPublic(zkey.NPublic); err != nil {
+		return nil, err
+	}
+	pk := zkey.ProvingKey
+	wireValues := w.Values
+
+	// Evaluations of A, B and C on the domain
+	n := int(pk.Domain.Cardinality)
+	a := make([]bn254fr.Element, n)
+	b := make([]bn254fr.Element, n)
+	c := make([]bn254fr.Element, n)
+	var tmp bn254fr.Element
+	for _, coef := range zkey.Coefficients {
+		if coef.Constraint >= n || coef.Signal >= len(wireValues) {
+			return nil, fmt.Errorf("coefficient of signal %d in constraint %d out of range", coef.Signal, coef.Constraint)
+		}
+		tmp.Mul(&coef.Value, &wireValues[coef.Signal])
+		if coef.Matrix == 0 {
+			a[coef.Constraint].Add(&a[coef.Constraint], &tmp)
+		} else {
+			b[coef.Constraint].Add(&b[coef.Constraint], &tmp)
+		}
+	}
+	for i := range c {
+		c[i].Mul(&a[i], &b[i])
+	}
+	h := computeH(a, b, c, &pk.Domain)
+
+	// Wire values of the points not at infinity
+	wireValuesA := make([]bn254fr.Element, 0, len(pk.G1.A))
+	wireValuesB := make([]bn254fr.Element, 0, len(pk.G1.B))
+	for i := range wireValues {
+		if !pk.InfinityA[i] {
+			wireValuesA = append(wireValuesA, wireValues[i])
+		}
+		if !pk.InfinityB[i] {
+			wireValuesB = append(wireValuesB, wireValues[i])
+		}
+	}
+
+	var _r, _s, _kr bn254fr.Element
+	if _, err := _r.SetRandom(); err != nil {
+		return nil, err
+	}
+	if _, err := _s.SetRandom(); err != nil {
+		return nil, err
+	}
+	_kr.Mul(&_r, &_s).Neg(&_kr)
+	var r, s big.Int
+	_r.BigInt(&r)
+	_s.BigInt(&s)
+	deltas := curve.BatchScalarMultiplicationG1(&pk.G1.Delta, []bn254fr.Element{_r, _s, _kr})
+
+	proof := &groth16_bn254.Proof{}
+
+	// Ar = α + Σ aᵢ·Aᵢ + r·δ
+	ar, err := multiExpG1(pk.G1.A, wireValuesA)
+	if err != nil {
+		return nil, err
+	}
+	ar.AddMixed(&pk.G1.Alpha)
+	ar.AddMixed(&deltas[0])
+	proof.Ar.FromJacobian(&ar)
+
+	// Bs = β + Σ aᵢ·Bᵢ + s·δ, in G1 and G2
+	bs1, err := multiExpG1(pk.G1.B, wireValuesB)
+	if err != nil {
+		return nil, err
+	}
+	bs1.AddMixed(&pk.G1.Beta)
+	bs1.AddMixed(&deltas[1])
+
+	var bs, deltaS curve.G2Jac
+	if len(wireValuesB) > 0 {
+		if _, err := bs.MultiExp(pk.G2.B, wireValuesB, ecc.MultiExpConfig{}); err != nil {
+			return nil, fmt.Errorf("failed to compute Bs: %w", err)
+		}
+	}
+	deltaS.FromAffine(&pk.G2.Delta)
+	deltaS.ScalarMultiplication(&deltaS, &s)
+	bs.AddAssign(&deltaS)
+	bs.AddMixed(&pk.G2.Beta)
+	proof.Bs.FromJacobian(&bs)
+
+	// Krs = Σ aᵢ·Kᵢ + Σ hᵢ·Zᵢ + s·Ar + r·Bs - r·s·δ, over the private wires
+	krs, err := multiExpG1(pk.G1.K, wireValues[zkey.NPublic+1:])
+	if err != nil {
+		return nil, err
+	}
+	krs2, err := multiExpG1(pk.G1.Z, h[:len(pk.G1.Z)])
+	if err != nil {
+		return nil, err
+	}
+	krs.AddAssign(&krs2)
+	krs.AddMixed(&deltas[2])
+	var p1 curve.G1Jac
+	p1.ScalarMultiplication(&ar, &s)
+	krs.AddAssign(&p1)
+	p1.ScalarMultiplication(&bs1, &r)
+	krs.AddAssign(&p1)
+	proof.Krs.FromJacobian(&krs)
+
+	return proof, nil
+}
+
+// multiExpG1 computes Σ scalarsᵢ·pointsᵢ, which is the point at infinity for
+// empty slices.
+func multiExpG1(points []curve.G1Affine, scalars []bn254fr.Element) (curve.G1Jac, error) {
+	var res curve.G1Jac
+	if len(points) != len(scalars) {
+		return res, fmt.Errorf("multi-exponentiation of %d points with %d scalars", len(points), len(scalars))
+	}
+	if len(points) == 0 {
+		res.Z.SetZero()
+		res.X.SetOne()
+		res.Y.SetOne()
+		return res, nil
+	}
+	if _, err := res.MultiExp(points, scalars, ecc.MultiExpConfig{}); err != nil {
+		return res, fmt.Errorf("failed to compute multi-exponentiation: %w", err)
+	}
+	return res, nil
+}
+
+// computeH computes the coefficients of h = (a·b - c)/t, with t = Xⁿ-1, from
+// the evaluations of a, b and c on the domain, as gnark does. They are
+// returned in bit-reversed order, matching pk.G1.Z.
+func computeH(a, b, c []bn254fr.Element, domain *fft.Domain) []bn254fr.Element {
+	domain.FFTInverse(a, fft.DIF)
+	domain.FFTInverse(b, fft.DIF)
+	domain.FFTInverse(c, fft.DIF)
+
+	domain.FFT(a, fft.DIT, fft.OnCoset())
+	domain.FFT(b, fft.DIT, fft.OnCoset())
+	domain.FFT(c, fft.DIT, fft.OnCoset())
+
+	// t is constant on the coset: gⁿ-1, with g the coset generator
+	var den, one bn254fr.Element
+	one.SetOne()
+	den.Exp(domain.FrMultiplicativeGen, big.NewInt(int64(domain.Cardinality)))
+	den.Sub(&den, &one).Inverse(&den)
+
+	for i := range a {
+		a[i].Mul(&a[i], &b[i]).
+			Sub(&a[i], &c[i]).
+			Mul(&a[i], &den)
+	}
+
+	domain.FFTInverse(a, fft.DIF, fft.OnCoset())
+	return a
+}
@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"fmt"
+
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/constraint"
+	cs "github.com/consensys/gnark/constraint/bn254"
+)
+
+// Section types of a Circom .r1cs file.
+const (
+	r1csSectionHeader          = 1
+	r1csSectionConstraints     = 2
+	r1csSectionWireToLabel     = 3
+	r1csSectionCustomGatesList = 4
+	r1csSectionCustomGatesUses = 5
+)
+
+// UnmarshalCircomR1CS parses a Circom .r1cs binary file over bn254.
+func UnmarshalCircomR1CS(data []byte) (*CircomR1CS, error) {
+	f, err := readBinFile(data, "r1cs", 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse r1cs: %w", err)
+	}
+
+	// Header
+	r, err := f.section(r1csSectionHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse r1cs: %w", err)
+	}
+	n8 := int(r.u32())
+	prime := leBytesToBigInt(r.read(n8))
+	r1cs := &CircomR1CS{
+		NWires:         int(r.u32()),
+		NOutputs:       int(r.u32()),
+		NPublicInputs:  int(r.u32()),
+		NPrivateInputs: int(r.u32()),
+		NLabels:        r.u64(),
+	}
+	nConstraints := int(r.u32())
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse r1cs header: %w", r.err)
+	}
+	if n8 != bn254fr.Bytes || prime.Cmp(bn254fr.Modulus()) != 0 {
+		return nil, fmt.Errorf("unsupported r1cs prime %s, only bn254 is supported", prime)
+	}
+	if r1cs.NWires < 1+r1cs.NPublic()+r1cs.NPrivateInputs {
+		return nil, fmt.Errorf("invalid r1cs: %d wires are not enough for %d outputs, %d public and %d private inputs",
+			r1cs.NWires, r1cs.NOutputs, r1cs.NPublicInputs, r1cs.NPrivateInputs)
+	}
+
+	// Constraints
+	r, err = f.section(r1csSectionConstraints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse r1cs: %w", err)
+	}
+	if nConstraints > r.remaining()/12 { // each constraint holds at least three term counts
+		return nil, fmt.Errorf("invalid r1cs: %d constraints do not fit in the constraints section", nConstraints)
+	}
+	r1cs.Constraints = make([]CircomConstraint, nConstraints)
+	nTerms := 0
+	for i := range r1cs.Constraints {
+		c := &r1cs.Constraints[i]
+		for _, lc := range []*[]CircomTerm{&c.A, &c.B, &c.C} {
+			*lc = readLinearCombination(r, r1cs.NWires)
+			nTerms += len(*lc)
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to parse r1cs constraint %d: %w", i, r.err)
+		}
+	}
+
+	// Wire to label map (optional)
+	if _, ok := f.Sections[r1csSectionWireToLabel]; ok {
+		r, err = f.section(r1csSectionWireToLabel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse r1cs: %w", err)
+		}
+		if r.remaining() != 8*r1cs.NWires {
+			return nil, fmt.Errorf("invalid r1cs: wire to label map should contain %d entries", r1cs.NWires)
+		}
+		r1cs.WireToLabel = make([]uint64, r1cs.NWires)
+		for i := range r1cs.WireToLabel {
+			r1cs.WireToLabel[i] = r.u64()
+		}
+	}
+
+	// NWires comes from the header, so it is bounded by the wire to label
+	// map or, without it, by the constraints: every wire besides the inputs
+	// is computed by some constraint. This keeps ConstraintSystem from
+	// allocating a huge number of variables for a corrupted header.
+	if r1cs.WireToLabel == nil && r1cs.NWires > 1+r1cs.NPublic()+r1cs.NPrivateInputs+nTerms {
+		return nil, fmt.Errorf("invalid r1cs: %d wires do not fit in the constraints section", r1cs.NWires)
+	}
+
+	// Custom gates (optional)
+	if _, ok := f.Sections[r1csSectionCustomGatesList]; ok {
+		r, err = f.section(r1csSectionCustomGatesList)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse r1cs: %w", err)
+		}
+		r1cs.CustomGates = make([]CircomCustomGate, r.count(5))
+		for i := range r1cs.CustomGates {
+			g := &r1cs.CustomGates[i]
+			g.Name = r.cstring()
+			g.Parameters = make([]bn254fr.Element, r.count(bn254fr.Bytes))
+			for j := range g.Parameters {
+				g.Parameters[j] = readFr(r)
+			}
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to parse r1cs custom gates: %w", r.err)
+		}
+	}
+	if _, ok := f.Sections[r1csSectionCustomGatesUses]; ok {
+		r, err = f.section(r1csSectionCustomGatesUses)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse r1cs: %w", err)
+		}
+		r1cs.CustomGateUses = make([]CircomCustomGateUse, r.count(8))
+		for i := range r1cs.CustomGateUses {
+			u := &r1cs.CustomGateUses[i]
+			u.GateID = int(r.u32())
+			u.Signals = make([]uint64, r.count(8))
+			for j := range u.Signals {
+				u.Signals[j] = r.u64()
+			}
+			if r.err == nil && u.GateID >= len(r1cs.CustomGates) {
+				return nil, fmt.Errorf("invalid r1cs: custom gate use %d references unknown gate %d", i, u.GateID)
+			}
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to parse r1cs custom gate uses: %w", r.err)
+		}
+	}
+	return r1cs, nil
+}
+
+// readLinearCombination reads a list of (wire, coefficient) terms.
+func readLinearCombination(r *binReader, nWires int) []CircomTerm {
+	terms := make([]CircomTerm, r.count(4+bn254fr.Bytes))
+	for i := range terms {
+		terms[i].Wire = int(r.u32())
+		terms[i].Coeff = readFr(r)
+		if r.err == nil && terms[i].Wire >= nWires {
+			r.err = fmt.Errorf("wire %d out of range", terms[i].Wire)
+		}
+	}
+	return terms
+}
+
+// readFr reads a scalar field element in canonical little-endian form.
+func readFr(r *binReader) bn254fr.Element {
+	b := r.read(bn254fr.Bytes)
+	if b == nil {
+		return bn254fr.Element{}
+	}
+	e, err := bn254fr.LittleEndian.Element((*[bn254fr.Bytes]byte)(b))
+	if err != nil {
+		r.err = err
+	}
+	return e
+}
+
+// NPublic returns the number of public signals (outputs and public inputs),
+// which matches the nPublic field of the verification key.
+func (r *CircomR1CS) NPublic() int {
+	return r.NOutputs + r.NPublicInputs
+}
+
+// CheckVerificationKey checks that the number of public signals of the
+// circuit matches the given verification key.
+func (r *CircomR1CS) CheckVerificationKey(vk *CircomVerificationKey) error {
+	if vk.NPublic != r.NPublic() {
+		return fmt.Errorf("verification key has %d public signals, but the circuit has %d (%d outputs and %d public inputs)",
+			vk.NPublic, r.NPublic(), r.NOutputs, r.NPublicInputs)
+	}
+	return nil
+}
+
+// ConstraintSystem builds a gnark R1CS over bn254 with the constraints of the
+// Circom circuit. Circom wire i is mapped to gnark wire i: the constant one
+// and the public signals are public variables, and all the other wires
+// (private inputs and internal signals) are secret variables, since Circom
+// computes them outside the constraint system. A full Circom witness (see
+// CircomWitness) can thus be checked with IsSolved.
+//
+// Circuits using custom templates are not supported, as they are only
+// meaningful for PLONK.
+func (r *CircomR1CS) ConstraintSystem() (constraint.ConstraintSystem, error) {
+	if len(r.CustomGateUses) > 0 {
+		return nil, fmt.Errorf("circuits with custom gates cannot be represented as a R1CS")
+	}
+	ccs := cs.NewR1CS(len(r.Constraints))
+	ccs.AddPublicVariable("1")
+	for i := 1; i < r.NWires; i++ {
+		name := fmt.Sprintf("w%d", i)
+		if i <= r.NPublic() {
+			ccs.AddPublicVariable(name)
+		} else {
+			ccs.AddSecretVariable(name)
+		}
+	}
+	blueprint := ccs.AddBlueprint(&constraint.BlueprintGenericR1C{})
+	toLinearExpression := func(terms []CircomTerm) constraint.LinearExpression {
+		le := make(constraint.LinearExpression, len(terms))
+		for i, t := range terms {
+			le[i] = ccs.MakeTerm(ccs.FromInterface(t.Coeff), t.Wire)
+		}
+		return le
+	}
+	for _, c := range r.Constraints {
+		ccs.AddR1C(constraint.R1C{
+			L: toLinearExpression(c.A),
+			R: toLinearExpression(c.B),
+			O: toLinearExpression(c.C),
+		}, blueprint)
+	}
+	return ccs, nil
+}
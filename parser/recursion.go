@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/std/math/emulated"
 	recursion "github.com/consensys/gnark/std/recursion/groth16"
 
@@ -14,16 +15,24 @@ import (
 // ConvertCircomToGnarkRecursion converts a Circom proof, verification key, and
 // public signals to the Gnark recursion proof format. If fixedVk is true, the
 // verification key is fixed and must be defined as 'gnark:"-"' in the Circuit.
+// Public signals are parsed with ConvertPublicInputsStrict, after checking the
+// inputs with ValidateTriple.
 func ConvertCircomToGnarkRecursion(circomVk *CircomVerificationKey,
 	circomProof *CircomProof, circomPublicSignals []string, fixedVk bool,
 ) (*GnarkRecursionProof, error) {
+	if err := validateTripleShape(circomVk, circomProof, circomPublicSignals); err != nil {
+		return nil, err
+	}
+	if err := checkCurve("vk", circomVk.Curve, ecc.BN254); err != nil {
+		return nil, err
+	}
 	// Convert public signals to field elements
-	publicInputs, err := ConvertPublicInputs(circomPublicSignals)
+	publicInputs, err := ConvertPublicInputsStrict(circomPublicSignals)
 	if err != nil {
 		return nil, err
 	}
 	// Convert the proof and verification key to gnark types
-	gnarkProof, err := ConvertProof(circomProof)
+	gnarkProof, err := convertProof(circomProof)
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +42,7 @@ func ConvertCircomToGnarkRecursion(circomVk *CircomVerificationKey,
 		return nil, fmt.Errorf("failed to convert proof to recursion proof: %w", err)
 	}
 	// Convert the verification key to recursion verification key
-	gnarkVk, err := ConvertVerificationKey(circomVk)
+	gnarkVk, err := convertVerificationKey(circomVk)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// VerifierRegistry caches converted and precomputed BN254 verification keys,
+// keyed by their SHA-256 fingerprint, so that proofs can be verified against
+// a known key without parsing it and running vk.Precompute on every call. The
+// keys are stored as a PrecomputedVerifyingKey.
+// When the registry is full, the least recently used key is evicted. It is
+// safe for concurrent use.
+type VerifierRegistry struct {
+	mu      sync.Mutex
+	maxKeys int
+	keys    map[[32]byte]*list.Element
+	lru     *list.List // of *registryEntry, most recently used first
+}
+
+type registryEntry struct {
+	id  [32]byte
+	pvk *PrecomputedVerifyingKey
+}
+
+// NewVerifierRegistry returns a registry holding at most maxKeys verification
+// keys. A maxKeys of zero or less means no limit.
+func NewVerifierRegistry(maxKeys int) *VerifierRegistry {
+	return &VerifierRegistry{
+		maxKeys: maxKeys,
+		keys:    make(map[[32]byte]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Register validates, including vk_alphabeta_12, converts and precomputes
+// the verification key, adds it to the registry and returns its ID, the
+// SHA-256 fingerprint of the key as returned by
+// vk.Fingerprint(FingerprintSHA256). Registering a key that is already
+// present only marks it as recently used.
+func (r *VerifierRegistry) Register(vk *CircomVerificationKey) ([32]byte, error) {
+	if vk == nil {
+		return [32]byte{}, newError(ErrInternal, "", "nil verification key")
+	}
+	if err := vk.ValidateAlphabeta12(); err != nil {
+		return [32]byte{}, err
+	}
+	gnarkVk, err := ConvertVerificationKey(vk)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return r.add(gnarkVk)
+}
+
+// RegisterVerifyingKey adds a copy of a gnark verifying key to the registry
+// and returns its ID, as Register does. The copy is precomputed, and vk is
+// left unchanged. Keys with commitments have no Circom equivalent and are
+// rejected.
+func (r *VerifierRegistry) RegisterVerifyingKey(vk *groth16_bn254.VerifyingKey) ([32]byte, error) {
+	if vk == nil {
+		return [32]byte{}, newError(ErrInternal, "", "nil verifying key")
+	}
+	if len(vk.CommitmentKeys) > 0 || len(vk.PublicAndCommitmentCommitted) > 0 {
+		return [32]byte{}, newError(ErrInternal, "vk", "verifying keys with commitments are not supported")
+	}
+	vkCopy := *vk
+	vkCopy.G1.K = append([]curve.G1Affine(nil), vk.G1.K...)
+	if err := vkCopy.Precompute(); err != nil {
+		return [32]byte{}, newError(ErrInternal, "", "failed to precompute verification key: %v", err)
+	}
+	return r.add(&vkCopy)
+}
+
+func (r *VerifierRegistry) add(vk *groth16_bn254.VerifyingKey) ([32]byte, error) {
+	id, err := VerifyingKeyFingerprint(vk, FingerprintSHA256)
+	if err != nil {
+		return id, err
+	}
+	if _, ok := r.Get(id); ok {
+		return id, nil
+	}
+	pvk, err := NewPrecomputedVerifyingKey(vk)
+	if err != nil {
+		return id, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.keys[id]; ok {
+		r.lru.MoveToFront(e)
+		return id, nil
+	}
+	r.keys[id] = r.lru.PushFront(&registryEntry{id: id, pvk: pvk})
+	if r.maxKeys > 0 && r.lru.Len() > r.maxKeys {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.keys, oldest.Value.(*registryEntry).id)
+	}
+	return id, nil
+}
+
+// Get returns the verification key with the given ID, if registered, and
+// marks it as recently used. The key must not be modified.
+func (r *VerifierRegistry) Get(vkID [32]byte) (*groth16_bn254.VerifyingKey, bool) {
+	pvk, ok := r.getPrecomputed(vkID)
+	if !ok {
+		return nil, false
+	}
+	return pvk.VerifyingKey, true
+}
+
+func (r *VerifierRegistry) getPrecomputed(vkID [32]byte) (*PrecomputedVerifyingKey, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.keys[vkID]
+	if !ok {
+		return nil, false
+	}
+	r.lru.MoveToFront(e)
+	return e.Value.(*registryEntry).pvk, true
+}
+
+// Remove removes the verification key with the given ID, if registered.
+func (r *VerifierRegistry) Remove(vkID [32]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.keys[vkID]; ok {
+		r.lru.Remove(e)
+		delete(r.keys, vkID)
+	}
+}
+
+// Len returns the number of registered verification keys.
+func (r *VerifierRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lru.Len()
+}
+
+// Verify verifies a Circom proof and its public signals against the
+// registered verification key with the given ID. Only the proof and the
+// public signals are parsed. An unregistered key is reported as
+// ErrUnknownKey, and the other errors are those of ConvertCircomToGnark and
+// VerifyProof.
+func (r *VerifierRegistry) Verify(vkID [32]byte, proof *CircomProof, publicSignals []string) (bool, error) {
+	pvk, ok := r.getPrecomputed(vkID)
+	if !ok {
+		return false, newError(ErrUnknownKey, "vk", "no verification key with ID %x", vkID)
+	}
+	if proof == nil {
+		return false, newError(ErrInternal, "proof", "nil proof")
+	}
+	if err := proof.Validate(); err != nil {
+		return false, err
+	}
+	if err := checkCurve("proof", proof.Curve, ecc.BN254); err != nil {
+		return false, err
+	}
+	if nPublic := len(pvk.VerifyingKey.G1.K) - 1; len(publicSignals) != nPublic {
+		return false, newError(ErrNPublicMismatch, "publicSignals", "expected %d public signals, got %d",
+			nPublic, len(publicSignals))
+	}
+	publicInputs, err := ConvertPublicInputsStrict(publicSignals)
+	if err != nil {
+		return false, err
+	}
+	gnarkProof, err := convertProof(proof)
+	if err != nil {
+		return false, err
+	}
+	return pvk.Verify(gnarkProof, publicInputs)
+}
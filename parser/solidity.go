@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend/groth16"
 	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
 	"github.com/consensys/gnark/backend/witness"
@@ -41,14 +43,6 @@ func ExportWitnessToSolidityInputs(w witness.Witness, circuitAssignments fronten
 
 //////////////////////
 
-var (
-	groth16CommitmentProofABI, _ = abi.NewType("tuple", "Groth16CommitmentProof", []abi.ArgumentMarshaling{
-		{Name: "proof", Type: "uint256[8]"},
-		{Name: "commitments", Type: "uint256[2]"},
-		{Name: "commitment_pok", Type: "uint256[2]"},
-	})
-)
-
 // SolidityProof represents a Groth16 proof for Solidity (without commitments).
 type SolidityProof struct {
 	Ar  [2]*big.Int    `json:"Ar"`
@@ -56,54 +50,118 @@ type SolidityProof struct {
 	Krs [2]*big.Int    `json:"Krs"`
 }
 
-// Groth16CommitmentProof represents a Groth16 proof with commitments, for Solidity.
-type Groth16CommitmentProof struct {
-	Proof         SolidityProof `json:"proof"`
-	Commitments   [2]*big.Int   `json:"commitments"`
-	CommitmentPok [2]*big.Int   `json:"commitment_pok"`
-}
-
-// FromGnarkProof converts a gnark groth16 proof to a Solidity‑compatible proof.
-func (p *Groth16CommitmentProof) FromGnarkProof(proof groth16.Proof) error {
-	g16proof, ok := proof.(*groth16_bn254.Proof)
-	if !ok {
-		return fmt.Errorf("expected groth16_bn254.Proof, got %T", proof)
-	}
-
-	solProof := SolidityProof{
+// newSolidityProof returns the Solidity encoding of the points of a proof.
+// G2 coordinates are written imaginary part first, as the EVM precompiles
+// expect.
+func newSolidityProof(proof *groth16_bn254.Proof) SolidityProof {
+	return SolidityProof{
 		Ar: [2]*big.Int{
-			g16proof.Ar.X.BigInt(new(big.Int)),
-			g16proof.Ar.Y.BigInt(new(big.Int)),
+			proof.Ar.X.BigInt(new(big.Int)),
+			proof.Ar.Y.BigInt(new(big.Int)),
 		},
 		Bs: [2][2]*big.Int{
 			{
-				g16proof.Bs.X.A1.BigInt(new(big.Int)),
-				g16proof.Bs.X.A0.BigInt(new(big.Int)),
+				proof.Bs.X.A1.BigInt(new(big.Int)),
+				proof.Bs.X.A0.BigInt(new(big.Int)),
 			},
 			{
-				g16proof.Bs.Y.A1.BigInt(new(big.Int)),
-				g16proof.Bs.Y.A0.BigInt(new(big.Int)),
+				proof.Bs.Y.A1.BigInt(new(big.Int)),
+				proof.Bs.Y.A0.BigInt(new(big.Int)),
 			},
 		},
 		Krs: [2]*big.Int{
-			g16proof.Krs.X.BigInt(new(big.Int)),
-			g16proof.Krs.Y.BigInt(new(big.Int)),
+			proof.Krs.X.BigInt(new(big.Int)),
+			proof.Krs.Y.BigInt(new(big.Int)),
 		},
 	}
+}
 
-	com := [2]*big.Int{
-		g16proof.Commitments[0].X.BigInt(new(big.Int)),
-		g16proof.Commitments[0].Y.BigInt(new(big.Int)),
+// Groth16SolidityProof holds the arguments of the verifyProof function of the
+// Solidity verifier exported by gnark for a verifying key with any number of
+// Pedersen commitments:
+//
+//	verifyProof(uint256[8] proof, uint256[2n] commitments, uint256[2] commitmentPok, uint256[m] input)
+//
+// where commitments and commitmentPok are omitted if there is no commitment.
+type Groth16SolidityProof struct {
+	Proof SolidityProof `json:"proof"`
+	// Commitments holds the x and y coordinates of each commitment.
+	Commitments   []*big.Int  `json:"commitments"`
+	CommitmentPok [2]*big.Int `json:"commitment_pok"`
+	PublicInputs  []*big.Int  `json:"public_inputs"`
+}
+
+// FromGnarkProof converts a gnark BN254 Groth16 proof and its public witness
+// to a Solidity‑compatible proof. publicWitness may also be the full witness.
+func (p *Groth16SolidityProof) FromGnarkProof(proof groth16.Proof, publicWitness witness.Witness) error {
+	g16proof, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return fmt.Errorf("expected groth16_bn254.Proof, got %T", proof)
+	}
+	if publicWitness == nil {
+		return fmt.Errorf("missing public witness")
+	}
+	pub, err := publicWitness.Public()
+	if err != nil {
+		return fmt.Errorf("failed to extract public witness: %w", err)
+	}
+	vector, ok := pub.Vector().(bn254fr.Vector)
+	if !ok {
+		return fmt.Errorf("expected a BN254 witness, got %T", pub.Vector())
 	}
 
-	comPok := [2]*big.Int{
+	p.Proof = newSolidityProof(g16proof)
+	p.Commitments = make([]*big.Int, 0, 2*len(g16proof.Commitments))
+	for _, c := range g16proof.Commitments {
+		p.Commitments = append(p.Commitments, c.X.BigInt(new(big.Int)), c.Y.BigInt(new(big.Int)))
+	}
+	p.CommitmentPok = [2]*big.Int{
 		g16proof.CommitmentPok.X.BigInt(new(big.Int)),
 		g16proof.CommitmentPok.Y.BigInt(new(big.Int)),
 	}
+	p.PublicInputs = make([]*big.Int, len(vector))
+	for i := range vector {
+		p.PublicInputs[i] = vector[i].BigInt(new(big.Int))
+	}
+	return nil
+}
+
+var groth16CommitmentProofABI, _ = abi.NewType("tuple", "Groth16CommitmentProof", []abi.ArgumentMarshaling{
+	{Name: "proof", Type: "uint256[8]"},
+	{Name: "commitments", Type: "uint256[2]"},
+	{Name: "commitment_pok", Type: "uint256[2]"},
+})
+
+// Groth16CommitmentProof represents a Groth16 proof with a single commitment,
+// for Solidity.
+//
+// Deprecated: use Groth16SolidityProof, which supports any number of
+// commitments and includes the public inputs.
+type Groth16CommitmentProof struct {
+	Proof         SolidityProof `json:"proof"`
+	Commitments   [2]*big.Int   `json:"commitments"`
+	CommitmentPok [2]*big.Int   `json:"commitment_pok"`
+}
 
-	p.Proof = solProof
-	p.Commitments = com
-	p.CommitmentPok = comPok
+// FromGnarkProof converts a gnark groth16 proof with exactly one commitment
+// to a Solidity‑compatible proof.
+func (p *Groth16CommitmentProof) FromGnarkProof(proof groth16.Proof) error {
+	g16proof, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return fmt.Errorf("expected groth16_bn254.Proof, got %T", proof)
+	}
+	if len(g16proof.Commitments) != 1 {
+		return fmt.Errorf("expected one commitment, got %d: use Groth16SolidityProof", len(g16proof.Commitments))
+	}
+	p.Proof = newSolidityProof(g16proof)
+	p.Commitments = [2]*big.Int{
+		g16proof.Commitments[0].X.BigInt(new(big.Int)),
+		g16proof.Commitments[0].Y.BigInt(new(big.Int)),
+	}
+	p.CommitmentPok = [2]*big.Int{
+		g16proof.CommitmentPok.X.BigInt(new(big.Int)),
+		g16proof.CommitmentPok.Y.BigInt(new(big.Int)),
+	}
 	return nil
 }
 
@@ -135,3 +193,174 @@ func (p *Groth16CommitmentProof) ABIEncode() ([]byte, error) {
 	}
 	return packer.Pack(proofABI)
 }
+
+// ABIArguments returns the arguments of verifyProof for the number of
+// commitments and public inputs of the proof.
+func (p *Groth16SolidityProof) ABIArguments() (abi.Arguments, error) {
+	return groth16SolidityArguments(len(p.Commitments)/2, len(p.PublicInputs))
+}
+
+// groth16SolidityArguments returns the ABI arguments of verifyProof for
+// nbCommitments commitments and nbInputs public inputs.
+func groth16SolidityArguments(nbCommitments, nbInputs int) (abi.Arguments, error) {
+	types := []string{"uint256[8]"}
+	if nbCommitments > 0 {
+		types = append(types, fmt.Sprintf("uint256[%d]", 2*nbCommitments), "uint256[2]")
+	}
+	types = append(types, fmt.Sprintf("uint256[%d]", nbInputs))
+	return abiArguments(types...)
+}
+
+// ABIEncode encodes the arguments of verifyProof, including the public
+// inputs, without the function selector.
+func (p *Groth16SolidityProof) ABIEncode() ([]byte, error) {
+	if len(p.Commitments)%2 != 0 {
+		return nil, fmt.Errorf("odd number of commitment coordinates %d", len(p.Commitments))
+	}
+	args, err := p.ABIArguments()
+	if err != nil {
+		return nil, err
+	}
+	proof := [8]*big.Int{
+		p.Proof.Ar[0],
+		p.Proof.Ar[1],
+		p.Proof.Bs[0][0],
+		p.Proof.Bs[0][1],
+		p.Proof.Bs[1][0],
+		p.Proof.Bs[1][1],
+		p.Proof.Krs[0],
+		p.Proof.Krs[1],
+	}
+	values := []any{proof}
+	if len(p.Commitments) > 0 {
+		values = append(values, p.Commitments, p.CommitmentPok)
+	}
+	values = append(values, p.PublicInputs)
+	data, err := args.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode proof: %w", err)
+	}
+	return data, nil
+}
+
+// ABIEncodeCall encodes a verifyProof call: the function selector followed
+// by the arguments.
+func (p *Groth16SolidityProof) ABIEncodeCall() ([]byte, error) {
+	args, err := p.ABIArguments()
+	if err != nil {
+		return nil, err
+	}
+	data, err := p.ABIEncode()
+	if err != nil {
+		return nil, err
+	}
+	return append(abiMethodID("verifyProof", args), data...), nil
+}
+
+// abiArguments returns unnamed ABI arguments of the given types.
+func abiArguments(types ...string) (abi.Arguments, error) {
+	args := make(abi.Arguments, len(types))
+	for i, t := range types {
+		typ, err := abi.NewType(t, "", nil)
+		if err != nil {
+			return nil, newError(ErrInternal, "", "failed to create ABI type %s: %v", t, err)
+		}
+		args[i] = abi.Argument{Type: typ}
+	}
+	return args, nil
+}
+
+// abiMethodID returns the function selector of a method.
+func abiMethodID(name string, args abi.Arguments) []byte {
+	method := abi.Method{Name: name, RawName: name, Inputs: args}
+	return method.ID()
+}
+
+// SolidityCalldata holds the arguments of the verifyProof function of the
+// Groth16 verifiers exported by SnarkJS:
+//
+//	verifyProof(uint[2] _pA, uint[2][2] _pB, uint[2] _pC, uint[N] _pubSignals)
+type SolidityCalldata struct {
+	Proof        SolidityProof `json:"proof"`
+	PublicInputs []*big.Int    `json:"publicInputs"`
+}
+
+// ConvertCircomToSolidityCalldata converts a Circom BN254 proof and its public
+// signals into the calldata of a SnarkJS Solidity verifier, as
+// `snarkjs zkey export soliditycalldata` does. Public signals must be
+// canonical scalars, as for ConvertPublicInputsStrict.
+func ConvertCircomToSolidityCalldata(proof *CircomProof, publicSignals []string) (*SolidityCalldata, error) {
+	gnarkProof, err := ConvertProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([]*big.Int, len(publicSignals))
+	for i, s := range publicSignals {
+		if inputs[i], err = stringToScalar(s, bn254fr.Modulus()); err != nil {
+			return nil, &Error{Kind: ErrInvalidScalar, Path: fmt.Sprintf("publicSignals[%d]", i), Err: err}
+		}
+	}
+	return &SolidityCalldata{Proof: newSolidityProof(gnarkProof), PublicInputs: inputs}, nil
+}
+
+// arguments returns the ABI arguments of verifyProof.
+func (c *SolidityCalldata) arguments() (abi.Arguments, error) {
+	return solidityCalldataArguments(len(c.PublicInputs))
+}
+
+// solidityCalldataArguments returns the ABI arguments of verifyProof for
+// nbInputs public inputs.
+func solidityCalldataArguments(nbInputs int) (abi.Arguments, error) {
+	return abiArguments("uint256[2]", "uint256[2][2]", "uint256[2]", fmt.Sprintf("uint256[%d]", nbInputs))
+}
+
+// ABIEncode encodes the arguments of verifyProof, without the function
+// selector. As all of them are static, this is the concatenation of the
+// values as 32-byte big-endian words.
+func (c *SolidityCalldata) ABIEncode() ([]byte, error) {
+	args, err := c.arguments()
+	if err != nil {
+		return nil, err
+	}
+	data, err := args.Pack(c.Proof.Ar, c.Proof.Bs, c.Proof.Krs, c.PublicInputs)
+	if err != nil {
+		return nil, newError(ErrInternal, "", "failed to encode calldata: %v", err)
+	}
+	return data, nil
+}
+
+// ABIEncodeCall encodes a verifyProof call: the function selector followed
+// by the arguments, ready to be sent to the verifier contract.
+func (c *SolidityCalldata) ABIEncodeCall() ([]byte, error) {
+	args, err := c.arguments()
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.ABIEncode()
+	if err != nil {
+		return nil, err
+	}
+	return append(abiMethodID("verifyProof", args), data...), nil
+}
+
+// String returns the textual calldata printed by
+// `snarkjs zkey export soliditycalldata`: quoted, 0x-prefixed hexadecimal
+// values padded to 32 bytes.
+func (c *SolidityCalldata) String() string {
+	p := c.Proof
+	inputs := make([]string, len(c.PublicInputs))
+	for i, x := range c.PublicInputs {
+		inputs[i] = soliditycalldataHex(x)
+	}
+	return fmt.Sprintf("[%s, %s],[[%s, %s],[%s, %s]],[%s, %s],[%s]",
+		soliditycalldataHex(p.Ar[0]), soliditycalldataHex(p.Ar[1]),
+		soliditycalldataHex(p.Bs[0][0]), soliditycalldataHex(p.Bs[0][1]),
+		soliditycalldataHex(p.Bs[1][0]), soliditycalldataHex(p.Bs[1][1]),
+		soliditycalldataHex(p.Krs[0]), soliditycalldataHex(p.Krs[1]),
+		strings.Join(inputs, ","))
+}
+
+// soliditycalldataHex formats a value as SnarkJS does in soliditycalldata.
+func soliditycalldataHex(x *big.Int) string {
+	return fmt.Sprintf("\"0x%064x\"", x)
+}
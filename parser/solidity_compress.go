@@ -0,0 +1,408 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// CompressedSolidityProof holds the arguments of the verifyCompressedProof
+// function of the gnark Solidity verifier:
+//
+//	verifyCompressedProof(uint256[4] compressedProof, uint256[n] compressedCommitments,
+//		uint256 compressedCommitmentPok, uint256[m] input)
+//
+// where compressedCommitments and compressedCommitmentPok are omitted if there
+// is no commitment. The points are compressed as by the compressProof function
+// of the contract, halving the size of the proof.
+type CompressedSolidityProof struct {
+	// Proof holds a, the imaginary part of the x coordinate of b, its real
+	// part with the two flag bits, and c.
+	Proof         [4]*big.Int `json:"compressed_proof"`
+	Commitments   []*big.Int  `json:"compressed_commitments"`
+	CommitmentPok *big.Int    `json:"compressed_commitment_pok"`
+	PublicInputs  []*big.Int  `json:"public_inputs"`
+}
+
+// FromGnarkProof converts a gnark BN254 Groth16 proof, such as one returned by
+// ConvertProof, to a Solidity proof. Commitments are ignored.
+func (p *SolidityProof) FromGnarkProof(proof groth16.Proof) error {
+	g16proof, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return fmt.Errorf("expected groth16_bn254.Proof, got %T", proof)
+	}
+	*p = newSolidityProof(g16proof)
+	return nil
+}
+
+// Compress compresses the points of the proof as the compressProof function
+// of the gnark Solidity verifier does. The points must be valid, as for
+// ToGnarkProof.
+func (p *SolidityProof) Compress() ([4]*big.Int, error) {
+	var out [4]*big.Int
+	proof, err := p.ToGnarkProof()
+	if err != nil {
+		return out, err
+	}
+	out[0] = compressSolidityG1(&proof.Ar)
+	out[2], out[1] = compressSolidityG2(&proof.Bs)
+	out[3] = compressSolidityG1(&proof.Krs)
+	return out, nil
+}
+
+// DecompressSolidityProof decompresses a proof compressed by
+// SolidityProof.Compress, as the verifyCompressedProof function of the gnark
+// Solidity verifier does.
+func DecompressSolidityProof(compressed [4]*big.Int) (*SolidityProof, error) {
+	var proof groth16_bn254.Proof
+	if err := decompressSolidityG1(&proof.Ar, "proof.pi_a", compressed[0]); err != nil {
+		return nil, err
+	}
+	if err := decompressSolidityG2(&proof.Bs, "proof.pi_b", compressed[2], compressed[1]); err != nil {
+		return nil, err
+	}
+	if err := decompressSolidityG1(&proof.Krs, "proof.pi_c", compressed[3]); err != nil {
+		return nil, err
+	}
+	solProof := newSolidityProof(&proof)
+	return &solProof, nil
+}
+
+// Compress compresses the proof and its commitments.
+func (p *Groth16SolidityProof) Compress() (*CompressedSolidityProof, error) {
+	proof, err := p.ToGnarkProof()
+	if err != nil {
+		return nil, err
+	}
+	c := &CompressedSolidityProof{
+		Commitments:   make([]*big.Int, len(proof.Commitments)),
+		CommitmentPok: new(big.Int),
+		PublicInputs:  p.PublicInputs,
+	}
+	c.Proof[0] = compressSolidityG1(&proof.Ar)
+	c.Proof[2], c.Proof[1] = compressSolidityG2(&proof.Bs)
+	c.Proof[3] = compressSolidityG1(&proof.Krs)
+	for i := range proof.Commitments {
+		c.Commitments[i] = compressSolidityG1(&proof.Commitments[i])
+	}
+	if len(proof.Commitments) > 0 {
+		c.CommitmentPok = compressSolidityG1(&proof.CommitmentPok)
+	}
+	return c, nil
+}
+
+// Compress compresses the proof of the calldata of a Circom proof, for the
+// verifyCompressedProof function of the gnark Solidity verifier, such as the
+// one exported by ExportCircomSolidityVerifier with WithGnarkInterface.
+func (c *SolidityCalldata) Compress() (*CompressedSolidityProof, error) {
+	proof, err := c.Proof.Compress()
+	if err != nil {
+		return nil, err
+	}
+	return &CompressedSolidityProof{
+		Proof:         proof,
+		Commitments:   []*big.Int{},
+		CommitmentPok: new(big.Int),
+		PublicInputs:  c.PublicInputs,
+	}, nil
+}
+
+// Decompress decompresses the proof and its commitments.
+func (c *CompressedSolidityProof) Decompress() (*Groth16SolidityProof, error) {
+	solProof, err := DecompressSolidityProof(c.Proof)
+	if err != nil {
+		return nil, err
+	}
+	p := &Groth16SolidityProof{
+		Proof:         *solProof,
+		Commitments:   make([]*big.Int, 0, 2*len(c.Commitments)),
+		CommitmentPok: [2]*big.Int{new(big.Int), new(big.Int)},
+		PublicInputs:  c.PublicInputs,
+	}
+	if len(c.Commitments) == 0 {
+		return p, nil
+	}
+	var pt curve.G1Affine
+	for i, cmt := range c.Commitments {
+		if err := decompressSolidityG1(&pt, fmt.Sprintf("proof.commitments[%d]", i), cmt); err != nil {
+			return nil, err
+		}
+		p.Commitments = append(p.Commitments, pt.X.BigInt(new(big.Int)), pt.Y.BigInt(new(big.Int)))
+	}
+	if err := decompressSolidityG1(&pt, "proof.commitment_pok", c.CommitmentPok); err != nil {
+		return nil, err
+	}
+	p.CommitmentPok = [2]*big.Int{pt.X.BigInt(new(big.Int)), pt.Y.BigInt(new(big.Int))}
+	return p, nil
+}
+
+// ABIArguments returns the arguments of verifyCompressedProof for the number
+// of commitments and public inputs of the proof.
+func (c *CompressedSolidityProof) ABIArguments() (abi.Arguments, error) {
+	return compressedSolidityArguments(len(c.Commitments), len(c.PublicInputs))
+}
+
+// compressedSolidityArguments returns the ABI arguments of
+// verifyCompressedProof for nbCommitments commitments and nbInputs public
+// inputs.
+func compressedSolidityArguments(nbCommitments, nbInputs int) (abi.Arguments, error) {
+	types := []string{"uint256[4]"}
+	if nbCommitments > 0 {
+		types = append(types, fmt.Sprintf("uint256[%d]", nbCommitments), "uint256")
+	}
+	types = append(types, fmt.Sprintf("uint256[%d]", nbInputs))
+	return abiArguments(types...)
+}
+
+// ABIEncode encodes the arguments of verifyCompressedProof, including the
+// public inputs, without the function selector.
+func (c *CompressedSolidityProof) ABIEncode() ([]byte, error) {
+	args, err := c.ABIArguments()
+	if err != nil {
+		return nil, err
+	}
+	values := []any{c.Proof}
+	if len(c.Commitments) > 0 {
+		values = append(values, c.Commitments, c.CommitmentPok)
+	}
+	values = append(values, c.PublicInputs)
+	data, err := args.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode proof: %w", err)
+	}
+	return data, nil
+}
+
+// ABIEncodeCall encodes a verifyCompressedProof call: the function selector
+// followed by the arguments.
+func (c *CompressedSolidityProof) ABIEncodeCall() ([]byte, error) {
+	args, err := c.ABIArguments()
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.ABIEncode()
+	if err != nil {
+		return nil, err
+	}
+	return append(abiMethodID("verifyCompressedProof", args), data...), nil
+}
+
+// ABIDecode decodes the arguments of verifyCompressedProof, as encoded by
+// ABIEncode or, with the function selector, by ABIEncodeCall, for a verifying
+// key with nbCommitments commitments. The number of public inputs is given by
+// the size of the data. The points are checked by Decompress.
+func (c *CompressedSolidityProof) ABIDecode(data []byte, nbCommitments int) error {
+	// Bound nbCommitments by the size of the data before computing sizes
+	if nbCommitments < 0 || nbCommitments > len(data)/solidityWordSize {
+		return newError(ErrMalformedBinary, "calldata", "invalid number of commitments %d for %d bytes", nbCommitments, len(data))
+	}
+	size := 4 * solidityWordSize
+	if nbCommitments > 0 {
+		size += (nbCommitments + 1) * solidityWordSize
+	}
+	data, err := trimSelector(data, "verifyCompressedProof", size, func(nbInputs int) (abi.Arguments, error) {
+		return compressedSolidityArguments(nbCommitments, nbInputs)
+	})
+	if err != nil {
+		return err
+	}
+	if len(data) < size || len(data)%solidityWordSize != 0 {
+		return newError(ErrMalformedBinary, "calldata", "invalid calldata size %d for %d commitments", len(data), nbCommitments)
+	}
+	words := solidityWords(data[:size])
+	decoded := CompressedSolidityProof{
+		Proof:         [4]*big.Int{words[0], words[1], words[2], words[3]},
+		Commitments:   []*big.Int{},
+		CommitmentPok: new(big.Int),
+	}
+	if nbCommitments > 0 {
+		decoded.Commitments = words[4 : 4+nbCommitments]
+		decoded.CommitmentPok = words[4+nbCommitments]
+	}
+	if decoded.PublicInputs, err = solidityScalars(data[size:]); err != nil {
+		return err
+	}
+	*c = decoded
+	return nil
+}
+
+// Constants of the point compression of the gnark Solidity verifier.
+var (
+	// expSqrtFp is (p + 1) / 4: a^expSqrtFp is the square root of a, if any.
+	expSqrtFp = new(big.Int).Rsh(new(big.Int).Add(fp.Modulus(), big.NewInt(1)), 2)
+	// fraction27_82Fp and fraction3_82Fp are the coefficients of the twist
+	// b' = 3/(9 + i) = 27/82 - 3/82·i.
+	fraction27_82Fp, fraction3_82Fp = func() (a, b fp.Element) {
+		var inv82 fp.Element
+		inv82.SetUint64(82).Inverse(&inv82)
+		a.SetUint64(27).Mul(&a, &inv82)
+		b.SetUint64(3).Mul(&b, &inv82)
+		return a, b
+	}()
+)
+
+// sqrtFp returns a^((p+1)/4), the square root of a computed by the contract,
+// and whether it is one.
+func sqrtFp(a *fp.Element) (fp.Element, bool) {
+	var x, x2 fp.Element
+	x.Exp(*a, expSqrtFp)
+	x2.Square(&x)
+	return x, x2.Equal(a)
+}
+
+// sqrtFp2 returns the square root of a0 + a1·i computed by the sqrt_Fp2
+// function of the contract, with the given hint, and whether it is one.
+func sqrtFp2(a0, a1 *fp.Element, hint bool) (x0, x1 fp.Element, ok bool) {
+	var n fp.Element
+	n.Square(a0)
+	var t fp.Element
+	t.Square(a1)
+	n.Add(&n, &t)
+	d, ok := sqrtFp(&n)
+	if !ok {
+		return x0, x1, false
+	}
+	if hint {
+		d.Neg(&d)
+	}
+	var half fp.Element
+	half.SetUint64(2).Inverse(&half)
+	t.Add(a0, &d).Mul(&t, &half)
+	if x0, ok = sqrtFp(&t); !ok || x0.IsZero() {
+		return x0, x1, false
+	}
+	t.Double(&x0).Inverse(&t)
+	x1.Mul(a1, &t)
+
+	// Check the root: (x0 + x1·i)² = x0² - x1² + 2·x0·x1·i
+	var r0, r1 fp.Element
+	r0.Square(&x0)
+	t.Square(&x1)
+	r0.Sub(&r0, &t)
+	r1.Mul(&x0, &x1).Double(&r1)
+	return x0, x1, r0.Equal(a0) && r1.Equal(a1)
+}
+
+// g2YSquared returns x³ + 3/(9 + i), as computed by the contract.
+func g2YSquared(x0, x1 *fp.Element) (y0, y1 fp.Element) {
+	var n3ab, a3, b3, t fp.Element
+	n3ab.Mul(x0, x1)
+	t.SetUint64(3)
+	n3ab.Mul(&n3ab, &t).Neg(&n3ab)
+	a3.Square(x0).Mul(&a3, x0)
+	b3.Square(x1).Mul(&b3, x1)
+	y0.Mul(&n3ab, x1).Add(&y0, &a3).Add(&y0, &fraction27_82Fp)
+	y1.Mul(&n3ab, x0).Add(&y1, &b3).Add(&y1, &fraction3_82Fp).Neg(&y1)
+	return y0, y1
+}
+
+// compressSolidityG1 compresses a valid G1 point as the compress_g1 function
+// of the contract: x << 1, with the low bit set if y is the negated root.
+func compressSolidityG1(p *curve.G1Affine) *big.Int {
+	if p.IsInfinity() {
+		return new(big.Int)
+	}
+	c := p.X.BigInt(new(big.Int))
+	c.Lsh(c, 1)
+	var y2, three fp.Element
+	three.SetUint64(3)
+	y2.Square(&p.X).Mul(&y2, &p.X).Add(&y2, &three)
+	if yPos, _ := sqrtFp(&y2); !yPos.Equal(&p.Y) {
+		c.SetBit(c, 0, 1)
+	}
+	return c
+}
+
+// compressSolidityG2 compresses a valid G2 point as the compress_g2 function
+// of the contract: c0 = x0 << 2 with the hint bit 1 and the negation bit 0,
+// and c1 = x1.
+func compressSolidityG2(p *curve.G2Affine) (c0, c1 *big.Int) {
+	if p.IsInfinity() {
+		return new(big.Int), new(big.Int)
+	}
+	y0, y1 := g2YSquared(&p.X.A0, &p.X.A1)
+	var n, t fp.Element
+	n.Square(&y0)
+	t.Square(&y1)
+	n.Add(&n, &t)
+	d, _ := sqrtFp(&n)
+	var half fp.Element
+	half.SetUint64(2).Inverse(&half)
+	t.Add(&y0, &d).Mul(&t, &half)
+	_, isSquare := sqrtFp(&t)
+	hint := !isSquare
+
+	c0 = p.X.A0.BigInt(new(big.Int))
+	c0.Lsh(c0, 2)
+	if hint {
+		c0.SetBit(c0, 1, 1)
+	}
+	if y0Pos, y1Pos, _ := sqrtFp2(&y0, &y1, hint); !y0Pos.Equal(&p.Y.A0) || !y1Pos.Equal(&p.Y.A1) {
+		c0.SetBit(c0, 0, 1)
+	}
+	return c0, p.X.A1.BigInt(new(big.Int))
+}
+
+// decompressSolidityG1 sets p to the G1 point compressed as c by
+// compressSolidityG1.
+func decompressSolidityG1(p *curve.G1Affine, path string, c *big.Int) error {
+	if c == nil || c.Sign() < 0 {
+		return newError(ErrInvalidPoint, path, "missing compressed point")
+	}
+	p.X.SetZero()
+	p.Y.SetZero()
+	if c.Sign() == 0 {
+		return nil // point at infinity
+	}
+	if err := solidityFp(&p.X, new(big.Int).Rsh(c, 1)); err != nil {
+		return &Error{Kind: ErrInvalidPoint, Path: path, Err: err}
+	}
+	var y2, three fp.Element
+	three.SetUint64(3)
+	y2.Square(&p.X).Mul(&y2, &p.X).Add(&y2, &three)
+	y, ok := sqrtFp(&y2)
+	if !ok {
+		return newError(ErrInvalidPoint, path, "not on the curve")
+	}
+	if c.Bit(0) == 1 {
+		y.Neg(&y)
+	}
+	p.Y = y
+	// Every point of G1 is in the subgroup
+	return nil
+}
+
+// decompressSolidityG2 sets p to the G2 point compressed as (c0, c1) by
+// compressSolidityG2.
+func decompressSolidityG2(p *curve.G2Affine, path string, c0, c1 *big.Int) error {
+	if c0 == nil || c1 == nil || c0.Sign() < 0 {
+		return newError(ErrInvalidPoint, path, "missing compressed point")
+	}
+	*p = curve.G2Affine{}
+	if c0.Sign() == 0 && c1.Sign() == 0 {
+		return nil // point at infinity
+	}
+	if err := solidityFp(&p.X.A0, new(big.Int).Rsh(c0, 2)); err != nil {
+		return &Error{Kind: ErrInvalidPoint, Path: path, Err: err}
+	}
+	if err := solidityFp(&p.X.A1, c1); err != nil {
+		return &Error{Kind: ErrInvalidPoint, Path: path, Err: err}
+	}
+	y0, y1 := g2YSquared(&p.X.A0, &p.X.A1)
+	var ok bool
+	if p.Y.A0, p.Y.A1, ok = sqrtFp2(&y0, &y1, c0.Bit(1) == 1); !ok {
+		return newError(ErrInvalidPoint, path, "not on the curve")
+	}
+	if c0.Bit(0) == 1 {
+		p.Y.Neg(&p.Y)
+	}
+	if !p.IsInSubGroup() {
+		return newError(ErrInvalidPoint, path, "not in the prime order subgroup")
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+const (
+	solidityWordSize  = 32
+	solidityProofSize = 8 * solidityWordSize
+)
+
+// DecodeSolidityProof decodes the 8 uint256 words of a Groth16 proof in the
+// Solidity encoding: a uint256[8] proof argument of the gnark verifiers, or
+// the 256-byte (a, b, c) seals of the zkVM Groth16 wrappers. The points are
+// checked as by ToGnarkProof.
+func DecodeSolidityProof(data []byte) (*SolidityProof, error) {
+	if len(data) != solidityProofSize {
+		return nil, newError(ErrMalformedBinary, "proof", "invalid proof size %d, expected %d", len(data), solidityProofSize)
+	}
+	p := solidityProofFromWords(solidityWords(data))
+	if _, err := p.ToGnarkProof(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DecodeSolidityCalldata decodes the arguments of the verifyProof function of
+// the SnarkJS Solidity verifiers, as encoded by SolidityCalldata.ABIEncode or,
+// with the function selector, by ABIEncodeCall. The number of public inputs
+// is given by the size of the data. Public inputs must be scalar field
+// elements.
+func DecodeSolidityCalldata(data []byte) (*SolidityCalldata, error) {
+	data, err := trimSelector(data, "verifyProof", solidityProofSize, solidityCalldataArguments)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < solidityProofSize || len(data)%solidityWordSize != 0 {
+		return nil, newError(ErrMalformedBinary, "calldata", "invalid calldata size %d", len(data))
+	}
+	proof, err := DecodeSolidityProof(data[:solidityProofSize])
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := solidityScalars(data[solidityProofSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &SolidityCalldata{Proof: *proof, PublicInputs: inputs}, nil
+}
+
+// ABIDecode decodes the arguments of the verifyProof function of the gnark
+// Solidity verifier, as encoded by ABIEncode or, with the function selector,
+// by ABIEncodeCall, for a verifying key with nbCommitments commitments. The
+// number of public inputs is given by the size of the data.
+func (p *Groth16SolidityProof) ABIDecode(data []byte, nbCommitments int) error {
+	// Bound nbCommitments by the size of the data before computing sizes
+	if nbCommitments < 0 || nbCommitments > len(data)/solidityWordSize {
+		return newError(ErrMalformedBinary, "calldata", "invalid number of commitments %d for %d bytes", nbCommitments, len(data))
+	}
+	size := solidityProofSize
+	if nbCommitments > 0 {
+		size += (2*nbCommitments + 2) * solidityWordSize
+	}
+	data, err := trimSelector(data, "verifyProof", size, func(nbInputs int) (abi.Arguments, error) {
+		return groth16SolidityArguments(nbCommitments, nbInputs)
+	})
+	if err != nil {
+		return err
+	}
+	if len(data) < size || len(data)%solidityWordSize != 0 {
+		return newError(ErrMalformedBinary, "calldata", "invalid calldata size %d for %d commitments", len(data), nbCommitments)
+	}
+	decoded := Groth16SolidityProof{
+		Proof:         *solidityProofFromWords(solidityWords(data[:solidityProofSize])),
+		Commitments:   []*big.Int{},
+		CommitmentPok: [2]*big.Int{new(big.Int), new(big.Int)},
+	}
+	if nbCommitments > 0 {
+		words := solidityWords(data[solidityProofSize:size])
+		decoded.Commitments = words[:2*nbCommitments]
+		decoded.CommitmentPok = [2]*big.Int{words[2*nbCommitments], words[2*nbCommitments+1]}
+	}
+	if decoded.PublicInputs, err = solidityScalars(data[size:]); err != nil {
+		return err
+	}
+	if _, err := decoded.ToGnarkProof(); err != nil {
+		return err
+	}
+	*p = decoded
+	return nil
+}
+
+// groth16CommitmentProofSize is the size of the (uint256[8], uint256[2],
+// uint256[2]) tuple of Groth16CommitmentProof.ABIEncode.
+const groth16CommitmentProofSize = solidityProofSize + 4*solidityWordSize
+
+// ABIDecode decodes a proof encoded by ABIEncode. The points are checked as
+// by Groth16SolidityProof.ToGnarkProof.
+func (p *Groth16CommitmentProof) ABIDecode(data []byte) error {
+	if len(data) != groth16CommitmentProofSize {
+		return newError(ErrMalformedBinary, "proof", "invalid proof size %d, expected %d", len(data), groth16CommitmentProofSize)
+	}
+	words := solidityWords(data)
+	decoded := Groth16CommitmentProof{
+		Proof:         *solidityProofFromWords(words[:8]),
+		Commitments:   [2]*big.Int{words[8], words[9]},
+		CommitmentPok: [2]*big.Int{words[10], words[11]},
+	}
+	solProof := Groth16SolidityProof{
+		Proof:         decoded.Proof,
+		Commitments:   decoded.Commitments[:],
+		CommitmentPok: decoded.CommitmentPok,
+	}
+	if _, err := solProof.ToGnarkProof(); err != nil {
+		return err
+	}
+	*p = decoded
+	return nil
+}
+
+// ToGnarkProof converts the proof to a gnark proof. Coordinates must be
+// reduced, and the points must be on the curve and in the prime order
+// subgroup; (0, 0) is the point at infinity, as for the EVM precompiles.
+func (p *SolidityProof) ToGnarkProof() (*groth16_bn254.Proof, error) {
+	proof := new(groth16_bn254.Proof)
+	if err := solidityG1(&proof.Ar, "proof.pi_a", p.Ar); err != nil {
+		return nil, err
+	}
+	if err := solidityG2(&proof.Bs, "proof.pi_b", p.Bs); err != nil {
+		return nil, err
+	}
+	if err := solidityG1(&proof.Krs, "proof.pi_c", p.Krs); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// ToCircomProof converts the proof to a Circom proof, with the checks of
+// ToGnarkProof.
+func (p *SolidityProof) ToCircomProof() (*CircomProof, error) {
+	proof, err := p.ToGnarkProof()
+	if err != nil {
+		return nil, err
+	}
+	return gnarkProofToCircom(proof)
+}
+
+// ToGnarkProof converts the proof, commitments included, to a gnark proof.
+func (p *Groth16SolidityProof) ToGnarkProof() (*groth16_bn254.Proof, error) {
+	if len(p.Commitments)%2 != 0 {
+		return nil, fmt.Errorf("odd number of commitment coordinates %d", len(p.Commitments))
+	}
+	proof, err := p.Proof.ToGnarkProof()
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Commitments) == 0 {
+		return proof, nil
+	}
+	proof.Commitments = make([]curve.G1Affine, len(p.Commitments)/2)
+	for i := range proof.Commitments {
+		path := fmt.Sprintf("proof.commitments[%d]", i)
+		if err := solidityG1(&proof.Commitments[i], path, [2]*big.Int{p.Commitments[2*i], p.Commitments[2*i+1]}); err != nil {
+			return nil, err
+		}
+	}
+	if err := solidityG1(&proof.CommitmentPok, "proof.commitment_pok", p.CommitmentPok); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// ToCircomProof converts the proof to a Circom proof. Circom proofs have no
+// commitments, so the proof must have none.
+func (p *Groth16SolidityProof) ToCircomProof() (*CircomProof, error) {
+	if len(p.Commitments) > 0 {
+		return nil, newError(ErrInternal, "proof.commitments", "Circom proofs do not support commitments")
+	}
+	return p.Proof.ToCircomProof()
+}
+
+// PublicSignals returns the public inputs as Circom public signals.
+func (p *Groth16SolidityProof) PublicSignals() []string {
+	return bigIntsToStrings(p.PublicInputs)
+}
+
+// PublicSignals returns the public inputs as Circom public signals.
+func (c *SolidityCalldata) PublicSignals() []string {
+	return bigIntsToStrings(c.PublicInputs)
+}
+
+// ToCircomProof converts the proof of the calldata to a Circom proof.
+func (c *SolidityCalldata) ToCircomProof() (*CircomProof, error) {
+	return c.Proof.ToCircomProof()
+}
+
+// gnarkProofToCircom converts the points of a gnark proof to a Circom proof.
+func gnarkProofToCircom(proof *groth16_bn254.Proof) (*CircomProof, error) {
+	piA, err := g1ToCircomString(&proof.Ar)
+	if err != nil {
+		return nil, atPath("proof.pi_a", err)
+	}
+	piB, err := g2ToCircomString(&proof.Bs)
+	if err != nil {
+		return nil, atPath("proof.pi_b", err)
+	}
+	piC, err := g1ToCircomString(&proof.Krs)
+	if err != nil {
+		return nil, atPath("proof.pi_c", err)
+	}
+	return &CircomProof{PiA: piA, PiB: piB, PiC: piC, Protocol: "groth16", Curve: "bn128"}, nil
+}
+
+// solidityProofFromWords returns the proof (a, b, c) of 8 words.
+func solidityProofFromWords(words []*big.Int) *SolidityProof {
+	return &SolidityProof{
+		Ar:  [2]*big.Int{words[0], words[1]},
+		Bs:  [2][2]*big.Int{{words[2], words[3]}, {words[4], words[5]}},
+		Krs: [2]*big.Int{words[6], words[7]},
+	}
+}
+
+// solidityG1 sets p to the G1 point (x, y).
+func solidityG1(p *curve.G1Affine, path string, xy [2]*big.Int) error {
+	for i, c := range []*fp.Element{&p.X, &p.Y} {
+		if err := solidityFp(c, xy[i]); err != nil {
+			return &Error{Kind: ErrInvalidPoint, Path: fmt.Sprintf("%s[%d]", path, i), Err: err}
+		}
+	}
+	if !p.IsOnCurve() || !p.IsInSubGroup() {
+		return newError(ErrInvalidPoint, path, "not on the curve")
+	}
+	return nil
+}
+
+// solidityG2 sets p to the G2 point ((x.A1, x.A0), (y.A1, y.A0)).
+func solidityG2(p *curve.G2Affine, path string, xy [2][2]*big.Int) error {
+	for i, c := range []*curve.E2{&p.X, &p.Y} {
+		for j, a := range []*fp.Element{&c.A1, &c.A0} {
+			if err := solidityFp(a, xy[i][j]); err != nil {
+				return &Error{Kind: ErrInvalidPoint, Path: fmt.Sprintf("%s[%d][%d]", path, i, j), Err: err}
+			}
+		}
+	}
+	if !p.IsOnCurve() {
+		return newError(ErrInvalidPoint, path, "not on the curve")
+	}
+	if !p.IsInSubGroup() {
+		return newError(ErrInvalidPoint, path, "not in the prime order subgroup")
+	}
+	return nil
+}
+
+// solidityFp sets e to x, which must be reduced.
+func solidityFp(e *fp.Element, x *big.Int) error {
+	if x == nil {
+		return fmt.Errorf("missing coordinate")
+	}
+	if x.Sign() < 0 || x.Cmp(fp.Modulus()) >= 0 {
+		return fmt.Errorf("coordinate is not reduced")
+	}
+	e.SetBigInt(x)
+	return nil
+}
+
+// solidityScalars decodes words that must be scalar field elements.
+func solidityScalars(data []byte) ([]*big.Int, error) {
+	words := solidityWords(data)
+	for i, x := range words {
+		if x.Cmp(bn254fr.Modulus()) >= 0 {
+			return nil, newError(ErrInvalidScalar, fmt.Sprintf("publicSignals[%d]", i), "not a scalar field element")
+		}
+	}
+	return words, nil
+}
+
+// solidityWords splits data into 32-byte big-endian words.
+func solidityWords(data []byte) []*big.Int {
+	words := make([]*big.Int, len(data)/solidityWordSize)
+	for i := range words {
+		words[i] = new(big.Int).SetBytes(data[i*solidityWordSize : (i+1)*solidityWordSize])
+	}
+	return words
+}
+
+// trimSelector removes the 4-byte function selector of a call, if any: the
+// arguments of the verifiers are all static, so their size is a multiple of
+// 32. The selector must be the one of the function name, with the arguments
+// returned by args for the number of public inputs that follow the fixedSize
+// bytes of the other arguments.
+func trimSelector(data []byte, name string, fixedSize int, args func(nbInputs int) (abi.Arguments, error)) ([]byte, error) {
+	if len(data)%solidityWordSize != 4 {
+		return data, nil
+	}
+	selector, data := data[:4], data[4:]
+	if len(data) < fixedSize {
+		// The size error is reported by the caller
+		return data, nil
+	}
+	arguments, err := args((len(data) - fixedSize) / solidityWordSize)
+	if err != nil {
+		return nil, err
+	}
+	if want := abiMethodID(name, arguments); !bytes.Equal(selector, want) {
+		return nil, newError(ErrMalformedBinary, "selector", "unexpected function selector %x, expected %x for %s",
+			selector, want, name)
+	}
+	return data, nil
+}
+
+func bigIntsToStrings(values []*big.Int) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = v.String()
+	}
+	return out
+}
@@ -0,0 +1,333 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"strings"
+	"text/template"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark/backend/solidity"
+)
+
+// SolidityExportOption configures ExportCircomSolidityVerifier.
+type SolidityExportOption func(*solidityExportConfig)
+
+type solidityExportConfig struct {
+	pragmaVersion  string
+	gnarkInterface bool
+}
+
+// WithSolidityPragmaVersion sets the Solidity version pragma of the contract.
+// It defaults to ">=0.7.0 <0.9.0", or to "^0.8.0" with WithGnarkInterface.
+func WithSolidityPragmaVersion(version string) SolidityExportOption {
+	return func(cfg *solidityExportConfig) {
+		cfg.pragmaVersion = version
+	}
+}
+
+// WithGnarkInterface renders the gnark Groth16 verifier contract instead, which
+// adds the gnark interface to the SnarkJS one: verifyProof(uint256[8],
+// uint256[N]), verifyCompressedProof(uint256[4], uint256[N]) and
+// compressProof(uint256[8]). These revert on invalid proofs, and the proof
+// points are encoded as in SolidityCalldata, in the order a, b, c.
+func WithGnarkInterface() SolidityExportOption {
+	return func(cfg *solidityExportConfig) {
+		cfg.gnarkInterface = true
+	}
+}
+
+// ExportCircomSolidityVerifier writes a Groth16 verifier contract for a Circom
+// BN254 verification key, without SnarkJS. The contract has the interface of
+// the verifiers exported by `snarkjs zkey export solidityverifier`:
+//
+//	function verifyProof(uint[2] calldata _pA, uint[2][2] calldata _pB,
+//		uint[2] calldata _pC, uint[N] calldata _pubSignals) public view returns (bool)
+//
+// which takes the arguments of SolidityCalldata. The points are those of
+// ConvertVerificationKey. The key must have at least one public signal.
+func ExportCircomSolidityVerifier(vk *CircomVerificationKey, w io.Writer, opts ...SolidityExportOption) error {
+	gnarkVk, err := ConvertVerificationKey(vk)
+	if err != nil {
+		return err
+	}
+	var cfg solidityExportConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	nPublic := len(gnarkVk.G1.K) - 1
+	if nPublic == 0 {
+		// Solidity does not allow the uint256[0] argument of the verifiers
+		return newError(ErrNPublicMismatch, "vk.nPublic", "Solidity verifiers need at least one public signal")
+	}
+
+	if cfg.gnarkInterface {
+		var exportOpts []solidity.ExportOption
+		if cfg.pragmaVersion != "" {
+			exportOpts = append(exportOpts, solidity.WithPragmaVersion(cfg.pragmaVersion))
+		}
+		var buf bytes.Buffer
+		if err := gnarkVk.ExportSolidity(&buf, exportOpts...); err != nil {
+			return newError(ErrInternal, "", "failed to export gnark verifier: %v", err)
+		}
+		// Add the SnarkJS interface before the closing brace of the contract
+		contract := strings.TrimRight(buf.String(), "\n")
+		end := strings.LastIndex(contract, "}")
+		if end < 0 {
+			return newError(ErrInternal, "", "unexpected gnark verifier contract")
+		}
+		var wrapper bytes.Buffer
+		if err := snarkjsWrapperTemplate.Execute(&wrapper, nPublic); err != nil {
+			return newError(ErrInternal, "", "failed to render Solidity verifier: %v", err)
+		}
+		if _, err := io.WriteString(w, contract[:end]+wrapper.String()+contract[end:]+"\n"); err != nil {
+			return newError(ErrInternal, "", "failed to write Solidity verifier: %v", err)
+		}
+		return nil
+	}
+
+	if cfg.pragmaVersion == "" {
+		cfg.pragmaVersion = ">=0.7.0 <0.9.0"
+	}
+	str := func(x fp.Element) string { return x.BigInt(new(big.Int)).String() }
+	data := snarkjsVerifierData{
+		PragmaVersion: cfg.pragmaVersion,
+		Alpha:         [2]string{str(gnarkVk.G1.Alpha.X), str(gnarkVk.G1.Alpha.Y)},
+		NPublic:       nPublic,
+	}
+	// The precompile expects the imaginary part first
+	g2 := func(p *curve.G2Affine) [4]string {
+		return [4]string{str(p.X.A1), str(p.X.A0), str(p.Y.A1), str(p.Y.A0)}
+	}
+	data.Beta, data.Gamma, data.Delta = g2(&gnarkVk.G2.Beta), g2(&gnarkVk.G2.Gamma), g2(&gnarkVk.G2.Delta)
+	for _, k := range gnarkVk.G1.K {
+		data.IC = append(data.IC, [2]string{str(k.X), str(k.Y)})
+	}
+	if err := snarkjsVerifierTemplate.Execute(w, data); err != nil {
+		return newError(ErrInternal, "", "failed to render Solidity verifier: %v", err)
+	}
+	return nil
+}
+
+// snarkjsVerifierData holds the decimal coordinates of the verification key,
+// G2 coordinates imaginary part first.
+type snarkjsVerifierData struct {
+	PragmaVersion      string
+	Alpha              [2]string
+	Beta, Gamma, Delta [4]string
+	IC                 [][2]string
+	NPublic            int
+}
+
+var solidityTemplateFuncs = template.FuncMap{
+	"mul":  func(a, b int) int { return a * b },
+	"sub1": func(a int) int { return a - 1 },
+	"seq": func(from, to int) []int {
+		var out []int
+		for i := from; i <= to; i++ {
+			out = append(out, i)
+		}
+		return out
+	},
+}
+
+// snarkjsVerifierTemplate is the Groth16 verifier of SnarkJS
+// (templates/verifier_groth16.sol.ejs). It checks
+// e(-A, B)·e(α, β)·e(L, γ)·e(C, δ) = 1 with the EVM precompiles.
+var snarkjsVerifierTemplate = template.Must(template.New("verifier").Funcs(solidityTemplateFuncs).Parse(
+	`// SPDX-License-Identifier: GPL-3.0
+/*
+    Copyright 2021 0KIMS association.
+
+    This file is generated with circom2gnark from the Groth16 verifier
+    template of [snarkJS](https://github.com/iden3/snarkjs).
+
+    snarkJS is a free software: you can redistribute it and/or modify it
+    under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    snarkJS is distributed in the hope that it will be useful, but WITHOUT
+    ANY WARRANTY; without even the implied warranty of MERCHANTABILITY
+    or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public
+    License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with snarkJS. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+pragma solidity {{ .PragmaVersion }};
+
+contract Groth16Verifier {
+    // Scalar field size
+    uint256 constant r    = 21888242871839275222246405745257275088548364400416034343698204186575808495617;
+    // Base field size
+    uint256 constant q   = 21888242871839275222246405745257275088696311157297823662689037894645226208583;
+
+    // Verification Key data
+    uint256 constant alphax  = {{ index .Alpha 0 }};
+    uint256 constant alphay  = {{ index .Alpha 1 }};
+    uint256 constant betax1  = {{ index .Beta 0 }};
+    uint256 constant betax2  = {{ index .Beta 1 }};
+    uint256 constant betay1  = {{ index .Beta 2 }};
+    uint256 constant betay2  = {{ index .Beta 3 }};
+    uint256 constant gammax1 = {{ index .Gamma 0 }};
+    uint256 constant gammax2 = {{ index .Gamma 1 }};
+    uint256 constant gammay1 = {{ index .Gamma 2 }};
+    uint256 constant gammay2 = {{ index .Gamma 3 }};
+    uint256 constant deltax1 = {{ index .Delta 0 }};
+    uint256 constant deltax2 = {{ index .Delta 1 }};
+    uint256 constant deltay1 = {{ index .Delta 2 }};
+    uint256 constant deltay2 = {{ index .Delta 3 }};
+{{ range $i, $ic := .IC }}
+    uint256 constant IC{{ $i }}x = {{ index $ic 0 }};
+    uint256 constant IC{{ $i }}y = {{ index $ic 1 }};
+{{ end }}
+    // Memory data
+    uint16 constant pVk = 0;
+    uint16 constant pPairing = 128;
+
+    uint16 constant pLastMem = 896;
+
+    function verifyProof(uint[2] calldata _pA, uint[2][2] calldata _pB, uint[2] calldata _pC, uint[{{ .NPublic }}] calldata _pubSignals) public view returns (bool) {
+        assembly {
+            function checkField(v) {
+                if iszero(lt(v, r)) {
+                    mstore(0, 0)
+                    return(0, 0x20)
+                }
+            }
+
+            // G1 function to multiply a G1 value(x,y) to value in an address
+            function g1_mulAccC(pR, x, y, s) {
+                let success
+                let mIn := mload(0x40)
+                mstore(mIn, x)
+                mstore(add(mIn, 32), y)
+                mstore(add(mIn, 64), s)
+
+                success := staticcall(sub(gas(), 2000), 7, mIn, 96, mIn, 64)
+
+                if iszero(success) {
+                    mstore(0, 0)
+                    return(0, 0x20)
+                }
+
+                mstore(add(mIn, 64), mload(pR))
+                mstore(add(mIn, 96), mload(add(pR, 32)))
+
+                success := staticcall(sub(gas(), 2000), 6, mIn, 128, pR, 64)
+
+                if iszero(success) {
+                    mstore(0, 0)
+                    return(0, 0x20)
+                }
+            }
+
+            function checkPairing(pA, pB, pC, pubSignals, pMem) -> isOk {
+                let _pPairing := add(pMem, pPairing)
+                let _pVk := add(pMem, pVk)
+
+                mstore(_pVk, IC0x)
+                mstore(add(_pVk, 32), IC0y)
+
+                // Compute the linear combination vk_x
+{{- range $i := seq 1 .NPublic }}
+                g1_mulAccC(_pVk, IC{{ $i }}x, IC{{ $i }}y, calldataload(add(pubSignals, {{ mul (sub1 $i) 32 }})))
+{{- end }}
+
+                // -A
+                mstore(_pPairing, calldataload(pA))
+                mstore(add(_pPairing, 32), mod(sub(q, calldataload(add(pA, 32))), q))
+
+                // B
+                mstore(add(_pPairing, 64), calldataload(pB))
+                mstore(add(_pPairing, 96), calldataload(add(pB, 32)))
+                mstore(add(_pPairing, 128), calldataload(add(pB, 64)))
+                mstore(add(_pPairing, 160), calldataload(add(pB, 96)))
+
+                // alpha1
+                mstore(add(_pPairing, 192), alphax)
+                mstore(add(_pPairing, 224), alphay)
+
+                // beta2
+                mstore(add(_pPairing, 256), betax1)
+                mstore(add(_pPairing, 288), betax2)
+                mstore(add(_pPairing, 320), betay1)
+                mstore(add(_pPairing, 352), betay2)
+
+                // vk_x
+                mstore(add(_pPairing, 384), mload(add(pMem, pVk)))
+                mstore(add(_pPairing, 416), mload(add(pMem, add(pVk, 32))))
+
+                // gamma2
+                mstore(add(_pPairing, 448), gammax1)
+                mstore(add(_pPairing, 480), gammax2)
+                mstore(add(_pPairing, 512), gammay1)
+                mstore(add(_pPairing, 544), gammay2)
+
+                // C
+                mstore(add(_pPairing, 576), calldataload(pC))
+                mstore(add(_pPairing, 608), calldataload(add(pC, 32)))
+
+                // delta2
+                mstore(add(_pPairing, 640), deltax1)
+                mstore(add(_pPairing, 672), deltax2)
+                mstore(add(_pPairing, 704), deltay1)
+                mstore(add(_pPairing, 736), deltay2)
+
+                let success := staticcall(sub(gas(), 2000), 8, _pPairing, 768, _pPairing, 0x20)
+
+                isOk := and(success, mload(_pPairing))
+            }
+
+            let pMem := mload(0x40)
+            mstore(0x40, add(pMem, pLastMem))
+
+            // Validate that all evaluations ∈ F
+{{- range $i := seq 1 .NPublic }}
+            checkField(calldataload(add(_pubSignals, {{ mul (sub1 $i) 32 }})))
+{{- end }}
+
+            // Validate all evaluations
+            let isValid := checkPairing(_pA, _pB, _pC, _pubSignals, pMem)
+
+            mstore(0, isValid)
+            return(0, 0x20)
+        }
+    }
+}
+`))
+
+// snarkjsWrapperTemplate adds the SnarkJS interface to the gnark verifier.
+// The gnark verifyProof reverts on invalid proofs, so it is called externally
+// to turn a revert into false.
+var snarkjsWrapperTemplate = template.Must(template.New("wrapper").Parse(`
+    /// Verify an uncompressed Groth16 proof with the interface of the SnarkJS
+    /// verifiers.
+    /// @notice Returns false instead of reverting if the proof is invalid.
+    /// @param _pA The point a, as (x, y).
+    /// @param _pB The point b, as ((x1, x0), (y1, y0)).
+    /// @param _pC The point c, as (x, y).
+    /// @param _pubSignals The public signals.
+    /// @return True if the proof is valid.
+    function verifyProof(
+        uint256[2] calldata _pA,
+        uint256[2][2] calldata _pB,
+        uint256[2] calldata _pC,
+        uint256[{{ . }}] calldata _pubSignals
+    ) public view returns (bool) {
+        uint256[8] memory proof = [
+            _pA[0], _pA[1],
+            _pB[0][0], _pB[0][1], _pB[1][0], _pB[1][1],
+            _pC[0], _pC[1]
+        ];
+        try this.verifyProof(proof, _pubSignals) {
+            return true;
+        } catch {
+            return false;
+        }
+    }
+`))
@@ -7,14 +7,22 @@ import (
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381"
 	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
 	"github.com/consensys/gnark/backend/witness"
 )
 
 // ConvertGnarkToCircom converts a Gnark proof (its proof, verifying key, and public inputs)
 // into Circom‑compatible objects. It returns a CircomProof, a CircomVerificationKey and the
-// public signals as a slice of strings.
+// public signals as a slice of strings. Proofs over bn254 and bls12-381 are supported.
 func ConvertGnarkToCircom(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness) (*CircomProof, *CircomVerificationKey, []string, error) {
+	if blsProof, ok := proof.(*groth16_bls12381.Proof); ok {
+		blsVk, ok := vk.(*groth16_bls12381.VerifyingKey)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("expected a bls12-381 verifying key, got %T", vk)
+		}
+		return convertGnarkToCircomBLS12381(blsProof, blsVk, publicWitness)
+	}
 	// Extract the underlying vector from the public witness.
 	vec, ok := publicWitness.Vector().(fr.Vector)
 	if !ok {
@@ -54,21 +62,47 @@ func ConvertGnarkToCircom(proof groth16.Proof, vk groth16.VerifyingKey, publicWi
 		return nil, nil, nil, fmt.Errorf("VerifyingKey is nil in gnarkProof")
 	}
 
+	// nPublic is expected to be the number of public inputs (IC should have length nPublic+1).
+	nPublic := len(gnarkProof.PublicInputs)
+	circomVk, err := verifyingKeyToCircom(vkey, nPublic)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// For non-recursive proofs, nPublic = len(publicInputs). But in recursive proofs we may have
+	// an extra element in IC. So if there are no public inputs and IC has more than one element,
+	// trim IC to length 1.
+	if nPublic == 0 && len(circomVk.IC) > 1 {
+		circomVk.IC = circomVk.IC[:1]
+	}
+
+	// Convert public inputs from bn254fr.Element to decimal strings.
+	publicSignals := make([]string, len(gnarkProof.PublicInputs))
+	for i, input := range gnarkProof.PublicInputs {
+		publicSignals[i] = elementToString(input)
+	}
+
+	return circomProof, circomVk, publicSignals, nil
+}
+
+// verifyingKeyToCircom converts a gnark bn254 verifying key into a
+// CircomVerificationKey with nPublic public inputs. All the points of
+// vk.G1.K are exported as IC.
+func verifyingKeyToCircom(vkey *groth16_bn254.VerifyingKey, nPublic int) (*CircomVerificationKey, error) {
 	vkAlpha1, err := g1ToCircomString(&vkey.G1.Alpha)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to convert vk.G1.Alpha: %w", err)
+		return nil, fmt.Errorf("failed to convert vk.G1.Alpha: %w", err)
 	}
 	vkBeta2, err := g2ToCircomString(&vkey.G2.Beta)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to convert vk.G2.Beta: %w", err)
+		return nil, fmt.Errorf("failed to convert vk.G2.Beta: %w", err)
 	}
 	vkGamma2, err := g2ToCircomString(&vkey.G2.Gamma)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to convert vk.G2.Gamma: %w", err)
+		return nil, fmt.Errorf("failed to convert vk.G2.Gamma: %w", err)
 	}
 	vkDelta2, err := g2ToCircomString(&vkey.G2.Delta)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to convert vk.G2.Delta: %w", err)
+		return nil, fmt.Errorf("failed to convert vk.G2.Delta: %w", err)
 	}
 
 	// Convert the IC array (G1 points for public inputs).
@@ -76,27 +110,18 @@ func ConvertGnarkToCircom(proof groth16.Proof, vk groth16.VerifyingKey, publicWi
 	for i, pt := range vkey.G1.K {
 		ptStr, err := g1ToCircomString(&pt)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("failed to convert IC[%d]: %w", i, err)
+			return nil, fmt.Errorf("failed to convert IC[%d]: %w", i, err)
 		}
 		ic[i] = ptStr
 	}
 
-	// nPublic is expected to be the number of public inputs (IC should have length nPublic+1).
-	nPublic := len(gnarkProof.PublicInputs)
-	// For non-recursive proofs, nPublic = len(publicInputs). But in recursive proofs we may have
-	// an extra element in IC. So if there are no public inputs and IC has more than one element,
-	// trim IC to length 1.
-	if nPublic == 0 && len(ic) > 1 {
-		ic = ic[:1]
-	}
-
 	// Compute vk_alphabeta_12 = e(vk_alpha_1, vk_beta_2) in the Circom format.
 	alphabeta, err := ComputeAlphabeta12(vkey.G1.Alpha, vkey.G2.Beta)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to compute vk_alphabeta_12: %w", err)
+		return nil, fmt.Errorf("failed to compute vk_alphabeta_12: %w", err)
 	}
 
-	circomVk := &CircomVerificationKey{
+	return &CircomVerificationKey{
 		Protocol:      "groth16",
 		Curve:         "bn128", // Circom uses "bn128" for bn254.
 		NPublic:       nPublic,
@@ -106,23 +131,19 @@ func ConvertGnarkToCircom(proof groth16.Proof, vk groth16.VerifyingKey, publicWi
 		VkDelta2:      vkDelta2,
 		IC:            ic,
 		VkAlphabeta12: alphabeta,
-	}
-
-	// Convert public inputs from bn254fr.Element to decimal strings.
-	publicSignals := make([]string, len(gnarkProof.PublicInputs))
-	for i, input := range gnarkProof.PublicInputs {
-		publicSignals[i] = elementToString(input)
-	}
-
-	return circomProof, circomVk, publicSignals, nil
+	}, nil
 }
 
 // g1ToCircomString converts a bn254 G1Affine point to a Circom‑compatible slice of strings.
-// The returned slice is [ X, Y, "1" ] (all in decimal string format).
+// The returned slice is [ X, Y, "1" ] (all in decimal string format), or
+// [ "0", "1", "0" ] for the point at infinity, as in SnarkJS.
 func g1ToCircomString(p *curve.G1Affine) ([]string, error) {
 	if p == nil {
 		return nil, fmt.Errorf("nil G1 point")
 	}
+	if p.IsInfinity() {
+		return []string{"0", "1", "0"}, nil
+	}
 	xBig := p.X.BigInt(new(big.Int))
 	yBig := p.Y.BigInt(new(big.Int))
 	return []string{
@@ -137,11 +158,15 @@ func g1ToCircomString(p *curve.G1Affine) ([]string, error) {
 //
 //	[ [ X.A0, X.A1 ], [ Y.A0, Y.A1 ], [ "1", "0" ] ]
 //
-// with all numbers in decimal.
+// with all numbers in decimal. The point at infinity is
+// [ [ "0", "0" ], [ "1", "0" ], [ "0", "0" ] ], as in SnarkJS.
 func g2ToCircomString(p *curve.G2Affine) ([][]string, error) {
 	if p == nil {
 		return nil, fmt.Errorf("nil G2 point")
 	}
+	if p.IsInfinity() {
+		return [][]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}, nil
+	}
 	x0 := p.X.A0.BigInt(new(big.Int))
 	x1 := p.X.A1.BigInt(new(big.Int))
 	y0 := p.Y.A0.BigInt(new(big.Int))
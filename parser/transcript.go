@@ -0,0 +1,45 @@
+package parser
+
+import (
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"golang.org/x/crypto/sha3"
+)
+
+// keccakTranscript is the Fiat-Shamir transcript of the SnarkJS PLONK and
+// FFLONK provers (Keccak256Transcript.js). Challenges are the Keccak-256 hash
+// of the big-endian encoding of the points (x, y) and scalars added since the
+// last reset, reduced modulo the scalar field.
+type keccakTranscript struct {
+	data []byte
+}
+
+func (t *keccakTranscript) reset() {
+	t.data = t.data[:0]
+}
+
+func (t *keccakTranscript) addPoint(p *curve.G1Affine) {
+	if p.IsInfinity() {
+		// ffjavascript flags the point at infinity in the first byte
+		var b [2 * fp.Bytes]byte
+		b[0] = 0x40
+		t.data = append(t.data, b[:]...)
+		return
+	}
+	b := p.RawBytes()
+	t.data = append(t.data, b[:]...)
+}
+
+func (t *keccakTranscript) addScalar(s *bn254fr.Element) {
+	b := s.Bytes()
+	t.data = append(t.data, b[:]...)
+}
+
+func (t *keccakTranscript) challenge() bn254fr.Element {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(t.data)
+	var c bn254fr.Element
+	c.SetBytes(h.Sum(nil))
+	return c
+}
@@ -1,7 +1,9 @@
 package parser
 
 import (
+	bls12381fr "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	groth16_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381"
 	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
 	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
 	recursion "github.com/consensys/gnark/std/recursion/groth16"
@@ -13,6 +15,7 @@ type CircomProof struct {
 	PiB      [][]string `json:"pi_b"`
 	PiC      []string   `json:"pi_c"`
 	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve,omitempty"`
 }
 
 // CircomVerificationKey represents the verification key structure output by SnarkJS.
@@ -28,6 +31,176 @@ type CircomVerificationKey struct {
 	VkAlphabeta12 [][][]string `json:"vk_alphabeta_12"` // Not used in verification
 }
 
+// CircomPlonkProof represents the PLONK proof structure output by SnarkJS.
+type CircomPlonkProof struct {
+	A        []string `json:"A"`
+	B        []string `json:"B"`
+	C        []string `json:"C"`
+	Z        []string `json:"Z"`
+	T1       []string `json:"T1"`
+	T2       []string `json:"T2"`
+	T3       []string `json:"T3"`
+	Wxi      []string `json:"Wxi"`
+	Wxiw     []string `json:"Wxiw"`
+	EvalA    string   `json:"eval_a"`
+	EvalB    string   `json:"eval_b"`
+	EvalC    string   `json:"eval_c"`
+	EvalS1   string   `json:"eval_s1"`
+	EvalS2   string   `json:"eval_s2"`
+	EvalZw   string   `json:"eval_zw"`
+	Protocol string   `json:"protocol"`
+	Curve    string   `json:"curve"`
+}
+
+// CircomPlonkVerificationKey represents the PLONK verification key structure
+// output by SnarkJS.
+type CircomPlonkVerificationKey struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	Power    int        `json:"power"` // log2 of the domain size
+	K1       string     `json:"k1"`
+	K2       string     `json:"k2"`
+	Qm       []string   `json:"Qm"`
+	Ql       []string   `json:"Ql"`
+	Qr       []string   `json:"Qr"`
+	Qo       []string   `json:"Qo"`
+	Qc       []string   `json:"Qc"`
+	S1       []string   `json:"S1"`
+	S2       []string   `json:"S2"`
+	S3       []string   `json:"S3"`
+	X2       [][]string `json:"X_2"`
+	W        string     `json:"w"` // root of unity of the domain
+}
+
+// CircomFflonkProof represents the FFLONK proof structure output by SnarkJS.
+type CircomFflonkProof struct {
+	Polynomials CircomFflonkPolynomials `json:"polynomials"`
+	Evaluations CircomFflonkEvaluations `json:"evaluations"`
+	Protocol    string                  `json:"protocol"`
+	Curve       string                  `json:"curve"`
+}
+
+// CircomFflonkPolynomials holds the commitments of a SnarkJS FFLONK proof.
+type CircomFflonkPolynomials struct {
+	C1 []string `json:"C1"`
+	C2 []string `json:"C2"`
+	W1 []string `json:"W1"`
+	W2 []string `json:"W2"`
+}
+
+// CircomFflonkEvaluations holds the evaluations of a SnarkJS FFLONK proof, at
+// ξ, or at ξω for Zw, T1w and T2w.
+type CircomFflonkEvaluations struct {
+	Ql  string `json:"ql"`
+	Qr  string `json:"qr"`
+	Qm  string `json:"qm"`
+	Qo  string `json:"qo"`
+	Qc  string `json:"qc"`
+	S1  string `json:"s1"`
+	S2  string `json:"s2"`
+	S3  string `json:"s3"`
+	A   string `json:"a"`
+	B   string `json:"b"`
+	C   string `json:"c"`
+	Z   string `json:"z"`
+	Zw  string `json:"zw"`
+	T1w string `json:"t1w"`
+	T2w string `json:"t2w"`
+	Inv string `json:"inv"` // batched inverse for the Solidity verifier
+}
+
+// CircomFflonkVerificationKey represents the FFLONK verification key
+// structure output by SnarkJS.
+type CircomFflonkVerificationKey struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	Power    int        `json:"power"` // log2 of the domain size
+	K1       string     `json:"k1"`
+	K2       string     `json:"k2"`
+	W        string     `json:"w"`  // root of unity of the domain
+	W3       string     `json:"w3"` // cube root of unity
+	W4       string     `json:"w4"` // 4th root of unity
+	W8       string     `json:"w8"` // 8th root of unity
+	Wr       string     `json:"wr"` // cube root of w
+	X2       [][]string `json:"X_2"`
+	C0       []string   `json:"C0"`
+}
+
+// CircomZKey represents a SnarkJS Groth16 proving key (.zkey file) together
+// with its gnark counterparts.
+type CircomZKey struct {
+	NVars      int // number of wires, including the constant one
+	NPublic    int // number of public signals (outputs and public inputs)
+	DomainSize int // size of the evaluation domain, a power of two
+
+	// Coefficients holds the A and B matrices of the circuit, used to compute
+	// the proof from a witness (see ProveCircom).
+	Coefficients []CircomZKeyCoefficient
+
+	ProvingKey            *groth16_bn254.ProvingKey
+	VerifyingKey          *groth16_bn254.VerifyingKey
+	CircomVerificationKey *CircomVerificationKey
+}
+
+// CircomZKeyCoefficient is a non-zero entry of the A or B matrix of a
+// circuit, as stored in a .zkey file. Besides the constraints of the circuit,
+// SnarkJS adds a constraint 1·signal in A for the constant one and each public
+// signal.
+type CircomZKeyCoefficient struct {
+	Matrix     int // 0 for A, 1 for B
+	Constraint int
+	Signal     int
+	Value      bn254fr.Element
+}
+
+// CircomR1CS represents a Circom constraint system (.r1cs file). Wires are
+// ordered as in Circom: the constant one, the outputs, the public inputs, the
+// private inputs and then the internal signals.
+type CircomR1CS struct {
+	NWires         int
+	NOutputs       int
+	NPublicInputs  int
+	NPrivateInputs int
+	NLabels        uint64
+	Constraints    []CircomConstraint
+	WireToLabel    []uint64              // label of each wire, as in the .sym file
+	CustomGates    []CircomCustomGate    // custom templates (PLONK only)
+	CustomGateUses []CircomCustomGateUse // applications of the custom templates
+}
+
+// CircomConstraint represents a constraint A·B - C = 0, where A, B and C are
+// linear combinations of wires.
+type CircomConstraint struct {
+	A, B, C []CircomTerm
+}
+
+// CircomTerm is a wire multiplied by a coefficient.
+type CircomTerm struct {
+	Wire  int
+	Coeff bn254fr.Element
+}
+
+// CircomCustomGate is a custom template declared with `pragma custom_templates`.
+type CircomCustomGate struct {
+	Name       string
+	Parameters []bn254fr.Element
+}
+
+// CircomCustomGateUse is an application of a custom template to a set of signals.
+type CircomCustomGateUse struct {
+	GateID  int
+	Signals []uint64
+}
+
+// CircomWitness represents a Circom witness (.wtns file): the values of all
+// the wires of the circuit, in the Circom order, starting with the constant
+// one.
+type CircomWitness struct {
+	Values []bn254fr.Element
+}
+
 // GnarkRecursionPlaceholders is a set of placeholders that can be used to define recursive circuits.
 type GnarkRecursionPlaceholders struct {
 	Vk      recursion.VerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
@@ -48,3 +221,10 @@ type GnarkProof struct {
 	VerifyingKey *groth16_bn254.VerifyingKey
 	PublicInputs []bn254fr.Element
 }
+
+// GnarkProofBLS12381 is a BLS12-381 proof that can be used with non-recursive circuits.
+type GnarkProofBLS12381 struct {
+	Proof        *groth16_bls12381.Proof
+	VerifyingKey *groth16_bls12381.VerifyingKey
+	PublicInputs []bls12381fr.Element
+}
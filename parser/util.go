@@ -1,12 +1,12 @@
 package parser
 
 import (
-	"encoding/hex"
 	"fmt"
 	"math/big"
 	"strings"
 
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
 )
 
 // stringToBigInt converts a string to a big.Int, handling both decimal and hexadecimal representations.
@@ -25,137 +25,123 @@ func stringToBigInt(s string) (*big.Int, error) {
 	return bi, nil
 }
 
-func addZPadding(b []byte) []byte {
-	var z [32]byte
-	var r []byte
-	r = append(r, z[len(b):]...) // add padding on the left
-	r = append(r, b...)
-	return r[:32]
+// isDigits reports whether s is a non-empty string of the given digits.
+func isDigits(s, digits string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(digits, c) {
+			return false
+		}
+	}
+	return true
 }
 
-func stringToBytes(s string) ([]byte, error) {
-	if s == "1" {
-		s = "0"
+// stringToScalar parses a public signal strictly: it must be a canonical
+// decimal number, as output by SnarkJS, without sign or leading zeros, and
+// lower than the scalar field modulus. This rules out different encodings of
+// the same field element, which would otherwise verify identically.
+func stringToScalar(s string, modulus *big.Int) (*big.Int, error) {
+	if s == "" || len(s) > maxCoordinateLength {
+		return nil, fmt.Errorf("invalid length %d", len(s))
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return nil, fmt.Errorf("%q is not a canonical decimal number", s)
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("%q is not a canonical decimal number", s)
+		}
 	}
 	bi, ok := new(big.Int).SetString(s, 10)
 	if !ok {
-		return nil, fmt.Errorf("error parsing bigint stringToBytes")
+		return nil, fmt.Errorf("failed to parse decimal string %s", s)
 	}
-	b := bi.Bytes()
-	if len(b) != 32 { //nolint:gomnd
-		b = addZPadding(b)
+	if bi.Cmp(modulus) >= 0 {
+		return nil, fmt.Errorf("%s is not lower than the scalar field modulus", s)
 	}
-	return b, nil
+	return bi, nil
 }
 
-//nolint:gomnd
-func stringToG1(h []string) (*curve.G1Affine, error) {
-	if len(h) <= 2 {
-		return nil, fmt.Errorf("not enough data for stringToG1")
-	}
-	h = h[:2]
-	hexa := false
-	if len(h[0]) > 1 {
-		if "0x" == h[0][:2] {
-			hexa = true
-		}
+// maxCoordinateLength bounds the length of the coordinate strings, so that
+// huge numbers are rejected before being parsed.
+const maxCoordinateLength = 256
+
+// stringToFp parses a decimal or hex string into a base field element,
+// rejecting values that are not reduced.
+func stringToFp(s string) (fp.Element, error) {
+	var e fp.Element
+	if len(s) > maxCoordinateLength {
+		return e, fmt.Errorf("coordinate of %d characters is too long", len(s))
+	}
+	bi, err := stringToBigInt(s)
+	if err != nil {
+		return e, err
+	}
+	if bi.Sign() < 0 || bi.Cmp(fp.Modulus()) >= 0 {
+		return e, fmt.Errorf("coordinate %s is not a valid field element", s)
 	}
-	in := ""
+	e.SetBigInt(bi)
+	return e, nil
+}
 
-	var b []byte
-	var err error
-	if hexa {
-		for i := range h {
-			in += strings.TrimPrefix(h[i], "0x")
-		}
-		b, err = hex.DecodeString(in)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		// TODO TMP
-		// TODO use stringToBytes()
-		if h[0] == "1" {
-			h[0] = "0"
-		}
-		if h[1] == "1" {
-			h[1] = "0"
-		}
-		bi0, ok := new(big.Int).SetString(h[0], 10)
-		if !ok {
-			return nil, fmt.Errorf("error parsing stringToG1")
-		}
-		bi1, ok := new(big.Int).SetString(h[1], 10)
-		if !ok {
-			return nil, fmt.Errorf("error parsing stringToG1")
-		}
-		b0 := bi0.Bytes()
-		b1 := bi1.Bytes()
-		if len(b0) != 32 {
-			b0 = addZPadding(b0)
-		}
-		if len(b1) != 32 {
-			b1 = addZPadding(b1)
+// stringToG1 parses a SnarkJS G1 point [x, y, z]. As in ffjavascript, the
+// coordinates are Jacobian, (x/z², y/z³): affine points have z = 1 and the
+// point at infinity has z = 0. Coordinates are decimal or 0x-prefixed hex
+// strings and must be reduced, and the point must be on the curve. Errors
+// are ErrInvalidPoint, with the path of the coordinate relative to the point.
+func stringToG1(h []string) (*curve.G1Affine, error) {
+	if len(h) != 3 { //nolint:gomnd
+		return nil, newError(ErrInvalidPoint, "", "expected 3 coordinates, got %d", len(h))
+	}
+	var p curve.G1Jac
+	for i, c := range []*fp.Element{&p.X, &p.Y, &p.Z} {
+		var err error
+		if *c, err = stringToFp(h[i]); err != nil {
+			return nil, &Error{Kind: ErrInvalidPoint, Path: fmt.Sprintf("[%d]", i), Err: err}
 		}
-
-		b = append(b, b0...)
-		b = append(b, b1...)
 	}
-	p := new(curve.G1Affine)
-	err = p.Unmarshal(b)
-
-	return p, err
+	g1 := new(curve.G1Affine)
+	if p.Z.IsZero() {
+		return g1, nil // point at infinity
+	}
+	g1.FromJacobian(&p)
+	if !g1.IsOnCurve() || !g1.IsInSubGroup() {
+		return nil, newError(ErrInvalidPoint, "", "not on the curve")
+	}
+	return g1, nil
 }
 
+// stringToG2 parses a SnarkJS G2 point [[x.A0, x.A1], [y.A0, y.A1], z] in
+// Jacobian coordinates, as stringToG1 does. The point must be on the curve
+// and in the prime order subgroup.
 func stringToG2(h [][]string) (*curve.G2Affine, error) {
-	if len(h) <= 2 { //nolint:gomnd
-		return nil, fmt.Errorf("not enough data for stringToG2")
-	}
-	h = h[:2]
-	hexa := false
-	if len(h[0][0]) > 1 {
-		if "0x" == h[0][0][:2] {
-			hexa = true
-		}
+	if len(h) != 3 { //nolint:gomnd
+		return nil, newError(ErrInvalidPoint, "", "expected 3 coordinates, got %d", len(h))
 	}
-	in := ""
-	var b []byte
-	var err error
-	if hexa {
-		for i := 0; i < len(h); i++ {
-			for j := 0; j < len(h[i]); j++ {
-				in += strings.TrimPrefix(h[i][j], "0x")
+	var p curve.G2Jac
+	for i, c := range []*curve.E2{&p.X, &p.Y, &p.Z} {
+		if len(h[i]) != 2 { //nolint:gomnd
+			return nil, newError(ErrInvalidPoint, fmt.Sprintf("[%d]", i), "expected 2 elements, got %d", len(h[i]))
+		}
+		for j, a := range []*fp.Element{&c.A0, &c.A1} {
+			var err error
+			if *a, err = stringToFp(h[i][j]); err != nil {
+				return nil, &Error{Kind: ErrInvalidPoint, Path: fmt.Sprintf("[%d][%d]", i, j), Err: err}
 			}
 		}
-		b, err = hex.DecodeString(in)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		// TODO TMP
-		bH, err := stringToBytes(h[0][1])
-		if err != nil {
-			return nil, err
-		}
-		b = append(b, bH...)
-		bH, err = stringToBytes(h[0][0])
-		if err != nil {
-			return nil, err
-		}
-		b = append(b, bH...)
-		bH, err = stringToBytes(h[1][1])
-		if err != nil {
-			return nil, err
-		}
-		b = append(b, bH...)
-		bH, err = stringToBytes(h[1][0])
-		if err != nil {
-			return nil, err
-		}
-		b = append(b, bH...)
 	}
-
-	p := new(curve.G2Affine)
-	err = p.Unmarshal(b)
-	return p, err
+	g2 := new(curve.G2Affine)
+	if p.Z.IsZero() {
+		return g2, nil // point at infinity
+	}
+	g2.FromJacobian(&p)
+	if !g2.IsOnCurve() {
+		return nil, newError(ErrInvalidPoint, "", "not on the curve")
+	}
+	if !g2.IsInSubGroup() {
+		return nil, newError(ErrInvalidPoint, "", "not in the prime order subgroup")
+	}
+	return g2, nil
 }
@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// Validate checks the consistency of the verification key: the protocol
+// must be groth16 (an empty protocol stands for groth16, as for keys built
+// in Go), the curve must be supported, the points must have the SnarkJS
+// shape, and IC must hold one point per public signal plus one. The
+// remaining points are checked on conversion. vk_alphabeta_12 is not used
+// for verification, and checking its value takes a pairing, so it is only
+// checked by ValidateAlphabeta12.
+func (vk *CircomVerificationKey) Validate() error {
+	if vk.Protocol != "" && vk.Protocol != "groth16" {
+		return newError(ErrWrongProtocol, "vk.protocol", "unexpected protocol %q, expected groth16", vk.Protocol)
+	}
+	if _, err := circomCurveID(vk.Curve); err != nil {
+		return atPath("vk.curve", err)
+	}
+	if vk.NPublic < 0 {
+		return newError(ErrNPublicMismatch, "vk.nPublic", "invalid number of public signals %d", vk.NPublic)
+	}
+	if len(vk.IC) != vk.NPublic+1 {
+		return newError(ErrNPublicMismatch, "vk.nPublic", "%d public signals but %d IC points", vk.NPublic, len(vk.IC))
+	}
+	if err := checkG1Shape("vk.vk_alpha_1", vk.VkAlpha1); err != nil {
+		return err
+	}
+	for _, p := range []struct {
+		path  string
+		point [][]string
+	}{
+		{"vk.vk_beta_2", vk.VkBeta2},
+		{"vk.vk_gamma_2", vk.VkGamma2},
+		{"vk.vk_delta_2", vk.VkDelta2},
+	} {
+		if err := checkG2Shape(p.path, p.point); err != nil {
+			return err
+		}
+	}
+	for i, p := range vk.IC {
+		if err := checkG1Shape(fmt.Sprintf("vk.IC[%d]", i), p); err != nil {
+			return err
+		}
+	}
+	if len(vk.VkAlphabeta12) == 0 {
+		return nil
+	}
+	return vk.checkAlphabeta12Shape()
+}
+
+// ValidateAlphabeta12 checks that vk_alphabeta_12, if present, equals
+// e(vk_alpha_1, vk_beta_2), as computed by ComputeAlphabeta12. It computes a
+// pairing, so it is meant to be called once per key, as
+// VerifierRegistry.Register does, rather than on every conversion.
+func (vk *CircomVerificationKey) ValidateAlphabeta12() error {
+	if err := vk.Validate(); err != nil {
+		return err
+	}
+	if len(vk.VkAlphabeta12) == 0 {
+		return nil
+	}
+	curveID, _ := circomCurveID(vk.Curve)
+	var want [][][]string
+	switch curveID {
+	case ecc.BLS12_381:
+		alpha, err := stringToG1BLS12381(vk.VkAlpha1)
+		if err != nil {
+			return atPath("vk.vk_alpha_1", err)
+		}
+		beta, err := stringToG2BLS12381(vk.VkBeta2)
+		if err != nil {
+			return atPath("vk.vk_beta_2", err)
+		}
+		if want, err = computeAlphabeta12BLS12381(*alpha, *beta); err != nil {
+			return newError(ErrInternal, "vk.vk_alphabeta_12", "%v", err)
+		}
+	default:
+		alpha, err := stringToG1(vk.VkAlpha1)
+		if err != nil {
+			return atPath("vk.vk_alpha_1", err)
+		}
+		beta, err := stringToG2(vk.VkBeta2)
+		if err != nil {
+			return atPath("vk.vk_beta_2", err)
+		}
+		if want, err = ComputeAlphabeta12(*alpha, *beta); err != nil {
+			return newError(ErrInternal, "vk.vk_alphabeta_12", "%v", err)
+		}
+	}
+	for i := range want {
+		for j := range want[i] {
+			for k := range want[i][j] {
+				s := vk.VkAlphabeta12[i][j][k]
+				if len(s) > maxCoordinateLength {
+					return newError(ErrInvalidPoint, "vk.vk_alphabeta_12", "coordinate of %d characters is too long", len(s))
+				}
+				if got, err := stringToBigInt(s); err != nil || got.String() != want[i][j][k] {
+					return newError(ErrInvalidPoint, "vk.vk_alphabeta_12", "does not match e(vk_alpha_1, vk_beta_2)")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkAlphabeta12Shape checks that vk_alphabeta_12 has the SnarkJS shape of
+// an Fp12 element: 2 coordinates of 3 elements of 2 elements.
+func (vk *CircomVerificationKey) checkAlphabeta12Shape() error {
+	if len(vk.VkAlphabeta12) != 2 { //nolint:gomnd
+		return newError(ErrInvalidPoint, "vk.vk_alphabeta_12", "expected 2 coordinates, got %d", len(vk.VkAlphabeta12))
+	}
+	for i := range vk.VkAlphabeta12 {
+		if len(vk.VkAlphabeta12[i]) != 3 { //nolint:gomnd
+			return newError(ErrInvalidPoint, fmt.Sprintf("vk.vk_alphabeta_12[%d]", i), "expected 3 elements, got %d", len(vk.VkAlphabeta12[i]))
+		}
+		for j := range vk.VkAlphabeta12[i] {
+			if len(vk.VkAlphabeta12[i][j]) != 2 { //nolint:gomnd
+				return newError(ErrInvalidPoint, fmt.Sprintf("vk.vk_alphabeta_12[%d][%d]", i, j), "expected 2 elements, got %d", len(vk.VkAlphabeta12[i][j]))
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the consistency of the proof: the protocol, if set, must
+// be groth16, the curve, if set, must be supported, and the points must have
+// the SnarkJS shape. The points themselves are checked on conversion.
+func (p *CircomProof) Validate() error {
+	if p.Protocol != "" && p.Protocol != "groth16" {
+		return newError(ErrWrongProtocol, "proof.protocol", "unexpected protocol %q, expected groth16", p.Protocol)
+	}
+	if p.Curve != "" {
+		if _, err := circomCurveID(p.Curve); err != nil {
+			return atPath("proof.curve", err)
+		}
+	}
+	if err := checkG1Shape("proof.pi_a", p.PiA); err != nil {
+		return err
+	}
+	if err := checkG2Shape("proof.pi_b", p.PiB); err != nil {
+		return err
+	}
+	return checkG1Shape("proof.pi_c", p.PiC)
+}
+
+// ValidateTriple checks a verification key, a proof and public signals
+// before conversion: besides the Validate checks of the key and the proof,
+// their curves must match, and there must be nPublic public signals, each a
+// canonical scalar field element.
+func ValidateTriple(vk *CircomVerificationKey, proof *CircomProof, publicSignals []string) error {
+	if err := validateTripleShape(vk, proof, publicSignals); err != nil {
+		return err
+	}
+	var err error
+	if curveID, _ := circomCurveID(vk.Curve); curveID == ecc.BLS12_381 {
+		_, err = ConvertPublicInputsStrictBLS12381(publicSignals)
+	} else {
+		_, err = ConvertPublicInputsStrict(publicSignals)
+	}
+	return err
+}
+
+// validateTripleShape is ValidateTriple without the parsing of the public
+// signals, for the conversions that parse them anyway.
+func validateTripleShape(vk *CircomVerificationKey, proof *CircomProof, publicSignals []string) error {
+	if vk == nil || proof == nil {
+		return newError(ErrInternal, "", "missing proof or verification key")
+	}
+	if err := vk.Validate(); err != nil {
+		return err
+	}
+	if err := proof.Validate(); err != nil {
+		return err
+	}
+	curveID, _ := circomCurveID(vk.Curve)
+	if proof.Curve != "" {
+		if proofCurveID, _ := circomCurveID(proof.Curve); proofCurveID != curveID {
+			return newError(ErrWrongCurve, "proof.curve", "proof curve %q does not match verification key curve %q", proof.Curve, vk.Curve)
+		}
+	}
+	if len(publicSignals) != vk.NPublic {
+		return newError(ErrNPublicMismatch, "publicSignals", "expected %d public signals, got %d", vk.NPublic, len(publicSignals))
+	}
+	return nil
+}
+
+// checkG1Shape checks that a SnarkJS G1 point has 3 coordinates.
+func checkG1Shape(path string, p []string) error {
+	if len(p) != 3 { //nolint:gomnd
+		return newError(ErrInvalidPoint, path, "expected 3 coordinates, got %d", len(p))
+	}
+	return nil
+}
+
+// checkG2Shape checks that a SnarkJS G2 point has 3 coordinates of 2
+// elements.
+func checkG2Shape(path string, p [][]string) error {
+	if len(p) != 3 { //nolint:gomnd
+		return newError(ErrInvalidPoint, path, "expected 3 coordinates, got %d", len(p))
+	}
+	for i, c := range p {
+		if len(c) != 2 { //nolint:gomnd
+			return newError(ErrInvalidPoint, fmt.Sprintf("%s[%d]", path, i), "expected 2 elements, got %d", len(c))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/vocdoni/go-snark/parsers"
+	gosnark "github.com/vocdoni/go-snark/verifier"
+)
+
+// Verifier verifies Circom Groth16 proofs. Verify returns true for a valid
+// proof, and false with an error otherwise: ErrVerificationFailed for a
+// well-formed proof that does not verify, or the error of the malformed input.
+type Verifier interface {
+	// Name returns the name of the verifier, used in reports.
+	Name() string
+	// Verify verifies the proof and public signals against the verification
+	// key.
+	Verify(vk *CircomVerificationKey, proof *CircomProof, publicSignals []string) (bool, error)
+}
+
+// GnarkVerifier is a Verifier using gnark, for bn128 and bls12381 proofs.
+type GnarkVerifier struct{}
+
+// Name implements Verifier.
+func (GnarkVerifier) Name() string { return "gnark" }
+
+// Verify implements Verifier with ConvertCircomToGnarkGroth16 and VerifyProof
+// or VerifyProofBLS12381.
+func (GnarkVerifier) Verify(vk *CircomVerificationKey, proof *CircomProof, publicSignals []string) (bool, error) {
+	if vk == nil {
+		return false, newError(ErrInternal, "vk", "nil verification key")
+	}
+	curveID, err := circomCurveID(vk.Curve)
+	if err != nil {
+		return false, atPath("vk.curve", err)
+	}
+	if curveID == ecc.BLS12_381 {
+		gnarkProof, err := ConvertCircomToGnarkBLS12381(vk, proof, publicSignals)
+		if err != nil {
+			return false, err
+		}
+		return VerifyProofBLS12381(gnarkProof)
+	}
+	gnarkProof, err := ConvertCircomToGnark(vk, proof, publicSignals)
+	if err != nil {
+		return false, err
+	}
+	return VerifyProof(gnarkProof)
+}
+
+// GoSnarkVerifier is a Verifier using go-snark, an independent implementation
+// of the Groth16 verifier, for bn128 proofs only. The inputs are parsed and
+// checked by go-snark itself, so that the differential mode also compares the
+// decoding of both libraries; only the curve of the key is checked
+// beforehand. Panics of go-snark on malformed inputs are reported as
+// ErrInternal.
+type GoSnarkVerifier struct{}
+
+// Name implements Verifier.
+func (GoSnarkVerifier) Name() string { return "go-snark" }
+
+// Verify implements Verifier with the go-snark parsers and verifier.
+func (GoSnarkVerifier) Verify(vk *CircomVerificationKey, proof *CircomProof, publicSignals []string) (ok bool, err error) {
+	if vk == nil || proof == nil {
+		return false, newError(ErrInternal, "", "missing proof or verification key")
+	}
+	if err := checkCurve("vk", vk.Curve, ecc.BN254); err != nil {
+		return false, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			ok, err = false, newError(ErrInternal, "", "go-snark panicked: %v", r)
+		}
+	}()
+	// go-snark only parses JSON, and its parsers modify the slices they are
+	// given, so the inputs are passed as plain JSON encodings.
+	vkJSON, err := json.Marshal(parsers.VkString{
+		Alpha: vk.VkAlpha1,
+		Beta:  vk.VkBeta2,
+		Gamma: vk.VkGamma2,
+		Delta: vk.VkDelta2,
+		IC:    vk.IC,
+	})
+	if err != nil {
+		return false, newError(ErrInternal, "vk", "failed to encode verification key: %v", err)
+	}
+	proofJSON, err := json.Marshal(parsers.ProofString{A: proof.PiA, B: proof.PiB, C: proof.PiC, Protocol: proof.Protocol})
+	if err != nil {
+		return false, newError(ErrInternal, "proof", "failed to encode proof: %v", err)
+	}
+	publicJSON, err := json.Marshal(publicSignals)
+	if err != nil {
+		return false, newError(ErrInternal, "publicSignals", "failed to encode public signals: %v", err)
+	}
+	goSnarkVk, err := parsers.ParseVk(vkJSON)
+	if err != nil {
+		return false, newError(ErrInvalidPoint, "vk", "go-snark: %v", err)
+	}
+	goSnarkProof, err := parsers.ParseProof(proofJSON)
+	if err != nil {
+		return false, newError(ErrInvalidPoint, "proof", "go-snark: %v", err)
+	}
+	public, err := parsers.ParsePublicSignals(publicJSON)
+	if err != nil {
+		return false, newError(ErrInvalidScalar, "publicSignals", "go-snark: %v", err)
+	}
+	if !gosnark.Verify(goSnarkVk, goSnarkProof, public) {
+		return false, newError(ErrVerificationFailed, "", "go-snark rejected the proof")
+	}
+	return true, nil
+}
+
+// DifferentialVerifier is a Verifier that runs several verifiers on each proof
+// and checks that they agree. If they do, it returns the result of the first
+// one. Otherwise, it returns false and an ErrVerifierMismatch error listing
+// the result of each verifier.
+type DifferentialVerifier struct {
+	Verifiers []Verifier
+}
+
+// NewDifferentialVerifier returns a DifferentialVerifier running the given
+// verifiers, or GnarkVerifier and GoSnarkVerifier if none is given.
+func NewDifferentialVerifier(verifiers ...Verifier) *DifferentialVerifier {
+	if len(verifiers) == 0 {
+		verifiers = []Verifier{GnarkVerifier{}, GoSnarkVerifier{}}
+	}
+	return &DifferentialVerifier{Verifiers: verifiers}
+}
+
+// Name implements Verifier.
+func (d *DifferentialVerifier) Name() string {
+	names := make([]string, len(d.Verifiers))
+	for i, v := range d.Verifiers {
+		names[i] = v.Name()
+	}
+	return "differential(" + strings.Join(names, ", ") + ")"
+}
+
+// Verify implements Verifier.
+func (d *DifferentialVerifier) Verify(vk *CircomVerificationKey, proof *CircomProof, publicSignals []string) (bool, error) {
+	if len(d.Verifiers) == 0 {
+		return false, newError(ErrInternal, "", "no verifiers")
+	}
+	oks := make([]bool, len(d.Verifiers))
+	errs := make([]error, len(d.Verifiers))
+	agree := true
+	for i, v := range d.Verifiers {
+		oks[i], errs[i] = v.Verify(vk, proof, publicSignals)
+		agree = agree && oks[i] == oks[0]
+	}
+	if agree {
+		return oks[0], errs[0]
+	}
+	results := make([]string, len(d.Verifiers))
+	for i, v := range d.Verifiers {
+		results[i] = fmt.Sprintf("%s: %t", v.Name(), oks[i])
+		if errs[i] != nil {
+			results[i] += fmt.Sprintf(" (%v)", errs[i])
+		}
+	}
+	return false, newError(ErrVerifierMismatch, "", "%s", strings.Join(results, ", "))
+}
@@ -25,6 +25,13 @@ func VerifyCircomProof(proofPath, verificationKeyPath, publicPath string) (bool,
 		return false, err
 	}
 
+	return VerifyCircomProofJSON(proofJSON, vkJSON, publicJSON)
+}
+
+// VerifyCircomProofJSON verifies a Circom proof using go-snark, as
+// VerifyCircomProof does, from the contents of the proof, verification key,
+// and public signals JSON files.
+func VerifyCircomProofJSON(proofJSON, vkJSON, publicJSON []byte) (bool, error) {
 	public, err := parsers.ParsePublicSignals(publicJSON)
 	if err != nil {
 		return false, err
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+// Section types of a Circom .wtns file.
+const (
+	wtnsSectionHeader = 1
+	wtnsSectionValues = 2
+
+	wtnsVersion = 2
+)
+
+// UnmarshalCircomWitness parses a Circom .wtns binary file over bn254.
+func UnmarshalCircomWitness(data []byte) (*CircomWitness, error) {
+	f, err := readBinFile(data, "wtns", wtnsVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wtns: %w", err)
+	}
+
+	// Header
+	r, err := f.section(wtnsSectionHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wtns: %w", err)
+	}
+	n8 := int(r.u32())
+	prime := leBytesToBigInt(r.read(n8))
+	nWitness := int(r.u32())
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse wtns header: %w", r.err)
+	}
+	if n8 != bn254fr.Bytes || prime.Cmp(bn254fr.Modulus()) != 0 {
+		return nil, fmt.Errorf("unsupported wtns prime %s, only bn254 is supported", prime)
+	}
+
+	// Values
+	r, err = f.section(wtnsSectionValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wtns: %w", err)
+	}
+	if r.remaining() != nWitness*bn254fr.Bytes {
+		return nil, fmt.Errorf("invalid wtns: values section should contain %d elements", nWitness)
+	}
+	w := &CircomWitness{Values: make([]bn254fr.Element, nWitness)}
+	for i := range w.Values {
+		w.Values[i] = readFr(r)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse wtns values: %w", r.err)
+	}
+	return w, nil
+}
+
+// MarshalCircomWitness encodes the given CircomWitness as a .wtns file
+// (version 2), as written by the Circom witness calculators.
+func MarshalCircomWitness(w *CircomWitness) ([]byte, error) {
+	if w == nil {
+		return nil, fmt.Errorf("nil witness")
+	}
+	header := binary.LittleEndian.AppendUint32(nil, bn254fr.Bytes)
+	header = append(header, bigIntToLEBytes(bn254fr.Modulus(), bn254fr.Bytes)...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(w.Values)))
+
+	values := make([]byte, 0, len(w.Values)*bn254fr.Bytes)
+	for _, v := range w.Values {
+		var b [bn254fr.Bytes]byte
+		bn254fr.LittleEndian.PutElement(&b, v)
+		values = append(values, b[:]...)
+	}
+	return writeBinFile("wtns", wtnsVersion, []binSection{
+		{Type: wtnsSectionHeader, Data: header},
+		{Type: wtnsSectionValues, Data: values},
+	}), nil
+}
+
+// ToGnarkWitness converts the Circom witness into a full gnark witness (public
+// and secret parts) for a circuit with nPublic public signals. The constant
+// one is dropped, as gnark does not include it in the witness, so the result
+// matches the constraint system returned by CircomR1CS.ConstraintSystem.
+func (w *CircomWitness) ToGnarkWitness(nPublic int) (witness.Witness, error) {
+	if err := w.checkNPublic(nPublic); err != nil {
+		return nil, err
+	}
+	gw, err := witness.New(bn254fr.Modulus())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create witness: %v", err)
+	}
+	values := w.Values[1:]
+	ch := make(chan any, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	close(ch)
+	if err := gw.Fill(nPublic, len(values)-nPublic, ch); err != nil {
+		return nil, fmt.Errorf("failed to fill witness: %v", err)
+	}
+	return gw, nil
+}
+
+// PublicSignals returns the first nPublic signals of the witness (after the
+// constant one) as decimal strings, in the same format as
+// UnmarshalCircomPublicSignalsJSON.
+func (w *CircomWitness) PublicSignals(nPublic int) ([]string, error) {
+	if err := w.checkNPublic(nPublic); err != nil {
+		return nil, err
+	}
+	publicSignals := make([]string, nPublic)
+	for i := range publicSignals {
+		publicSignals[i] = elementToString(w.Values[i+1])
+	}
+	return publicSignals, nil
+}
+
+func (w *CircomWitness) checkNPublic(nPublic int) error {
+	if len(w.Values) == 0 || !w.Values[0].IsOne() {
+		return fmt.Errorf("invalid witness: the first value must be the constant one")
+	}
+	if nPublic < 0 || nPublic >= len(w.Values) {
+		return fmt.Errorf("invalid number of public signals %d for a witness of %d values", nPublic, len(w.Values))
+	}
+	return nil
+}
+
+// CircomWitnessFromGnark converts a full gnark witness over bn254 into a
+// CircomWitness, prepending the constant one.
+func CircomWitnessFromGnark(fullWitness witness.Witness) (*CircomWitness, error) {
+	vec, ok := fullWitness.Vector().(bn254fr.Vector)
+	if !ok {
+		return nil, fmt.Errorf("expected witness vector to be of type bn254fr.Vector, got %T", fullWitness.Vector())
+	}
+	values := make([]bn254fr.Element, len(vec)+1)
+	values[0].SetOne()
+	copy(values[1:], vec)
+	return &CircomWitness{Values: values}, nil
+}
@@ -0,0 +1,370 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// Section types of a SnarkJS .zkey file.
+const (
+	zkeySectionHeader        = 1
+	zkeySectionGroth16Header = 2
+	zkeySectionIC            = 3
+	zkeySectionCoefs         = 4
+	zkeySectionA             = 5
+	zkeySectionB1            = 6
+	zkeySectionB2            = 7
+	zkeySectionC             = 8
+	zkeySectionH             = 9
+
+	zkeyProtocolGroth16 = 1
+)
+
+// fpRInv holds R⁻¹ mod q, with R = 2²⁵⁶, used to decode the Montgomery form
+// in which SnarkJS stores the point coordinates. frR2Inv holds R⁻² mod r, as
+// the coefficients are stored multiplied by R².
+var (
+	fpRInv  fp.Element
+	frR2Inv bn254fr.Element
+)
+
+func init() {
+	r := new(big.Int).Lsh(big.NewInt(1), 256)
+	fpRInv.SetBigInt(new(big.Int).ModInverse(r, fp.Modulus()))
+	frR2Inv.SetBigInt(new(big.Int).ModInverse(r, bn254fr.Modulus())).Square(&frR2Inv)
+}
+
+// UnmarshalCircomZKey parses a SnarkJS Groth16 .zkey file over bn254 into the
+// equivalent gnark proving and verifying keys, together with the
+// CircomVerificationKey that `snarkjs zkey export verificationkey` outputs.
+func UnmarshalCircomZKey(data []byte) (*CircomZKey, error) {
+	f, err := readBinFile(data, "zkey", 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse zkey: %w", err)
+	}
+
+	// Header: the proving system
+	r, err := f.section(zkeySectionHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse zkey: %w", err)
+	}
+	protocol := r.u32()
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse zkey header: %w", r.err)
+	}
+	if protocol != zkeyProtocolGroth16 {
+		return nil, fmt.Errorf("unsupported zkey protocol %d, only groth16 is supported", protocol)
+	}
+
+	// Groth16 header: field moduli, sizes and the toxic waste commitments
+	r, err = f.section(zkeySectionGroth16Header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse zkey: %w", err)
+	}
+	q := leBytesToBigInt(r.read(int(r.u32())))
+	rr := leBytesToBigInt(r.read(int(r.u32())))
+	if r.err == nil && (q.Cmp(fp.Modulus()) != 0 || rr.Cmp(bn254fr.Modulus()) != 0) {
+		return nil, fmt.Errorf("unsupported zkey curve, only bn254 is supported")
+	}
+	zkey := &CircomZKey{
+		NVars:      int(r.u32()),
+		NPublic:    int(r.u32()),
+		DomainSize: int(r.u32()),
+	}
+	alpha1 := readG1Mont(r)
+	beta1 := readG1Mont(r)
+	beta2 := readG2Mont(r)
+	gamma2 := readG2Mont(r)
+	delta1 := readG1Mont(r)
+	delta2 := readG2Mont(r)
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse zkey groth16 header: %w", r.err)
+	}
+	// G1 has cofactor 1, but the twist does not: the G2 points of the
+	// verification key must be in the prime order subgroup.
+	if !beta2.IsInSubGroup() || !gamma2.IsInSubGroup() || !delta2.IsInSubGroup() {
+		return nil, fmt.Errorf("invalid zkey: G2 point of the groth16 header is not in the prime order subgroup")
+	}
+	if zkey.NPublic >= zkey.NVars {
+		return nil, fmt.Errorf("invalid zkey: nPublic (%d) must be lower than nVars (%d)", zkey.NPublic, zkey.NVars)
+	}
+	if zkey.DomainSize == 0 || zkey.DomainSize&(zkey.DomainSize-1) != 0 {
+		return nil, fmt.Errorf("invalid zkey: domain size %d is not a power of two", zkey.DomainSize)
+	}
+
+	// Point sections
+	ic, err := readG1Section(f, zkeySectionIC, zkey.NPublic+1)
+	if err != nil {
+		return nil, err
+	}
+	pointsA, err := readG1Section(f, zkeySectionA, zkey.NVars)
+	if err != nil {
+		return nil, err
+	}
+	pointsB1, err := readG1Section(f, zkeySectionB1, zkey.NVars)
+	if err != nil {
+		return nil, err
+	}
+	pointsB2, err := readG2Section(f, zkeySectionB2, zkey.NVars)
+	if err != nil {
+		return nil, err
+	}
+	pointsC, err := readG1Section(f, zkeySectionC, zkey.NVars-zkey.NPublic-1)
+	if err != nil {
+		return nil, err
+	}
+	pointsH, err := readG1Section(f, zkeySectionH, zkey.DomainSize)
+	if err != nil {
+		return nil, err
+	}
+	if zkey.Coefficients, err = readCoefficients(f, zkey.NVars, zkey.DomainSize); err != nil {
+		return nil, err
+	}
+
+	// Construct the ProvingKey
+	pk := &groth16_bn254.ProvingKey{}
+	pk.Domain = *fft.NewDomain(uint64(zkey.DomainSize))
+	pk.G1.Alpha = alpha1
+	pk.G1.Beta = beta1
+	pk.G1.Delta = delta1
+	pk.G1.K = pointsC
+	pk.G1.Z = hPointsToZ(pointsH)
+	pk.G2.Beta = beta2
+	pk.G2.Delta = delta2
+
+	// gnark filters out the points at infinity of A and B, marking them in
+	// InfinityA and InfinityB. The same mask is used for B in G1 and G2.
+	pk.InfinityA = make([]bool, zkey.NVars)
+	pk.InfinityB = make([]bool, zkey.NVars)
+	for i := 0; i < zkey.NVars; i++ {
+		if pointsA[i].IsInfinity() {
+			pk.InfinityA[i] = true
+			pk.NbInfinityA++
+		} else {
+			pk.G1.A = append(pk.G1.A, pointsA[i])
+		}
+		if pointsB1[i].IsInfinity() != pointsB2[i].IsInfinity() {
+			return nil, fmt.Errorf("invalid zkey: B1[%d] and B2[%d] do not match", i, i)
+		}
+		if pointsB1[i].IsInfinity() {
+			pk.InfinityB[i] = true
+			pk.NbInfinityB++
+		} else {
+			pk.G1.B = append(pk.G1.B, pointsB1[i])
+			pk.G2.B = append(pk.G2.B, pointsB2[i])
+		}
+	}
+
+	// Construct the VerifyingKey
+	vk := &groth16_bn254.VerifyingKey{}
+	vk.G1.Alpha = alpha1
+	vk.G1.Beta = beta1
+	vk.G1.Delta = delta1
+	vk.G1.K = ic
+	vk.G2.Beta = beta2
+	vk.G2.Gamma = gamma2
+	vk.G2.Delta = delta2
+	if err := vk.Precompute(); err != nil {
+		return nil, fmt.Errorf("failed to precompute verification key: %v", err)
+	}
+
+	circomVk, err := verifyingKeyToCircom(vk, zkey.NPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	zkey.ProvingKey = pk
+	zkey.VerifyingKey = vk
+	zkey.CircomVerificationKey = circomVk
+	return zkey, nil
+}
+
+// readCoefficients reads the non-zero entries of the A and B matrices.
+func readCoefficients(f *binFile, nVars, domainSize int) ([]CircomZKeyCoefficient, error) {
+	r, err := f.section(zkeySectionCoefs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse zkey: %w", err)
+	}
+	coefs := make([]CircomZKeyCoefficient, r.count(12+bn254fr.Bytes))
+	for i := range coefs {
+		c := &coefs[i]
+		c.Matrix = int(r.u32())
+		c.Constraint = int(r.u32())
+		c.Signal = int(r.u32())
+		c.Value = readFr(r)
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to parse zkey coefficient %d: %w", i, r.err)
+		}
+		if c.Matrix > 1 || c.Constraint >= domainSize || c.Signal >= nVars {
+			return nil, fmt.Errorf("invalid zkey: coefficient %d out of range", i)
+		}
+		c.Value.Mul(&c.Value, &frR2Inv)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse zkey coefficients: %w", r.err)
+	}
+	if r.remaining() != 0 {
+		return nil, fmt.Errorf("invalid zkey: unexpected data after the coefficients")
+	}
+	return coefs, nil
+}
+
+// readG1Section reads a section made of exactly n G1 points.
+func readG1Section(f *binFile, sType uint32, n int) ([]curve.G1Affine, error) {
+	r, err := f.section(sType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse zkey: %w", err)
+	}
+	if r.remaining() != n*2*fp.Bytes {
+		return nil, fmt.Errorf("invalid zkey: section %d should contain %d G1 points", sType, n)
+	}
+	points := make([]curve.G1Affine, n)
+	for i := range points {
+		points[i] = readG1Mont(r)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse zkey section %d: %w", sType, r.err)
+	}
+	return points, nil
+}
+
+// readG2Section reads a section made of exactly n G2 points.
+func readG2Section(f *binFile, sType uint32, n int) ([]curve.G2Affine, error) {
+	r, err := f.section(sType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse zkey: %w", err)
+	}
+	if r.remaining() != n*4*fp.Bytes {
+		return nil, fmt.Errorf("invalid zkey: section %d should contain %d G2 points", sType, n)
+	}
+	points := make([]curve.G2Affine, n)
+	for i := range points {
+		points[i] = readG2Mont(r)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse zkey section %d: %w", sType, r.err)
+	}
+	return points, nil
+}
+
+// readFpMont reads a base field element stored in little-endian Montgomery
+// form, which matches the in-memory representation of fp.Element.
+func readFpMont(r *binReader) fp.Element {
+	b := r.read(fp.Bytes)
+	if b == nil {
+		return fp.Element{}
+	}
+	e, err := fp.LittleEndian.Element((*[fp.Bytes]byte)(b))
+	if err != nil {
+		r.err = err
+		return fp.Element{}
+	}
+	return *e.Mul(&e, &fpRInv)
+}
+
+// readG1Mont reads an affine G1 point as (x, y). The point at infinity is
+// encoded as (0, 0), as in gnark.
+func readG1Mont(r *binReader) curve.G1Affine {
+	var p curve.G1Affine
+	p.X = readFpMont(r)
+	p.Y = readFpMont(r)
+	if r.err == nil && !p.IsOnCurve() {
+		r.err = fmt.Errorf("G1 point is not on the curve")
+	}
+	return p
+}
+
+// readG2Mont reads an affine G2 point as (x.A0, x.A1, y.A0, y.A1).
+func readG2Mont(r *binReader) curve.G2Affine {
+	var p curve.G2Affine
+	p.X.A0 = readFpMont(r)
+	p.X.A1 = readFpMont(r)
+	p.Y.A0 = readFpMont(r)
+	p.Y.A1 = readFpMont(r)
+	if r.err == nil && !p.IsOnCurve() {
+		r.err = fmt.Errorf("G2 point is not on the curve")
+	}
+	return p
+}
+
+// hPointsToZ converts the H section of a zkey into gnark's pk.G1.Z.
+//
+// SnarkJS stores hⱼ = [L'₂ⱼ₊₁(τ)/δ]₁ for j < n, where L'ᵢ is the i-th Lagrange
+// polynomial over the 2n-th roots of unity, while gnark expects
+// zᵢ = [τⁱ·t(τ)/δ]₁ with t(X) = Xⁿ-1, in bit-reversed order. Xⁱ·t(X) has
+// degree < 2n and vanishes on the n-th roots of unity, so interpolating it on
+// the odd 2n-th roots gives zᵢ = -2·gⁱ·Σⱼ ωⁱʲ·hⱼ, where g is the 2n-th root of
+// unity and ω = g². The sum is a DFT over G1, computed with a radix-2 FFT.
+func hPointsToZ(h []curve.G1Affine) []curve.G1Affine {
+	n := len(h)
+	points := make([]curve.G1Jac, n)
+	for i := range h {
+		points[i].FromAffine(&h[i])
+	}
+	domain := fft.NewDomain(uint64(2 * n))
+	var omega bn254fr.Element
+	omega.Square(&domain.Generator)
+	fftG1(points, omega)
+
+	// scale by -2·gⁱ
+	var s, minusTwo bn254fr.Element
+	minusTwo.SetInt64(-2)
+	s.Set(&minusTwo)
+	var sBig big.Int
+	for i := range points {
+		points[i].ScalarMultiplication(&points[i], s.BigInt(&sBig))
+		s.Mul(&s, &domain.Generator)
+	}
+	affine := curve.BatchJacobianToAffineG1(points)
+
+	// bit-reverse and drop the last point, as deg(H) = n-2
+	z := make([]curve.G1Affine, n-1)
+	logN := uint64(bits.TrailingZeros(uint(n)))
+	for i := range z {
+		z[i] = affine[bits.Reverse64(uint64(i))>>(64-logN)]
+	}
+	return z
+}
+
+// fftG1 computes in place the DFT aᵢ = Σⱼ ωⁱʲ·aⱼ of a slice of G1 points,
+// where ω is a primitive len(a)-th root of unity and len(a) is a power of two.
+func fftG1(a []curve.G1Jac, omega bn254fr.Element) {
+	n := len(a)
+	logN := uint64(bits.TrailingZeros(uint(n)))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse64(uint64(i)) >> (64 - logN))
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	var t curve.G1Jac
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		var wLen, w bn254fr.Element
+		wLen.Exp(omega, big.NewInt(int64(n/size)))
+		twiddles := make([]big.Int, half)
+		w.SetOne()
+		for k := range twiddles {
+			w.BigInt(&twiddles[k])
+			w.Mul(&w, &wLen)
+		}
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				if k == 0 {
+					t.Set(&a[start+half])
+				} else {
+					t.ScalarMultiplication(&a[start+k+half], &twiddles[k])
+				}
+				a[start+k+half].Set(&a[start+k]).SubAssign(&t)
+				a[start+k].AddAssign(&t)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestVerifyBatch(t *testing.T) {
+	var circuit Circuit
+	cs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+	if err != nil {
+		t.Fatalf("failed to compile circuit: %v", err)
+	}
+	pk, vk, err := groth16.Setup(cs)
+	if err != nil {
+		t.Fatalf("groth16 setup failed: %v", err)
+	}
+	var (
+		circomVk      *parser.CircomVerificationKey
+		proofs        []*parser.CircomProof
+		publicSignals [][]string
+	)
+	for e := 1; e <= 8; e++ {
+		assignment := Circuit{X: 3, E: e, Y: pow(3, e)}
+		witnessFull, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+		if err != nil {
+			t.Fatalf("failed to create witness: %v", err)
+		}
+		publicWitness, err := witnessFull.Public()
+		if err != nil {
+			t.Fatalf("failed to get public witness: %v", err)
+		}
+		proof, err := groth16.Prove(cs, pk, witnessFull)
+		if err != nil {
+			t.Fatalf("groth16 proving failed: %v", err)
+		}
+		circomProof, v, signals, err := parser.ConvertGnarkToCircom(proof, vk, publicWitness)
+		if err != nil {
+			t.Fatalf("conversion to Circom format failed: %v", err)
+		}
+		circomVk = v
+		proofs = append(proofs, circomProof)
+		publicSignals = append(publicSignals, signals)
+	}
+
+	invalid, err := parser.VerifyBatch(circomVk, proofs, publicSignals)
+	if err != nil || invalid != nil {
+		t.Fatalf("all proofs should verify, got %v: %v", invalid, err)
+	}
+
+	// Invalid proofs are identified by bisection
+	tampered := append([][]string(nil), publicSignals...)
+	tampered[2] = []string{"3", "10"}
+	tampered[5] = []string{"3", "-1"}
+	tampered[7] = publicSignals[6]
+	swapped := append([]*parser.CircomProof(nil), proofs...)
+	swapped[0], swapped[1] = proofs[1], proofs[0]
+	invalid, err = parser.VerifyBatch(circomVk, swapped, tampered)
+	if !errors.Is(err, parser.ErrVerificationFailed) || !reflect.DeepEqual(invalid, []int{0, 1, 2, 5, 7}) {
+		t.Errorf("expected proofs 0, 1, 2, 5 and 7 to be invalid, got %v: %v", invalid, err)
+	}
+	if _, err := parser.VerifyBatch(circomVk, proofs, publicSignals[:1]); err == nil {
+		t.Errorf("expected error for mismatched public signals")
+	}
+
+	// Batches mixing several verification keys
+	circomDataVk, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(t, "circom_data/vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	circomDataProof, err := parser.UnmarshalCircomProofJSON(loadFile(t, "circom_data/proof.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	circomDataSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadFile(t, "circom_data/public_signals.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+	other, err := parser.ConvertCircomToGnark(circomDataVk, circomDataProof, circomDataSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	var mixed []*parser.GnarkProof
+	for i := range proofs {
+		p, err := parser.ConvertCircomToGnark(circomVk, proofs[i], publicSignals[i])
+		if err != nil {
+			t.Fatalf("failed to convert proof: %v", err)
+		}
+		if len(mixed) > 0 {
+			p.VerifyingKey = mixed[0].VerifyingKey
+		}
+		mixed = append(mixed, p)
+	}
+	// Proofs under keys with commitments are verified one by one
+	committed := commitGnarkProof(t, 1)
+	mixed = append(mixed, other, committed)
+	if invalid, err := parser.VerifyProofBatch(mixed); err != nil || invalid != nil {
+		t.Fatalf("all proofs should verify, got %v: %v", invalid, err)
+	}
+	wrongKey := *other
+	wrongKey.VerifyingKey = mixed[0].VerifyingKey
+	wrongInputs := *committed
+	wrongInputs.PublicInputs = append([]fr.Element(nil), committed.PublicInputs...)
+	wrongInputs.PublicInputs[0].SetOne()
+	mixed = append(mixed, &wrongKey, nil, &wrongInputs)
+	if invalid, err := parser.VerifyProofBatch(mixed); !errors.Is(err, parser.ErrVerificationFailed) ||
+		!reflect.DeepEqual(invalid, []int{len(proofs) + 2, len(proofs) + 3, len(proofs) + 4}) {
+		t.Errorf("expected the last three proofs to be invalid, got %v: %v", invalid, err)
+	}
+	if invalid, err := parser.VerifyProofBatch(nil); err != nil || invalid != nil {
+		t.Errorf("an empty batch should verify, got %v: %v", invalid, err)
+	}
+}
+
+func pow(x, e int) int {
+	r := 1
+	for i := 0; i < e; i++ {
+		r *= x
+	}
+	return r
+}
@@ -0,0 +1,162 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestBinaryEncoding(t *testing.T) {
+	vkJSON := loadFile(t, "circom_data/vkey.json")
+	vk, err := parser.UnmarshalCircomVerificationKeyJSON(vkJSON)
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	proof, err := parser.UnmarshalCircomProofJSON(loadFile(t, "circom_data/proof.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	publicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadFile(t, "circom_data/public_signals.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+
+	vkData, err := vk.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal verification key: %v", err)
+	}
+	if 4*len(vkData) > len(vkJSON) {
+		t.Errorf("binary verification key of %d bytes is not 4x smaller than JSON (%d bytes)", len(vkData), len(vkJSON))
+	}
+	proofData, err := proof.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal proof: %v", err)
+	}
+	publicData, err := parser.CircomPublicSignals(publicSignals).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal public signals: %v", err)
+	}
+
+	var gotVk parser.CircomVerificationKey
+	if err := gotVk.UnmarshalBinary(vkData); err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	var gotProof parser.CircomProof
+	if err := gotProof.UnmarshalBinary(proofData); err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	var gotPublic parser.CircomPublicSignals
+	if err := gotPublic.UnmarshalBinary(publicData); err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+	if !reflect.DeepEqual(&gotVk, vk) || !reflect.DeepEqual(&gotProof, proof) ||
+		!reflect.DeepEqual([]string(gotPublic), publicSignals) {
+		t.Fatalf("roundtrip mismatch")
+	}
+	// The protocol is normalized to groth16
+	noProtocol := *proof
+	noProtocol.Protocol = ""
+	noProtocolData, err := noProtocol.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal proof: %v", err)
+	}
+	if !bytes.Equal(noProtocolData, proofData) {
+		t.Errorf("the protocol should not be encoded")
+	}
+	if err := noProtocol.UnmarshalBinary(noProtocolData); err != nil || noProtocol.Protocol != "groth16" {
+		t.Errorf("expected protocol groth16, got %q: %v", noProtocol.Protocol, err)
+	}
+
+	gnarkProof, err := parser.ConvertCircomToGnark(&gotVk, &gotProof, gotPublic)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if ok, err := parser.VerifyProof(gnarkProof); !ok || err != nil {
+		t.Errorf("proof should verify: %v", err)
+	}
+
+	// WriteTo and ReadFrom stream several values
+	var buf bytes.Buffer
+	n1, err := vk.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("failed to write verification key: %v", err)
+	}
+	n2, err := proof.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("failed to write proof: %v", err)
+	}
+	if n1 != int64(len(vkData)) || n1+n2 != int64(buf.Len()) {
+		t.Errorf("unexpected byte counts %d and %d for %d bytes", n1, n2, buf.Len())
+	}
+	if n, err := gotVk.ReadFrom(&buf); err != nil || n != n1 {
+		t.Errorf("failed to read verification key (%d bytes): %v", n, err)
+	}
+	if n, err := gotProof.ReadFrom(&buf); err != nil || n != n2 || !reflect.DeepEqual(&gotProof, proof) {
+		t.Errorf("failed to read proof (%d bytes): %v", n, err)
+	}
+
+	// The loader recognizes the binary encodings
+	for _, tc := range []struct {
+		data []byte
+		kind parser.ArtifactKind
+	}{
+		{proofData, parser.ArtifactProof},
+		{vkData, parser.ArtifactVerificationKey},
+		{publicData, parser.ArtifactPublicSignals},
+	} {
+		if a, err := parser.ParseArtifact(tc.data); err != nil || a.Kind != tc.kind {
+			t.Errorf("expected %s, got %+v: %v", tc.kind, a, err)
+		}
+	}
+
+	// Truncated and corrupted data
+	for _, data := range [][]byte{vkData, proofData} {
+		for i := 0; i < len(data); i++ {
+			if err := gotVk.UnmarshalBinary(data[:i]); !errors.Is(err, parser.ErrMalformedBinary) {
+				t.Fatalf("expected error for %d truncated bytes", i)
+			}
+			if err := gotProof.UnmarshalBinary(data[:i]); !errors.Is(err, parser.ErrMalformedBinary) {
+				t.Fatalf("expected error for %d truncated bytes", i)
+			}
+		}
+	}
+	if err := gotProof.UnmarshalBinary(append(bytes.Clone(proofData), 0)); !errors.Is(err, parser.ErrMalformedBinary) || errorPath(err) != "proof" {
+		t.Errorf("expected error for trailing bytes")
+	}
+	if err := gotProof.UnmarshalBinary(vkData); !errors.Is(err, parser.ErrMalformedBinary) {
+		t.Errorf("expected error for a verification key decoded as a proof")
+	}
+	bad := bytes.Clone(proofData)
+	bad[4] = 2
+	if err := gotProof.UnmarshalBinary(bad); !errors.Is(err, parser.ErrMalformedBinary) {
+		t.Errorf("expected error for an unsupported version")
+	}
+	bad = bytes.Clone(proofData)
+	bad[6] |= 0x3f
+	if err := gotProof.UnmarshalBinary(bad); !errors.Is(err, parser.ErrInvalidPoint) || errorPath(err) != "proof.pi_a" {
+		t.Errorf("expected invalid point at proof.pi_a, got %v", err)
+	}
+	bad = bytes.Clone(vkData)
+	bad[9]++
+	if err := gotVk.UnmarshalBinary(bad); !errors.Is(err, parser.ErrNPublicMismatch) {
+		t.Errorf("expected public signals mismatch, got %v", err)
+	}
+	bad = bytes.Clone(vkData)
+	bad[10] = 2
+	if err := gotVk.UnmarshalBinary(bad); !errors.Is(err, parser.ErrMalformedBinary) || errorPath(err) != "vk.vk_alphabeta_12" {
+		t.Errorf("expected malformed binary data at vk.vk_alphabeta_12, got %v", err)
+	}
+
+	// Invalid inputs cannot be encoded
+	badProof := *proof
+	badProof.PiA = []string{"1", "3", "1"}
+	if _, err := badProof.MarshalBinary(); !errors.Is(err, parser.ErrInvalidPoint) {
+		t.Errorf("expected invalid point, got %v", err)
+	}
+	if _, err := parser.CircomPublicSignals([]string{"0x1"}).MarshalBinary(); !errors.Is(err, parser.ErrInvalidScalar) {
+		t.Errorf("expected invalid scalar, got %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381fr "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestBLS12381Groth16(t *testing.T) {
+	var circuit Circuit
+	assignment := Circuit{X: 2, E: 12, Y: 4096}
+	cs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, &circuit)
+	if err != nil {
+		t.Fatalf("failed to compile circuit: %v", err)
+	}
+	pk, vk, err := groth16.Setup(cs)
+	if err != nil {
+		t.Fatalf("groth16 setup failed: %v", err)
+	}
+	witnessFull, err := frontend.NewWitness(&assignment, ecc.BLS12_381.ScalarField())
+	if err != nil {
+		t.Fatalf("failed to create witness: %v", err)
+	}
+	publicWitness, err := witnessFull.Public()
+	if err != nil {
+		t.Fatalf("failed to get public witness: %v", err)
+	}
+	proof, err := groth16.Prove(cs, pk, witnessFull)
+	if err != nil {
+		t.Fatalf("groth16 proving failed: %v", err)
+	}
+
+	// Gnark to Circom
+	circomProof, circomVk, circomPub, err := parser.ConvertGnarkToCircom(proof, vk, publicWitness)
+	if err != nil {
+		t.Fatalf("conversion to Circom format failed: %v", err)
+	}
+	if circomVk.Curve != "bls12381" || circomProof.Curve != "bls12381" {
+		t.Errorf("unexpected curves %q and %q", circomVk.Curve, circomProof.Curve)
+	}
+	if len(circomPub) != 2 || circomPub[0] != "2" || circomPub[1] != "4096" {
+		t.Errorf("unexpected public signals: %v", circomPub)
+	}
+
+	// JSON roundtrip
+	proofJSON, err := parser.MarshalCircomProofJSON(circomProof)
+	if err != nil {
+		t.Fatalf("failed to marshal proof: %v", err)
+	}
+	vkJSON, err := parser.MarshalCircomVerificationKeyJSON(circomVk)
+	if err != nil {
+		t.Fatalf("failed to marshal verification key: %v", err)
+	}
+	if circomProof, err = parser.UnmarshalCircomProofJSON(proofJSON); err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	if circomVk, err = parser.UnmarshalCircomVerificationKeyJSON(vkJSON); err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+
+	// Compressed points roundtrip
+	proofJSON, err = parser.MarshalCircomProofJSON(circomProof, parser.WithCompressedPoints())
+	if err != nil {
+		t.Fatalf("failed to marshal compressed proof: %v", err)
+	}
+	vkJSON, err = parser.MarshalCircomVerificationKeyJSON(circomVk, parser.WithCompressedPoints(), parser.WithHexCoordinates())
+	if err != nil {
+		t.Fatalf("failed to marshal compressed verification key: %v", err)
+	}
+	if p, err := parser.UnmarshalCircomProofJSON(proofJSON); err != nil || !reflect.DeepEqual(p, circomProof) {
+		t.Errorf("compressed proof roundtrip mismatch: %v", err)
+	}
+	if vk, err := parser.UnmarshalCircomVerificationKeyJSON(vkJSON); err != nil || !reflect.DeepEqual(vk, circomVk) {
+		t.Errorf("compressed verification key roundtrip mismatch: %v", err)
+	}
+
+	// Binary roundtrip
+	vkData, err := circomVk.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal binary verification key: %v", err)
+	}
+	var binaryVk parser.CircomVerificationKey
+	if err := binaryVk.UnmarshalBinary(vkData); err != nil || !reflect.DeepEqual(&binaryVk, circomVk) {
+		t.Errorf("binary verification key roundtrip mismatch: %v", err)
+	}
+	fp, err := circomVk.Fingerprint(parser.FingerprintKeccak256)
+	if err != nil {
+		t.Fatalf("failed to compute fingerprint: %v", err)
+	}
+	if got, err := binaryVk.Fingerprint(parser.FingerprintKeccak256); err != nil || got != fp {
+		t.Errorf("fingerprint mismatch: %v", err)
+	}
+
+	if ok, err := (parser.GnarkVerifier{}).Verify(circomVk, circomProof, circomPub); !ok || err != nil {
+		t.Errorf("gnark verifier should verify the proof: %v", err)
+	}
+
+	// Circom to Gnark
+	gnarkProof, err := parser.ConvertCircomToGnarkBLS12381(circomVk, circomProof, circomPub)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if ok, err := parser.VerifyProofBLS12381(gnarkProof); !ok || err != nil {
+		t.Errorf("proof should verify: %v", err)
+	}
+	gnarkProof, err = parser.ConvertCircomToGnarkBLS12381(circomVk, circomProof, []string{"2", "4097"})
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if ok, _ := parser.VerifyProofBLS12381(gnarkProof); ok {
+		t.Errorf("proof should not verify with wrong public signals")
+	}
+
+	// Dispatch on the curve of the verification key
+	p, v, w, err := parser.ConvertCircomToGnarkGroth16(circomVk, circomProof, circomPub)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if p.CurveID() != ecc.BLS12_381 {
+		t.Errorf("unexpected curve %s", p.CurveID())
+	}
+	if err := groth16.Verify(p, v, w); err != nil {
+		t.Errorf("proof should verify: %v", err)
+	}
+
+	// The bn254 conversion rejects BLS12-381 keys and coordinates
+	if _, err := parser.ConvertVerificationKey(circomVk); err == nil {
+		t.Errorf("expected error converting a bls12381 key to bn254")
+	}
+	if _, err := parser.ConvertCircomToGnark(circomVk, circomProof, circomPub); err == nil {
+		t.Errorf("expected error converting a bls12381 proof to bn254")
+	}
+	bn254Vk := *circomVk
+	bn254Vk.Curve = "bn128"
+	if _, _, _, err := parser.ConvertCircomToGnarkGroth16(&bn254Vk, circomProof, circomPub); err == nil {
+		t.Errorf("expected error for mismatched proof and key curves")
+	}
+
+	// Invalid points
+	bad := *circomProof
+	bad.PiA = []string{"1", "2", "1"}
+	if _, err := parser.ConvertProofBLS12381(&bad); err == nil {
+		t.Errorf("expected error for a point not on the curve")
+	}
+	bad.PiA = []string{circomProof.PiA[0], circomProof.PiA[1], "2"}
+	if _, err := parser.ConvertProofBLS12381(&bad); err == nil {
+		t.Errorf("expected error for a Jacobian point not on the curve")
+	}
+	bad.PiA = []string{"0", "1", "0"}
+	if _, err := parser.ConvertProofBLS12381(&bad); err != nil {
+		t.Errorf("the point at infinity should be accepted: %v", err)
+	}
+
+	// The commitments of gnark keys are not public signals
+	ccs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, &commitCircuit{NbCommitments: 1})
+	if err != nil {
+		t.Fatalf("failed to compile circuit: %v", err)
+	}
+	commitPk, commitVk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatalf("groth16 setup failed: %v", err)
+	}
+	commitWitness, err := frontend.NewWitness(&commitCircuit{X: 3, Y: 21, Z: 7}, ecc.BLS12_381.ScalarField())
+	if err != nil {
+		t.Fatalf("failed to create witness: %v", err)
+	}
+	commitProof, err := groth16.Prove(ccs, commitPk, commitWitness)
+	if err != nil {
+		t.Fatalf("groth16 proving failed: %v", err)
+	}
+	commitPublic, err := commitWitness.Public()
+	if err != nil {
+		t.Fatalf("failed to get public witness: %v", err)
+	}
+	gnarkProof = &parser.GnarkProofBLS12381{
+		Proof:        commitProof.(*groth16_bls12381.Proof),
+		VerifyingKey: commitVk.(*groth16_bls12381.VerifyingKey),
+		PublicInputs: commitPublic.Vector().(bls12381fr.Vector),
+	}
+	if ok, err := parser.VerifyProofBLS12381(gnarkProof); !ok || err != nil {
+		t.Errorf("proof with a commitment should verify: %v", err)
+	}
+	gnarkProof.PublicInputs = gnarkProof.PublicInputs[:1]
+	if _, err := parser.VerifyProofBLS12381(gnarkProof); !errors.Is(err, parser.ErrNPublicMismatch) {
+		t.Errorf("expected public signals mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+pragma circom 2.0.0;
+
+// Multiplier is the circuit mimicked by the test fixtures: out = a·b·(x+1),
+// with x public and a, b private.
+template Multiplier() {
+    signal input x;
+    signal input a;
+    signal input b;
+    signal output out;
+    signal t;
+
+    t <== a * b;
+    out <== t * (x + 1);
+}
+
+component main {public [x]} = Multiplier();
@@ -0,0 +1,34 @@
+#!/bin/sh
+# Builds the circom and snarkjs artifacts of circuit.circom used by the tests.
+# It needs circom 2 and snarkjs in the PATH; the tests that read the artifacts
+# are skipped until it has been run and its output committed.
+set -eu
+cd "$(dirname "$0")"
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+circom circuit.circom --r1cs --wasm -o "$tmp"
+cp "$tmp/circuit.r1cs" circuit.r1cs
+cp "$tmp/circuit_js/circuit.wasm" circuit.wasm
+
+# Powers of tau, large enough for the circuit
+snarkjs powersoftau new bn128 10 "$tmp/pot_0.ptau"
+snarkjs powersoftau contribute "$tmp/pot_0.ptau" "$tmp/pot_1.ptau" -e="circom2gnark"
+snarkjs powersoftau prepare phase2 "$tmp/pot_1.ptau" "$tmp/pot.ptau"
+
+# Groth16
+snarkjs groth16 setup "$tmp/circuit.r1cs" "$tmp/pot.ptau" "$tmp/groth16_0.zkey"
+snarkjs zkey contribute "$tmp/groth16_0.zkey" groth16.zkey -e="circom2gnark"
+snarkjs zkey export verificationkey groth16.zkey groth16_vkey.json
+snarkjs wtns calculate circuit.wasm input.json witness.wtns
+snarkjs groth16 prove groth16.zkey witness.wtns groth16_proof.json groth16_public.json
+
+# PLONK
+snarkjs plonk setup "$tmp/circuit.r1cs" "$tmp/pot.ptau" "$tmp/plonk.zkey"
+snarkjs zkey export verificationkey "$tmp/plonk.zkey" plonk_vkey.json
+snarkjs plonk prove "$tmp/plonk.zkey" witness.wtns plonk_proof.json plonk_public.json
+
+# FFLONK
+snarkjs fflonk setup "$tmp/circuit.r1cs" "$tmp/pot.ptau" "$tmp/fflonk.zkey"
+snarkjs zkey export verificationkey "$tmp/fflonk.zkey" fflonk_vkey.json
+snarkjs fflonk prove "$tmp/fflonk.zkey" witness.wtns fflonk_proof.json fflonk_public.json
@@ -10,7 +10,7 @@ import (
 	"github.com/vocdoni/circom2gnark/parser"
 )
 
-func loadFile(t *testing.T, path string) []byte {
+func loadFile(t testing.TB, path string) []byte {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		t.Fatalf("failed to read %s: %v", path, err)
@@ -0,0 +1,251 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestStrictPointDecoding(t *testing.T) {
+	_, _, g1Gen, g2Gen := curve.Generators()
+	var two fr.Element
+	two.SetUint64(2)
+	g1Two := g1Mul(two)
+	g2Strings := [][]string{
+		{g2Gen.X.A0.String(), g2Gen.X.A1.String()},
+		{g2Gen.Y.A0.String(), g2Gen.Y.A1.String()},
+		{"1", "0"},
+	}
+	valid := func() *parser.CircomProof {
+		return &parser.CircomProof{
+			PiA:      []string{"1", "2", "1"}, // the generator
+			PiB:      g2Strings,
+			PiC:      g1ToStrings(g1Two),
+			Protocol: "groth16",
+		}
+	}
+
+	proof, err := parser.ConvertProof(valid())
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if !proof.Ar.Equal(&g1Gen) || !proof.Bs.Equal(&g2Gen) || !proof.Krs.Equal(&g1Two) {
+		t.Errorf("decoded points do not match")
+	}
+
+	// Jacobian coordinates (x·z², y·z³, z) and hex strings decode to the same points
+	p := valid()
+	p.PiA = []string{"4", "16", "2"}
+	p.PiC = []string{"0x" + g1Two.X.Text(16), "0x" + g1Two.Y.Text(16), "0x1"}
+	var z, z2, z3 curve.E2
+	z.A0.SetUint64(3)
+	z.A1.SetUint64(5)
+	z2.Square(&z)
+	z3.Mul(&z2, &z)
+	var x, y curve.E2
+	x.Mul(&g2Gen.X, &z2)
+	y.Mul(&g2Gen.Y, &z3)
+	p.PiB = [][]string{{x.A0.String(), x.A1.String()}, {y.A0.String(), y.A1.String()}, {"3", "5"}}
+	if proof, err = parser.ConvertProof(p); err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if !proof.Ar.Equal(&g1Gen) || !proof.Bs.Equal(&g2Gen) || !proof.Krs.Equal(&g1Two) {
+		t.Errorf("decoded Jacobian points do not match")
+	}
+
+	// The point at infinity
+	p = valid()
+	p.PiA = []string{"0", "1", "0"}
+	p.PiB = [][]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+	if proof, err = parser.ConvertProof(p); err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if !proof.Ar.IsInfinity() || !proof.Bs.IsInfinity() {
+		t.Errorf("expected the points at infinity")
+	}
+
+	pMinusOne := new(big.Int).Sub(fp.Modulus(), big.NewInt(1))
+	for name, tamper := range map[string]func(p *parser.CircomProof){
+		"non-canonical x":      func(p *parser.CircomProof) { p.PiA[0] = new(big.Int).Add(fp.Modulus(), big.NewInt(1)).String() },
+		"negative y":           func(p *parser.CircomProof) { p.PiA[1] = "-2" },
+		"not on the curve":     func(p *parser.CircomProof) { p.PiA[1] = "3" },
+		"large z":              func(p *parser.CircomProof) { p.PiA[2] = fp.Modulus().String() },
+		"bad number":           func(p *parser.CircomProof) { p.PiA[0] = "0x" },
+		"empty number":         func(p *parser.CircomProof) { p.PiC[1] = "" },
+		"missing z":            func(p *parser.CircomProof) { p.PiA = p.PiA[:2] },
+		"extra coordinate":     func(p *parser.CircomProof) { p.PiA = append(p.PiA, "1") },
+		"nil G1":               func(p *parser.CircomProof) { p.PiC = nil },
+		"nil G2":               func(p *parser.CircomProof) { p.PiB = nil },
+		"short G2":             func(p *parser.CircomProof) { p.PiB = p.PiB[:2] },
+		"short G2 coordinate":  func(p *parser.CircomProof) { p.PiB[0] = p.PiB[0][:1] },
+		"empty G2 coordinate":  func(p *parser.CircomProof) { p.PiB[2] = nil },
+		"non-canonical G2":     func(p *parser.CircomProof) { p.PiB[1][1] = fp.Modulus().String() },
+		"G2 not on the curve":  func(p *parser.CircomProof) { p.PiB[1][0] = pMinusOne.String() },
+		"G2 not in subgroup":   func(p *parser.CircomProof) { p.PiB = twistPointNotInSubgroup(t) },
+		"G2 infinity, bad z.1": func(p *parser.CircomProof) { p.PiB[2] = []string{"0", "-1"} },
+	} {
+		p := valid()
+		tamper(p)
+		if _, err := parser.ConvertProof(p); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// twistPointNotInSubgroup returns a point of the bn254 twist y² = x³ + 3/(9+u)
+// outside of the prime order subgroup, in Circom projective form.
+func twistPointNotInSubgroup(t *testing.T) [][]string {
+	p := twistG2NotInSubgroup(t)
+	return [][]string{{p.X.A0.String(), p.X.A1.String()}, {p.Y.A0.String(), p.Y.A1.String()}, {"1", "0"}}
+}
+
+// twistG2NotInSubgroup returns a point of the bn254 twist y² = x³ + 3/(9+u)
+// outside of the prime order subgroup.
+func twistG2NotInSubgroup(t *testing.T) curve.G2Affine {
+	var b, xi curve.E2
+	b.A0.SetUint64(3)
+	xi.A0.SetUint64(9)
+	xi.A1.SetUint64(1)
+	b.Div(&b, &xi)
+	for i := uint64(1); i < 100; i++ {
+		var p curve.G2Affine
+		p.X.A0.SetUint64(i)
+		var rhs curve.E2
+		rhs.Square(&p.X).Mul(&rhs, &p.X).Add(&rhs, &b)
+		if rhs.Legendre() != 1 {
+			continue
+		}
+		p.Y.Sqrt(&rhs)
+		if !p.IsOnCurve() || p.IsInSubGroup() {
+			continue
+		}
+		return p
+	}
+	t.Fatal("no twist point found outside of the subgroup")
+	return curve.G2Affine{}
+}
+
+// FuzzConvertProof checks that decoding an arbitrary proof never panics, with
+// the BN254 and the BLS12-381 decoders. The seed corpus is in
+// testdata/fuzz/FuzzConvertProof.
+func FuzzConvertProof(f *testing.F) {
+	data, err := os.ReadFile("circom_data/proof.json")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		proof, err := parser.UnmarshalCircomProofJSON(data)
+		if err != nil {
+			return
+		}
+		_, _ = parser.ConvertProof(proof)
+		_, _ = parser.ConvertProofBLS12381(proof)
+	})
+}
+
+// FuzzConvertVerificationKey checks that decoding an arbitrary verification
+// key never panics. The seed corpus is in
+// testdata/fuzz/FuzzConvertVerificationKey.
+func FuzzConvertVerificationKey(f *testing.F) {
+	data, err := os.ReadFile("circom_data/vkey.json")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		vk, err := parser.UnmarshalCircomVerificationKeyJSON(data)
+		if err != nil {
+			return
+		}
+		_, _ = parser.ConvertVerificationKey(vk)
+		_, _ = parser.ConvertVerificationKeyBLS12381(vk)
+	})
+}
+
+func TestStrictPublicInputs(t *testing.T) {
+	vk, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(t, "circom_data/vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	proof, err := parser.UnmarshalCircomProofJSON(loadFile(t, "circom_data/proof.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	publicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadFile(t, "circom_data/public_signals.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+	if _, err := parser.ConvertCircomToGnark(vk, proof, publicSignals); err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+
+	// x + r is reduced to x by the lenient conversion, but rejected by default
+	last := len(publicSignals) - 1
+	x, _ := new(big.Int).SetString(publicSignals[last], 10)
+	shifted := append([]string{}, publicSignals...)
+	shifted[last] = new(big.Int).Add(x, fr.Modulus()).String()
+	lenient, err := parser.ConvertPublicInputs(shifted)
+	if err != nil {
+		t.Fatalf("failed to convert public inputs: %v", err)
+	}
+	if ok, _ := parser.VerifyProof(&parser.GnarkProof{
+		Proof:        mustConvertProof(t, proof),
+		VerifyingKey: mustConvertVerificationKey(t, vk),
+		PublicInputs: lenient,
+	}); !ok {
+		t.Errorf("reduced public inputs should verify")
+	}
+	_, err = parser.ConvertCircomToGnark(vk, proof, shifted)
+	if path := errorPath(err); path != fmt.Sprintf("publicSignals[%d]", last) {
+		t.Errorf("expected error at public signal %d, got %v", last, err)
+	}
+	if _, err := parser.ConvertCircomToGnarkRecursion(vk, proof, shifted, false); err == nil {
+		t.Errorf("expected error for a public input out of range in recursion")
+	}
+
+	for _, s := range []string{
+		"0", "1", new(big.Int).Sub(fr.Modulus(), big.NewInt(1)).String(),
+	} {
+		if _, err := parser.ConvertPublicInputsStrict([]string{s}); err != nil {
+			t.Errorf("%s: unexpected error: %v", s, err)
+		}
+	}
+	for _, s := range []string{
+		"", "-1", "+1", "01", "00", "0x1", "1e3", " 1", fr.Modulus().String(), strings.Repeat("9", 1000),
+	} {
+		if _, err := parser.ConvertPublicInputsStrict([]string{"1", s}); err == nil {
+			t.Errorf("%q: expected error", s)
+		} else if !errors.Is(err, parser.ErrInvalidScalar) || errorPath(err) != "publicSignals[1]" {
+			t.Errorf("%q: error does not name the index: %v", s, err)
+		}
+	}
+	if _, err := parser.ConvertPublicInputsStrictBLS12381([]string{fr.Modulus().String()}); err != nil {
+		t.Errorf("the bn254 modulus is a valid BLS12-381 scalar: %v", err)
+	}
+}
+
+func mustConvertProof(t *testing.T, proof *parser.CircomProof) *groth16_bn254.Proof {
+	p, err := parser.ConvertProof(proof)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	return p
+}
+
+func mustConvertVerificationKey(t *testing.T, vk *parser.CircomVerificationKey) *groth16_bn254.VerifyingKey {
+	v, err := parser.ConvertVerificationKey(vk)
+	if err != nil {
+		t.Fatalf("failed to convert verification key: %v", err)
+	}
+	return v
+}
@@ -0,0 +1,194 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestMarshalOptions(t *testing.T) {
+	vk, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(t, "circom_data/vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	proof, err := parser.UnmarshalCircomProofJSON(loadFile(t, "circom_data/proof.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	publicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadFile(t, "circom_data/public_signals.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+
+	all := []parser.MarshalOption{
+		parser.WithHexCoordinates(),
+		parser.WithCompressedPoints(),
+		parser.WithCurveField(),
+		parser.WithCompactJSON(),
+	}
+	for mask := 0; mask < 1<<len(all); mask++ {
+		var opts []parser.MarshalOption
+		for i := range all {
+			if mask&(1<<i) != 0 {
+				opts = append(opts, all[i])
+			}
+		}
+		proofJSON, err := parser.MarshalCircomProofJSON(proof, opts...)
+		if err != nil {
+			t.Fatalf("%b: failed to marshal proof: %v", mask, err)
+		}
+		vkJSON, err := parser.MarshalCircomVerificationKeyJSON(vk, opts...)
+		if err != nil {
+			t.Fatalf("%b: failed to marshal verification key: %v", mask, err)
+		}
+		publicJSON, err := parser.MarshalCircomPublicSignalsJSON(publicSignals, opts...)
+		if err != nil {
+			t.Fatalf("%b: failed to marshal public signals: %v", mask, err)
+		}
+		if compact := mask&(1<<3) != 0; compact == bytes.Contains(proofJSON, []byte("\n")) {
+			t.Errorf("%b: unexpected indentation in %s", mask, proofJSON)
+		}
+		if hex := mask&1 != 0; hex != bytes.Contains(publicJSON, []byte(`"0x`)) {
+			t.Errorf("%b: unexpected public signals encoding %s", mask, publicJSON)
+		}
+
+		gotProof, err := parser.UnmarshalCircomProofJSON(proofJSON)
+		if err != nil {
+			t.Fatalf("%b: failed to unmarshal proof: %v", mask, err)
+		}
+		gotVk, err := parser.UnmarshalCircomVerificationKeyJSON(vkJSON)
+		if err != nil {
+			t.Fatalf("%b: failed to unmarshal verification key: %v", mask, err)
+		}
+		gotPublic, err := parser.UnmarshalCircomPublicSignalsJSON(publicJSON)
+		if err != nil {
+			t.Fatalf("%b: failed to unmarshal public signals: %v", mask, err)
+		}
+		wantProof := *proof
+		if mask&(1<<2) != 0 {
+			wantProof.Curve = "bn128"
+		}
+		if !reflect.DeepEqual(gotProof, &wantProof) || !reflect.DeepEqual(gotVk, vk) ||
+			!reflect.DeepEqual(gotPublic, publicSignals) {
+			t.Fatalf("%b: roundtrip mismatch", mask)
+		}
+
+		// The loader accepts all the encodings too
+		if a, err := parser.ParseArtifact(proofJSON); err != nil || !reflect.DeepEqual(a.Proof, &wantProof) {
+			t.Errorf("%b: failed to load proof: %v", mask, err)
+		}
+	}
+
+	// Compressed points are a single hex string
+	data, err := parser.MarshalCircomProofJSON(proof, parser.WithCompressedPoints(), parser.WithCompactJSON())
+	if err != nil {
+		t.Fatalf("failed to marshal proof: %v", err)
+	}
+	if !bytes.Contains(data, []byte(`"pi_a":["0x`)) || !bytes.Contains(data, []byte(`"pi_b":[["0x`)) {
+		t.Errorf("unexpected compressed proof %s", data)
+	}
+
+	// Invalid compressed points
+	var compressedProof map[string]any
+	if err := json.Unmarshal(data, &compressedProof); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name  string
+		field string
+		point any
+	}{
+		{"odd hex", "pi_a", []string{"0x123"}},
+		{"length", "pi_c", []string{"0x" + strings.Repeat("00", 40)}},
+		{"not reduced", "pi_c", []string{"0xbf" + strings.Repeat("ff", 31)}},
+		{"G2 length", "pi_b", [][]string{{"0x" + strings.Repeat("00", 32)}}},
+	} {
+		tampered := maps.Clone(compressedProof)
+		tampered[tc.field] = tc.point
+		data, err := json.Marshal(tampered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parser.UnmarshalCircomProofJSON(data); !errors.Is(err, parser.ErrInvalidPoint) || errorPath(err) != "proof."+tc.field {
+			t.Errorf("%s: expected invalid point at proof.%s, got %v", tc.name, tc.field, err)
+		}
+	}
+
+	// Invalid points cannot be compressed
+	bad := *proof
+	bad.PiA = []string{"1", "3", "1"}
+	if _, err := parser.MarshalCircomProofJSON(&bad, parser.WithCompressedPoints()); !errors.Is(err, parser.ErrInvalidPoint) {
+		t.Errorf("expected invalid point, got %v", err)
+	}
+
+	// Without options, values are encoded as they are
+	for name, tc := range map[string]struct {
+		marshal func(opts ...parser.MarshalOption) ([]byte, error)
+		want    string
+	}{
+		"nil proof": {func(opts ...parser.MarshalOption) ([]byte, error) {
+			return parser.MarshalCircomProofJSON(nil, opts...)
+		}, "null"},
+		"nil verification key": {func(opts ...parser.MarshalOption) ([]byte, error) {
+			return parser.MarshalCircomVerificationKeyJSON(nil, opts...)
+		}, "null"},
+		"nil public signals": {func(opts ...parser.MarshalOption) ([]byte, error) {
+			return parser.MarshalCircomPublicSignalsJSON(nil, opts...)
+		}, "null"},
+	} {
+		if data, err := tc.marshal(); err != nil || string(data) != tc.want {
+			t.Errorf("%s: expected %s, got %s: %v", name, tc.want, data, err)
+		}
+	}
+	if _, err := parser.MarshalCircomProofJSON(nil, parser.WithHexCoordinates()); !errors.Is(err, parser.ErrInternal) {
+		t.Errorf("expected internal error for a nil proof, got %v", err)
+	}
+	if _, err := parser.MarshalCircomVerificationKeyJSON(nil, parser.WithCompactJSON()); !errors.Is(err, parser.ErrInternal) {
+		t.Errorf("expected internal error for a nil verification key, got %v", err)
+	}
+	// The curve only matters to compressed points, and nil points stay null
+	unknown := parser.CircomVerificationKey{Protocol: "groth16", Curve: "secp256k1", NPublic: 1}
+	for _, opts := range [][]parser.MarshalOption{nil, {parser.WithHexCoordinates()}} {
+		data, err := parser.MarshalCircomVerificationKeyJSON(&unknown, opts...)
+		if err != nil {
+			t.Fatalf("failed to marshal a key with an unknown curve: %v", err)
+		}
+		if !bytes.Contains(data, []byte(`"IC": null`)) {
+			t.Errorf("expected a null IC, got %s", data)
+		}
+	}
+	unknown.VkAlpha1 = vk.VkAlpha1
+	if _, err := parser.MarshalCircomVerificationKeyJSON(&unknown, parser.WithCompressedPoints()); !errors.Is(err, parser.ErrWrongCurve) || errorPath(err) != "vk.curve" {
+		t.Errorf("expected wrong curve at vk.curve, got %v", err)
+	}
+
+	// Hex public signals must be in the canonical form output by
+	// WithHexCoordinates, and cannot be mixed with decimal ones
+	for _, tc := range []struct {
+		name    string
+		signals string
+		index   int
+	}{
+		{"leading zeros", `["0x0001"]`, 0},
+		{"mixed", `["0x1", "2"]`, 1},
+		{"mixed decimal first", `["2", "0x1"]`, 0},
+		{"uppercase", `["0xA"]`, 0},
+		{"empty", `["0x1", "0x"]`, 1},
+		{"signed", `["0x-1"]`, 0},
+	} {
+		_, err := parser.UnmarshalCircomPublicSignalsJSON([]byte(tc.signals))
+		if path := fmt.Sprintf("publicSignals[%d]", tc.index); !errors.Is(err, parser.ErrInvalidScalar) || errorPath(err) != path {
+			t.Errorf("%s: expected invalid scalar at %s, got %v", tc.name, path, err)
+		}
+	}
+	if got, err := parser.UnmarshalCircomPublicSignalsJSON([]byte(`["0x0", "0xa"]`)); err != nil || !reflect.DeepEqual(got, []string{"0", "10"}) {
+		t.Errorf("unexpected hex public signals %v: %v", got, err)
+	}
+}
@@ -0,0 +1,177 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+// errorPath returns the JSON path of a parser error, or "" if err is not a
+// *parser.Error.
+func errorPath(err error) string {
+	var e *parser.Error
+	if !errors.As(err, &e) {
+		return ""
+	}
+	return e.Path
+}
+
+func TestErrors(t *testing.T) {
+	load := func() (*parser.CircomVerificationKey, *parser.CircomProof, []string) {
+		vk, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(t, "circom_data/vkey.json"))
+		if err != nil {
+			t.Fatalf("failed to unmarshal verification key: %v", err)
+		}
+		proof, err := parser.UnmarshalCircomProofJSON(loadFile(t, "circom_data/proof.json"))
+		if err != nil {
+			t.Fatalf("failed to unmarshal proof: %v", err)
+		}
+		publicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadFile(t, "circom_data/public_signals.json"))
+		if err != nil {
+			t.Fatalf("failed to unmarshal public signals: %v", err)
+		}
+		return vk, proof, publicSignals
+	}
+
+	// Malformed JSON
+	for _, tc := range []struct {
+		data string
+		path string
+	}{
+		{`{"pi_a": [1, 2, 3]}`, "proof.pi_a[0]"},
+		{`{"pi_b": [["1", 2]]}`, "proof.pi_b[0][1]"},
+		{`{"pi_a": `, "proof"},
+	} {
+		_, err := parser.UnmarshalCircomProofJSON([]byte(tc.data))
+		if !errors.Is(err, parser.ErrMalformedJSON) || errorPath(err) != tc.path {
+			t.Errorf("%s: expected malformed JSON at %s, got %v", tc.data, tc.path, err)
+		}
+	}
+	if _, err := parser.UnmarshalCircomVerificationKeyJSON([]byte(`{"nPublic": "1"}`)); errorPath(err) != "vk.nPublic" {
+		t.Errorf("expected malformed JSON at vk.nPublic, got %v", err)
+	}
+	if _, err := parser.UnmarshalCircomPublicSignalsJSON([]byte(`[1]`)); !errors.Is(err, parser.ErrMalformedJSON) {
+		t.Errorf("expected malformed JSON, got %v", err)
+	}
+
+	// Conversion errors
+	for _, tc := range []struct {
+		name   string
+		tamper func(vk *parser.CircomVerificationKey, proof *parser.CircomProof, publicSignals *[]string)
+		kind   error
+		path   string
+	}{
+		{
+			"proof protocol",
+			func(_ *parser.CircomVerificationKey, proof *parser.CircomProof, _ *[]string) {
+				proof.Protocol = "plonk"
+			},
+			parser.ErrWrongProtocol, "proof.protocol",
+		},
+		{
+			"vk curve",
+			func(vk *parser.CircomVerificationKey, _ *parser.CircomProof, _ *[]string) { vk.Curve = "bls12377" },
+			parser.ErrWrongCurve, "vk.curve",
+		},
+		{
+			"proof curve",
+			func(_ *parser.CircomVerificationKey, proof *parser.CircomProof, _ *[]string) {
+				proof.Curve = "bls12381"
+			},
+			parser.ErrWrongCurve, "proof.curve",
+		},
+		{
+			"G2 coordinate",
+			func(_ *parser.CircomVerificationKey, proof *parser.CircomProof, _ *[]string) {
+				proof.PiB = [][]string{{"1", "x"}, {"1", "0"}, {"1", "0"}}
+			},
+			parser.ErrInvalidPoint, "proof.pi_b[0][1]",
+		},
+		{
+			"G2 not on the curve",
+			func(_ *parser.CircomVerificationKey, proof *parser.CircomProof, _ *[]string) {
+				proof.PiB = [][]string{{"1", "0"}, {"1", "0"}, {"1", "0"}}
+			},
+			parser.ErrInvalidPoint, "proof.pi_b",
+		},
+		{
+			"IC coordinate",
+			func(vk *parser.CircomVerificationKey, _ *parser.CircomProof, _ *[]string) {
+				vk.IC[1] = []string{"1", "-2", "1"}
+			},
+			parser.ErrInvalidPoint, "vk.IC[1][1]",
+		},
+		{
+			"vk nPublic",
+			func(vk *parser.CircomVerificationKey, _ *parser.CircomProof, _ *[]string) { vk.NPublic++ },
+			parser.ErrNPublicMismatch, "vk.nPublic",
+		},
+		{
+			"public signals count",
+			func(_ *parser.CircomVerificationKey, _ *parser.CircomProof, publicSignals *[]string) {
+				*publicSignals = append(*publicSignals, "1")
+			},
+			parser.ErrNPublicMismatch, "publicSignals",
+		},
+		{
+			"public signal",
+			func(_ *parser.CircomVerificationKey, _ *parser.CircomProof, publicSignals *[]string) {
+				(*publicSignals)[0] = "0x1"
+			},
+			parser.ErrInvalidScalar, "publicSignals[0]",
+		},
+	} {
+		vk, proof, publicSignals := load()
+		tc.tamper(vk, proof, &publicSignals)
+		_, err := parser.ConvertCircomToGnark(vk, proof, publicSignals)
+		if !errors.Is(err, tc.kind) || errorPath(err) != tc.path {
+			t.Errorf("%s: expected %v at %s, got %v", tc.name, tc.kind, tc.path, err)
+		}
+	}
+
+	// VerifyProof tells an invalid proof from malformed inputs
+	vk, proof, publicSignals := load()
+	gnarkProof, err := parser.ConvertCircomToGnark(vk, proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if ok, err := parser.VerifyProof(gnarkProof); !ok || err != nil {
+		t.Fatalf("proof should verify: %v", err)
+	}
+	gnarkProof.PublicInputs[0].Add(&gnarkProof.PublicInputs[0], new(fr.Element).SetOne())
+	if _, err := parser.VerifyProof(gnarkProof); !errors.Is(err, parser.ErrVerificationFailed) {
+		t.Errorf("expected verification failure, got %v", err)
+	}
+	gnarkProof.PublicInputs = nil
+	if _, err := parser.VerifyProof(gnarkProof); !errors.Is(err, parser.ErrNPublicMismatch) {
+		t.Errorf("expected public signals mismatch, got %v", err)
+	}
+	if _, err := parser.VerifyProof(&parser.GnarkProof{}); !errors.Is(err, parser.ErrInternal) {
+		t.Errorf("expected internal error, got %v", err)
+	}
+
+	// The commitments of gnark keys are not public signals
+	gnarkProof = commitGnarkProof(t, 1)
+	if ok, err := parser.VerifyProof(gnarkProof); !ok || err != nil {
+		t.Errorf("proof with a commitment should verify: %v", err)
+	}
+	gnarkProof.PublicInputs = gnarkProof.PublicInputs[:1]
+	if _, err := parser.VerifyProof(gnarkProof); !errors.Is(err, parser.ErrNPublicMismatch) {
+		t.Errorf("expected public signals mismatch, got %v", err)
+	}
+
+	// PLONK
+	fixture := newPlonkMultiplierFixture()
+	var tau fr.Element
+	if _, err := tau.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	plonkVk, polys := fixture.keys(tau)
+	plonkProof := fixture.prove(t, tau, plonkVk, polys)
+	plonkProof.Wxiw = plonkProof.Wxiw[:2]
+	if _, err := parser.VerifyPlonkProof(plonkVk, plonkProof, fixture.publicSignals()); !errors.Is(err, parser.ErrInvalidPoint) || errorPath(err) != "proof.Wxiw" {
+		t.Errorf("expected invalid point at proof.Wxiw, got %v", err)
+	}
+}
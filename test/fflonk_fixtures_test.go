@@ -0,0 +1,244 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+// fflonkRoots returns the roots of unity of a SnarkJS FFLONK verification
+// key: w3, w4, w8, and wr with wr³ = ω.
+func (f *plonkFixture) fflonkRoots() (w3, w4, w8, wr fr.Element) {
+	var exp big.Int
+	exp.Sub(fr.Modulus(), big.NewInt(1)).Div(&exp, big.NewInt(3))
+	w3.SetUint64(5)
+	w3.Exp(w3, &exp)
+	w8.Exp(f.omega, big.NewInt(int64(f.n/8))) // f.n >= 8
+	w4.Square(&w8)
+	// ω has order n = 2^k, so ω^((2n+1)/3) or ω^((n+1)/3) is its cube root
+	k := (f.n + 1) / 3
+	if (f.n+1)%3 != 0 {
+		k = (2*f.n + 1) / 3
+	}
+	wr.Exp(f.omega, big.NewInt(int64(k)))
+	return w3, w4, w8, wr
+}
+
+// fflonkKeys returns the SnarkJS FFLONK verification key of the circuit for
+// the secret τ, together with the polynomials of the circuit.
+func (f *plonkFixture) fflonkKeys(tau fr.Element) (*parser.CircomFflonkVerificationKey, *plonkCircuitPolys, poly) {
+	_, polys := f.keys(tau)
+	c0 := interleave(polys.ql, polys.qr, polys.qo, polys.qm, polys.qc, polys.s[0], polys.s[1], polys.s[2])
+	w3, w4, w8, wr := f.fflonkRoots()
+	x2 := g2Mul(tau)
+	return &parser.CircomFflonkVerificationKey{
+		Protocol: "fflonk",
+		Curve:    "bn128",
+		NPublic:  f.nPublic,
+		Power:    f.power,
+		K1:       f.k1.String(),
+		K2:       f.k2.String(),
+		W:        f.omega.String(),
+		W3:       w3.String(),
+		W4:       w4.String(),
+		W8:       w8.String(),
+		Wr:       wr.String(),
+		X2: [][]string{
+			{x2.X.A0.String(), x2.X.A1.String()},
+			{x2.Y.A0.String(), x2.Y.A1.String()},
+			{"1", "0"},
+		},
+		C0: g1ToStrings(g1Mul(c0.eval(tau))),
+	}, polys, c0
+}
+
+// fflonkProve computes an FFLONK proof following the rounds of
+// `snarkjs fflonk prove` (without blinding).
+func (f *plonkFixture) fflonkProve(t *testing.T, tau fr.Element, vk *parser.CircomFflonkVerificationKey, c *plonkCircuitPolys, c0 poly) *parser.CircomFflonkProof {
+	commit := func(p poly) curve.G1Affine { return g1Mul(p.eval(tau)) }
+	tr := &testKeccakTranscript{}
+	tr.addPoint(stringsToG1(t, vk.C0))
+	for i := 0; i < f.nPublic; i++ {
+		tr.addScalar(f.values[f.wires[0][i]])
+	}
+
+	// Round 1: C1 = A(X⁴) + X·B(X⁴) + X²·C(X⁴) + X³·T0(X⁴)
+	wires := f.wirePolys()
+	t0 := f.gateIdentity(c, wires).divZH(t, f.n)
+	c1 := interleave(wires[0], wires[1], wires[2], t0)
+	c1Commitment := commit(c1)
+	tr.addPoint(c1Commitment)
+	beta := tr.challenge()
+	tr.reset()
+	tr.addScalar(beta)
+	gamma := tr.challenge()
+
+	// Round 2: C2 = Z(X³) + X·T1(X³) + X²·T2(X³)
+	z := f.permutationPoly(beta, gamma)
+	l1 := f.interpolate(unitVector(f.n, 0))
+	t1 := z.sub(poly{fr.One()}).mul(l1).divZH(t, f.n)
+	t2 := f.permutationIdentity(c, wires, z, beta, gamma).divZH(t, f.n)
+	c2 := interleave(z, t1, t2)
+	c2Commitment := commit(c2)
+	tr.reset()
+	tr.addScalar(gamma)
+	tr.addPoint(c2Commitment)
+	xiSeed := tr.challenge()
+
+	// Opening points
+	w3, w4, w8, wr := f.fflonkRoots()
+	var h0, h1, h2, h3, xi, xiw fr.Element
+	h0.Exp(xiSeed, big.NewInt(3))
+	h1.Square(&h0)
+	h2.Exp(xiSeed, big.NewInt(8))
+	h3.Mul(&h2, &wr)
+	xi.Exp(xiSeed, big.NewInt(24))
+	xiw.Mul(&xi, &f.omega)
+	s0 := rootsTimes(h0, w8, 8)
+	s1 := rootsTimes(h1, w4, 4)
+	s2 := append(rootsTimes(h2, w3, 3), rootsTimes(h3, w3, 3)...)
+
+	// Round 3: evaluations
+	evals := []fr.Element{
+		c.ql.eval(xi), c.qr.eval(xi), c.qm.eval(xi), c.qo.eval(xi), c.qc.eval(xi),
+		c.s[0].eval(xi), c.s[1].eval(xi), c.s[2].eval(xi),
+		wires[0].eval(xi), wires[1].eval(xi), wires[2].eval(xi),
+		z.eval(xi), z.eval(xiw), t1.eval(xiw), t2.eval(xiw),
+	}
+	tr.reset()
+	tr.addScalar(xiSeed)
+	for _, e := range evals {
+		tr.addScalar(e)
+	}
+	alpha := tr.challenge()
+
+	// Round 4: W1 = (Z_S1·Z_S2·(C0-r0) + α·Z_S0·Z_S2·(C1-r1) + α²·Z_S0·Z_S1·(C2-r2)) / Z_T
+	r0 := interpolatePoints(s0, c0)
+	r1 := interpolatePoints(s1, c1)
+	r2 := interpolatePoints(s2, c2)
+	zs0, zs1, zs2 := vanishingPoly(s0), vanishingPoly(s1), vanishingPoly(s2)
+	var alpha2 fr.Element
+	alpha2.Square(&alpha)
+	num := zs1.mul(zs2).mul(c0.sub(r0)).
+		add(zs0.mul(zs2).mul(c1.sub(r1)).scale(alpha)).
+		add(zs0.mul(zs1).mul(c2.sub(r2)).scale(alpha2))
+	w1 := num.divExact(t, zs0.mul(zs1).mul(zs2))
+	w1Commitment := commit(w1)
+	tr.reset()
+	tr.addScalar(alpha)
+	tr.addPoint(w1Commitment)
+	y := tr.challenge()
+
+	// Round 5: W2 = L/(X-y), with
+	// L = C0-r0(y) + α·Z_S0(y)/Z_S1(y)·(C1-r1(y)) + α²·Z_S0(y)/Z_S2(y)·(C2-r2(y)) - Z_S0(y)·W1
+	zs0y, zs1y, zs2y := zs0.eval(y), zs1.eval(y), zs2.eval(y)
+	var q1, q2 fr.Element
+	q1.Div(&zs0y, &zs1y).Mul(&q1, &alpha)
+	q2.Div(&zs0y, &zs2y).Mul(&q2, &alpha2)
+	l := c0.sub(poly{r0.eval(y)}).
+		add(c1.sub(poly{r1.eval(y)}).scale(q1)).
+		add(c2.sub(poly{r2.eval(y)}).scale(q2)).
+		sub(w1.scale(zs0y))
+	w2 := l.divLinear(t, y)
+
+	// inv is only used by the Solidity verifier
+	var inv fr.Element
+	inv.Sub(&xi, &xiw).Inverse(&inv)
+
+	s := func(i int) string { return evals[i].String() }
+	return &parser.CircomFflonkProof{
+		Polynomials: parser.CircomFflonkPolynomials{
+			C1: g1ToStrings(c1Commitment),
+			C2: g1ToStrings(c2Commitment),
+			W1: g1ToStrings(w1Commitment),
+			W2: g1ToStrings(commit(w2)),
+		},
+		Evaluations: parser.CircomFflonkEvaluations{
+			Ql: s(0), Qr: s(1), Qm: s(2), Qo: s(3), Qc: s(4),
+			S1: s(5), S2: s(6), S3: s(7),
+			A: s(8), B: s(9), C: s(10),
+			Z: s(11), Zw: s(12), T1w: s(13), T2w: s(14),
+			Inv: inv.String(),
+		},
+		Protocol: "fflonk",
+		Curve:    "bn128",
+	}
+}
+
+// interleave returns Σ Xⁱ·pᵢ(Xᵏ), with k the number of polynomials.
+func interleave(polys ...poly) poly {
+	k, size := len(polys), 0
+	for _, p := range polys {
+		size = max(size, len(p))
+	}
+	res := make(poly, k*size)
+	for i, p := range polys {
+		for j := range p {
+			res[k*j+i] = p[j]
+		}
+	}
+	return res
+}
+
+// rootsTimes returns h·wⁱ for i = 0..n-1.
+func rootsTimes(h, w fr.Element, n int) []fr.Element {
+	res := make([]fr.Element, n)
+	res[0] = h
+	for i := 1; i < n; i++ {
+		res[i].Mul(&res[i-1], &w)
+	}
+	return res
+}
+
+// vanishingPoly returns Π (X - pointsᵢ).
+func vanishingPoly(points []fr.Element) poly {
+	res := poly{fr.One()}
+	for _, x := range points {
+		var minus fr.Element
+		minus.Neg(&x)
+		res = res.mul(poly{minus, fr.One()})
+	}
+	return res
+}
+
+// interpolatePoints returns the polynomial of degree < len(points) equal to p
+// on the points.
+func interpolatePoints(points []fr.Element, p poly) poly {
+	res := poly{}
+	for i := range points {
+		others := append(append([]fr.Element(nil), points[:i]...), points[i+1:]...)
+		basis := vanishingPoly(others)
+		var s fr.Element
+		v, d := p.eval(points[i]), basis.eval(points[i])
+		s.Div(&v, &d)
+		res = res.add(basis.scale(s))
+	}
+	return res
+}
+
+// divExact divides p by d, failing if the division is not exact.
+func (p poly) divExact(t *testing.T, d poly) poly {
+	rem := append(poly(nil), p...)
+	lead := d[len(d)-1]
+	var leadInv fr.Element
+	leadInv.Inverse(&lead)
+	q := make(poly, max(len(p)-len(d)+1, 0))
+	for i := len(rem) - 1; i >= len(d)-1; i-- {
+		var c, tmp fr.Element
+		c.Mul(&rem[i], &leadInv)
+		q[i-len(d)+1] = c
+		for j := range d {
+			tmp.Mul(&c, &d[j])
+			rem[i-len(d)+1+j].Sub(&rem[i-len(d)+1+j], &tmp)
+		}
+	}
+	for i := range rem {
+		if !rem[i].IsZero() {
+			t.Fatalf("polynomial division is not exact")
+		}
+	}
+	return q
+}
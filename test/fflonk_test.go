@@ -0,0 +1,119 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestVerifyFflonkProof(t *testing.T) {
+	fixture := newPlonkMultiplierFixture()
+	var tau fr.Element
+	if _, err := tau.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	vk, polys, c0 := fixture.fflonkKeys(tau)
+	proof := fixture.fflonkProve(t, tau, vk, polys, c0)
+	publicSignals := fixture.publicSignals()
+
+	ok, err := parser.VerifyFflonkProof(vk, proof, publicSignals)
+	if !ok || err != nil {
+		t.Fatalf("proof should verify: %v", err)
+	}
+
+	// JSON roundtrip
+	proofJSON, err := parser.MarshalCircomFflonkProofJSON(proof)
+	if err != nil {
+		t.Fatalf("failed to marshal proof: %v", err)
+	}
+	vkJSON, err := parser.MarshalCircomFflonkVerificationKeyJSON(vk)
+	if err != nil {
+		t.Fatalf("failed to marshal verification key: %v", err)
+	}
+	proof2, err := parser.UnmarshalCircomFflonkProofJSON(proofJSON)
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	vk2, err := parser.UnmarshalCircomFflonkVerificationKeyJSON(vkJSON)
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	if ok, err := parser.VerifyFflonkProof(vk2, proof2, publicSignals); !ok || err != nil {
+		t.Errorf("proof should verify after a JSON roundtrip: %v", err)
+	}
+
+	// Invalid public signals
+	if ok, _ := parser.VerifyFflonkProof(vk, proof, []string{"46", "2"}); ok {
+		t.Errorf("proof should not verify with wrong public signals")
+	}
+	if _, err := parser.VerifyFflonkProof(vk, proof, publicSignals[:1]); err == nil {
+		t.Errorf("expected error for a wrong number of public signals")
+	}
+
+	// Tampered evaluations
+	for name, tamper := range map[string]func(e *parser.CircomFflonkEvaluations){
+		"a":   func(e *parser.CircomFflonkEvaluations) { e.A = "4" },
+		"qm":  func(e *parser.CircomFflonkEvaluations) { e.Qm = "4" },
+		"zw":  func(e *parser.CircomFflonkEvaluations) { e.Zw = "4" },
+		"t2w": func(e *parser.CircomFflonkEvaluations) { e.T2w = "4" },
+	} {
+		tampered := *proof
+		tamper(&tampered.Evaluations)
+		if ok, _ := parser.VerifyFflonkProof(vk, &tampered, publicSignals); ok {
+			t.Errorf("proof should not verify with a tampered %s evaluation", name)
+		}
+	}
+
+	// Curve names are normalized as for Groth16
+	namedVk := *vk
+	namedVk.Curve = "bn254"
+	if ok, err := parser.VerifyFflonkProof(&namedVk, proof, publicSignals); !ok || err != nil {
+		t.Errorf("proof should verify with curve bn254: %v", err)
+	}
+
+	// Invalid verification keys
+	badVk := *vk
+	badVk.Curve = "bls12381"
+	if _, err := parser.VerifyFflonkProof(&badVk, proof, publicSignals); !errors.Is(err, parser.ErrWrongCurve) {
+		t.Errorf("expected wrong curve error, got %v", err)
+	}
+	badVk = *vk
+	badVk.Protocol = "plonk"
+	if _, err := parser.VerifyFflonkProof(&badVk, proof, publicSignals); err == nil {
+		t.Errorf("expected error for a plonk verification key")
+	}
+	badVk = *vk
+	badVk.Wr = vk.W
+	if _, err := parser.VerifyFflonkProof(&badVk, proof, publicSignals); err == nil {
+		t.Errorf("expected error for an invalid wr")
+	}
+	badVk = *vk
+	badVk.W8 = vk.W4
+	if _, err := parser.VerifyFflonkProof(&badVk, proof, publicSignals); err == nil {
+		t.Errorf("expected error for an invalid w8")
+	}
+}
+
+// TestVerifyFflonkProofSnarkjs verifies a proof of snarkjs fflonk prove.
+func TestVerifyFflonkProofSnarkjs(t *testing.T) {
+	vk, err := parser.UnmarshalCircomFflonkVerificationKeyJSON(loadSnarkjsFile(t, "fflonk_vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	proof, err := parser.UnmarshalCircomFflonkProofJSON(loadSnarkjsFile(t, "fflonk_proof.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	publicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadSnarkjsFile(t, "fflonk_public.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+	if ok, err := parser.VerifyFflonkProof(vk, proof, publicSignals); !ok || err != nil {
+		t.Fatalf("proof should verify: %v", err)
+	}
+	if ok, _ := parser.VerifyFflonkProof(vk, proof, []string{"46", "2"}); ok {
+		t.Errorf("proof should not verify with wrong public signals")
+	}
+}
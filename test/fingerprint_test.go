@@ -0,0 +1,94 @@
+package test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestFingerprint(t *testing.T) {
+	vk, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(t, "circom_data/vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	gnarkVk, err := parser.ConvertVerificationKey(vk)
+	if err != nil {
+		t.Fatalf("failed to convert verification key: %v", err)
+	}
+
+	// Variants of the same key: hex coordinates, compressed points and no
+	// vk_alphabeta_12
+	hexJSON, err := parser.MarshalCircomVerificationKeyJSON(vk, parser.WithHexCoordinates())
+	if err != nil {
+		t.Fatalf("failed to marshal verification key: %v", err)
+	}
+	hexVk, err := parser.UnmarshalCircomVerificationKeyJSON(hexJSON)
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	compressedJSON, err := parser.MarshalCircomVerificationKeyJSON(vk, parser.WithCompressedPoints())
+	if err != nil {
+		t.Fatalf("failed to marshal verification key: %v", err)
+	}
+	compressedVk, err := parser.UnmarshalCircomVerificationKeyJSON(compressedJSON)
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	noAlphabeta := *vk
+	noAlphabeta.VkAlphabeta12 = nil
+
+	// The expected values were computed independently from the coordinates of
+	// vkey.json. The Poseidon implementation is checked against the circomlib
+	// test vectors in the parser package.
+	for _, tc := range []struct {
+		hash parser.FingerprintHash
+		want string
+	}{
+		{parser.FingerprintSHA256, "2d22419a7bfc502965e1a4cb1f54779bc9bd3c0028f242f7ce2929b15381841e"},
+		{parser.FingerprintKeccak256, "c802c816aebaee397322d7ae63e5e9e2a46f2b06e103c331befbb84a337571c8"},
+		{parser.FingerprintPoseidon, "1626188cd12fae22dbaeb6d0724f79ca2676834a90ad92e3bef2f00c420db062"},
+	} {
+		fp, err := vk.Fingerprint(tc.hash)
+		if err != nil {
+			t.Fatalf("%s: failed to compute fingerprint: %v", tc.hash, err)
+		}
+		if hex.EncodeToString(fp[:]) != tc.want {
+			t.Errorf("%s: expected %s, got %x", tc.hash, tc.want, fp)
+		}
+		for _, variant := range []*parser.CircomVerificationKey{hexVk, compressedVk, &noAlphabeta} {
+			if got, err := variant.Fingerprint(tc.hash); err != nil || got != fp {
+				t.Errorf("%s: variant fingerprint %x differs from %x: %v", tc.hash, got, fp, err)
+			}
+		}
+		if got, err := parser.VerifyingKeyFingerprint(gnarkVk, tc.hash); err != nil || got != fp {
+			t.Errorf("%s: gnark fingerprint %x differs from %x: %v", tc.hash, got, fp, err)
+		}
+	}
+	sha, _ := vk.Fingerprint(parser.FingerprintSHA256)
+	keccak, _ := vk.Fingerprint(parser.FingerprintKeccak256)
+	if sha == keccak {
+		t.Errorf("SHA-256 and Keccak-256 fingerprints should differ")
+	}
+
+	// A different key has a different fingerprint
+	other := *vk
+	other.IC = [][]string{vk.IC[1], vk.IC[0]}
+	if fp, err := other.Fingerprint(parser.FingerprintPoseidon); err != nil || hex.EncodeToString(fp[:]) == "1626188cd12fae22dbaeb6d0724f79ca2676834a90ad92e3bef2f00c420db062" {
+		t.Errorf("a different key should have a different fingerprint: %v", err)
+	}
+
+	// Invalid keys and hashes
+	bad := *vk
+	bad.VkAlpha1 = []string{"1", "3", "1"}
+	if _, err := bad.Fingerprint(parser.FingerprintSHA256); !errors.Is(err, parser.ErrInvalidPoint) || errorPath(err) != "vk.vk_alpha_1" {
+		t.Errorf("expected invalid point at vk.vk_alpha_1, got %v", err)
+	}
+	if _, err := vk.Fingerprint(parser.FingerprintHash(42)); err == nil {
+		t.Errorf("expected error for an unsupported hash")
+	}
+	if _, err := parser.VerifyingKeyFingerprint(commitGnarkProof(t, 1).VerifyingKey, parser.FingerprintSHA256); err == nil {
+		t.Errorf("expected error for a key with commitments")
+	}
+}
@@ -0,0 +1,182 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestLoadArtifact(t *testing.T) {
+	fsys := os.DirFS("circom_data")
+	proof, err := parser.LoadArtifactFS(fsys, "proof.json")
+	if err != nil {
+		t.Fatalf("failed to load proof: %v", err)
+	}
+	vk, err := parser.LoadArtifactFS(fsys, "vkey.json")
+	if err != nil {
+		t.Fatalf("failed to load verification key: %v", err)
+	}
+	public, err := parser.LoadArtifactFS(fsys, "public_signals.json")
+	if err != nil {
+		t.Fatalf("failed to load public signals: %v", err)
+	}
+	if proof.Kind != parser.ArtifactProof || vk.Kind != parser.ArtifactVerificationKey || public.Kind != parser.ArtifactPublicSignals {
+		t.Fatalf("unexpected kinds %s, %s and %s", proof.Kind, vk.Kind, public.Kind)
+	}
+	if vk.Protocol != "groth16" || vk.Curve != "bn128" {
+		t.Errorf("unexpected protocol %q and curve %q", vk.Protocol, vk.Curve)
+	}
+	gnarkProof, err := parser.ConvertCircomToGnark(vk.VerificationKey, proof.Proof, public.PublicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if ok, err := parser.VerifyProof(gnarkProof); !ok || err != nil {
+		t.Errorf("proof should verify: %v", err)
+	}
+
+	// Numbers and hex strings are normalized to decimal strings, and the
+	// protocol is optional
+	a, err := parser.LoadArtifact(bytes.NewReader([]byte(`{
+		"pi_a": [1, "0x2", "1"],
+		"pi_b": [["0", "0"], ["1", "0"], ["0", "0"]],
+		"pi_c": ["0x0", 1, 0]
+	}`)))
+	if err != nil {
+		t.Fatalf("failed to load proof: %v", err)
+	}
+	if a.Kind != parser.ArtifactProof || !reflect.DeepEqual(a.Proof.PiA, []string{"1", "2", "1"}) ||
+		!reflect.DeepEqual(a.Proof.PiC, []string{"0", "1", "0"}) {
+		t.Errorf("unexpected proof %+v", a.Proof)
+	}
+	// Only the 0x prefix is hex, as everywhere else
+	a, err = parser.ParseArtifact([]byte(`{"pi_a": ["0X1", "2", "1"], "pi_b": [["0", "0"], ["1", "0"], ["0", "0"]], "pi_c": ["0", "1", "0"]}`))
+	if err != nil {
+		t.Fatalf("failed to load proof: %v", err)
+	}
+	if _, err := parser.ConvertProof(a.Proof); !errors.Is(err, parser.ErrInvalidPoint) {
+		t.Errorf("expected invalid point for a 0X prefix, got %v", err)
+	}
+
+	// Public signals follow the rules of UnmarshalCircomPublicSignalsJSON:
+	// hex signals must all be canonical
+	for _, data := range []string{`[1, 42, "3"]`, `["0x1", "0x2a", "0x3"]`} {
+		a, err = parser.ParseArtifact([]byte(data))
+		if err != nil {
+			t.Fatalf("failed to load public signals %s: %v", data, err)
+		}
+		if !reflect.DeepEqual(a.PublicSignals, []string{"1", "42", "3"}) {
+			t.Errorf("unexpected public signals %v", a.PublicSignals)
+		}
+	}
+	for _, data := range []string{`["0x01", "5"]`, `["0x0001", "0X0a"]`, `["5", "0x5"]`, `[1, "0x2a"]`, `["0x2A"]`} {
+		if _, err := parser.ParseArtifact([]byte(data)); !errors.Is(err, parser.ErrInvalidScalar) {
+			t.Errorf("expected invalid scalar for public signals %s, got %v", data, err)
+		}
+	}
+	a, err = parser.ParseArtifact([]byte(`{"protocol": "groth16", "nPublic": "2", "vk_alpha_1": [1, 2, 1]}`))
+	if err != nil {
+		t.Fatalf("failed to load verification key: %v", err)
+	}
+	if a.VerificationKey.NPublic != 2 || a.VerificationKey.VkAlpha1[0] != "1" {
+		t.Errorf("unexpected verification key %+v", a.VerificationKey)
+	}
+	for _, data := range []string{
+		`{"protocol": "groth16", "nPublic": "two", "vk_alpha_1": [1, 2, 1]}`,
+		`{"protocol": "groth16", "nPublic": "-1", "vk_alpha_1": [1, 2, 1]}`,
+		`{"protocol": "groth16", "nPublic": "", "vk_alpha_1": [1, 2, 1]}`,
+	} {
+		if _, err := parser.ParseArtifact([]byte(data)); !errors.Is(err, parser.ErrMalformedJSON) || errorPath(err) != "artifact.nPublic" {
+			t.Errorf("expected malformed JSON at artifact.nPublic for %s, got %v", data, err)
+		}
+	}
+	if _, err := parser.ParseArtifact([]byte(`{"protocol": "plonk", "power": "1e3", "Qm": [1, 2, 1]}`)); !errors.Is(err, parser.ErrMalformedJSON) || errorPath(err) != "artifact.power" {
+		t.Errorf("expected malformed JSON at artifact.power, got %v", err)
+	}
+
+	// PLONK and FFLONK
+	fixture := newPlonkMultiplierFixture()
+	var tau fr.Element
+	if _, err := tau.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	plonkVk, polys := fixture.keys(tau)
+	plonkProof := fixture.prove(t, tau, plonkVk, polys)
+	fflonkVk, fflonkPolys, c0 := fixture.fflonkKeys(tau)
+	fflonkProof := fixture.fflonkProve(t, tau, fflonkVk, fflonkPolys, c0)
+	for _, tc := range []struct {
+		marshal func() ([]byte, error)
+		kind    parser.ArtifactKind
+	}{
+		{func() ([]byte, error) { return parser.MarshalCircomPlonkProofJSON(plonkProof) }, parser.ArtifactPlonkProof},
+		{func() ([]byte, error) { return parser.MarshalCircomPlonkVerificationKeyJSON(plonkVk) }, parser.ArtifactPlonkVerificationKey},
+		{func() ([]byte, error) { return parser.MarshalCircomFflonkProofJSON(fflonkProof) }, parser.ArtifactFflonkProof},
+		{func() ([]byte, error) { return parser.MarshalCircomFflonkVerificationKeyJSON(fflonkVk) }, parser.ArtifactFflonkVerificationKey},
+	} {
+		data, err := tc.marshal()
+		if err != nil {
+			t.Fatalf("failed to marshal %s: %v", tc.kind, err)
+		}
+		if a, err := parser.ParseArtifact(data); err != nil || a.Kind != tc.kind {
+			t.Errorf("expected %s, got %+v: %v", tc.kind, a, err)
+		}
+	}
+	data, err := parser.MarshalCircomPlonkVerificationKeyJSON(plonkVk)
+	if err != nil {
+		t.Fatalf("failed to marshal verification key: %v", err)
+	}
+	if a, err = parser.ParseArtifact(data); err != nil {
+		t.Fatalf("failed to load verification key: %v", err)
+	}
+	if ok, err := parser.VerifyPlonkProof(a.PlonkVerificationKey, plonkProof, fixture.publicSignals()); !ok || err != nil {
+		t.Errorf("proof should verify with the loaded key: %v", err)
+	}
+
+	// Binary files
+	circom := newMultiplierFixture()
+	wtns, err := parser.MarshalCircomWitness(&parser.CircomWitness{Values: circom.witness})
+	if err != nil {
+		t.Fatalf("failed to marshal witness: %v", err)
+	}
+	for _, tc := range []struct {
+		data []byte
+		kind parser.ArtifactKind
+	}{
+		{circom.zkey(newToxicWaste(t)), parser.ArtifactZKey},
+		{circom.r1cs(), parser.ArtifactR1CS},
+		{wtns, parser.ArtifactWitness},
+	} {
+		a, err := parser.LoadArtifact(bytes.NewReader(tc.data))
+		if err != nil || a.Kind != tc.kind {
+			t.Errorf("expected %s, got %+v: %v", tc.kind, a, err)
+			continue
+		}
+		if a.ZKey == nil && a.R1CS == nil && a.Witness == nil {
+			t.Errorf("%s was not parsed", tc.kind)
+		}
+	}
+
+	// Invalid artifacts
+	for _, tc := range []struct {
+		data string
+		kind error
+	}{
+		{`{"foo": 1}`, parser.ErrUnknownArtifact},
+		{`{"protocol": "plonk", "pi_a": []}`, parser.ErrUnknownArtifact},
+		{`"proof"`, parser.ErrUnknownArtifact},
+		{`[1.5]`, parser.ErrMalformedJSON},
+		{`{"pi_a": `, parser.ErrMalformedJSON},
+		{`{"pi_a": 1}`, parser.ErrMalformedJSON},
+	} {
+		if _, err := parser.ParseArtifact([]byte(tc.data)); !errors.Is(err, tc.kind) {
+			t.Errorf("%s: expected %v, got %v", tc.data, tc.kind, err)
+		}
+	}
+	if _, err := parser.ParseArtifact([]byte("zkey\x01\x00")); err == nil {
+		t.Errorf("expected error for a truncated zkey")
+	}
+}
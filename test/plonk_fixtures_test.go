@@ -0,0 +1,527 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/vocdoni/circom2gnark/parser"
+	"golang.org/x/crypto/sha3"
+)
+
+// plonkFixture is a PLONK circuit in the SnarkJS layout, with gates
+// qL·a + qR·b + qO·c + qM·a·b + qC = 0 and the public signals in the first
+// gates. It mimics the multiplier of circomFixture, with out and x public:
+//
+//	t = a·b, s = x + 1, out = t·s
+type plonkFixture struct {
+	power  int
+	n      int
+	omega  fr.Element
+	k1, k2 fr.Element
+
+	qm, ql, qr, qo, qc []fr.Element // selector evaluations on the domain
+	wires              [3][]int     // variable of each gate in the A, B and C columns
+	values             []fr.Element // variable values; variable 0 is an unused zero
+	nPublic            int
+}
+
+func newPlonkMultiplierFixture() *plonkFixture {
+	const power = 3
+	n := 1 << power
+	f := &plonkFixture{power: power, n: n, nPublic: 2}
+	f.omega, _ = fft.Generator(uint64(n))
+	f.k1.SetUint64(2)
+	f.k2.SetUint64(3)
+	for _, s := range []*[]fr.Element{&f.qm, &f.ql, &f.qr, &f.qo, &f.qc} {
+		*s = make([]fr.Element, n)
+	}
+	for i := range f.wires {
+		f.wires[i] = make([]int, n)
+	}
+
+	// variables: 0:zero 1:out 2:x 3:a 4:b 5:t 6:s
+	x, a, b := int64(2), int64(3), int64(5)
+	for _, v := range []int64{0, a * b * (x + 1), x, a, b, a * b, x + 1} {
+		var e fr.Element
+		e.SetInt64(v)
+		f.values = append(f.values, e)
+	}
+	one, minusOne := fr.One(), fr.One()
+	minusOne.Neg(&minusOne)
+	gate := func(i, a, b, c int, qm, ql, qo, qc fr.Element) {
+		f.wires[0][i], f.wires[1][i], f.wires[2][i] = a, b, c
+		f.qm[i], f.ql[i], f.qo[i], f.qc[i] = qm, ql, qo, qc
+	}
+	var zero fr.Element
+	gate(0, 1, 0, 0, zero, one, zero, zero)     // public out
+	gate(1, 2, 0, 0, zero, one, zero, zero)     // public x
+	gate(2, 3, 4, 5, one, zero, minusOne, zero) // a·b = t
+	gate(3, 2, 0, 6, zero, one, minusOne, one)  // x + 1 = s
+	gate(4, 5, 6, 1, one, zero, minusOne, zero) // t·s = out
+	return f
+}
+
+func (f *plonkFixture) publicSignals() []string {
+	signals := make([]string, f.nPublic)
+	for i := range signals {
+		signals[i] = f.values[f.wires[0][i]].String()
+	}
+	return signals
+}
+
+// label returns the identity permutation label of a position: ωⁱ, k₁·ωⁱ or
+// k₂·ωⁱ for gate i in the column A, B or C.
+func (f *plonkFixture) label(col, row int) fr.Element {
+	var l fr.Element
+	l.Exp(f.omega, big.NewInt(int64(row)))
+	switch col {
+	case 1:
+		l.Mul(&l, &f.k1)
+	case 2:
+		l.Mul(&l, &f.k2)
+	}
+	return l
+}
+
+// sigma returns the evaluations of S1, S2 and S3: each position is mapped to
+// the next position holding the same variable.
+func (f *plonkFixture) sigma() [3][]fr.Element {
+	positions := map[int][][2]int{}
+	for col := 0; col < 3; col++ {
+		for row := 0; row < f.n; row++ {
+			v := f.wires[col][row]
+			positions[v] = append(positions[v], [2]int{col, row})
+		}
+	}
+	var s [3][]fr.Element
+	for col := range s {
+		s[col] = make([]fr.Element, f.n)
+	}
+	for _, cycle := range positions {
+		for i, p := range cycle {
+			next := cycle[(i+1)%len(cycle)]
+			s[p[0]][p[1]] = f.label(next[0], next[1])
+		}
+	}
+	return s
+}
+
+// keys returns the SnarkJS PLONK verification key of the circuit for the
+// secret τ, together with the polynomials of the circuit.
+func (f *plonkFixture) keys(tau fr.Element) (*parser.CircomPlonkVerificationKey, *plonkCircuitPolys) {
+	polys := &plonkCircuitPolys{
+		qm: f.interpolate(f.qm),
+		ql: f.interpolate(f.ql),
+		qr: f.interpolate(f.qr),
+		qo: f.interpolate(f.qo),
+		qc: f.interpolate(f.qc),
+	}
+	sigma := f.sigma()
+	for i := range sigma {
+		polys.s[i] = f.interpolate(sigma[i])
+	}
+	commit := func(p poly) []string { return g1ToStrings(g1Mul(p.eval(tau))) }
+	x2 := g2Mul(tau)
+	return &parser.CircomPlonkVerificationKey{
+		Protocol: "plonk",
+		Curve:    "bn128",
+		NPublic:  f.nPublic,
+		Power:    f.power,
+		K1:       f.k1.String(),
+		K2:       f.k2.String(),
+		Qm:       commit(polys.qm),
+		Ql:       commit(polys.ql),
+		Qr:       commit(polys.qr),
+		Qo:       commit(polys.qo),
+		Qc:       commit(polys.qc),
+		S1:       commit(polys.s[0]),
+		S2:       commit(polys.s[1]),
+		S3:       commit(polys.s[2]),
+		X2: [][]string{
+			{x2.X.A0.String(), x2.X.A1.String()},
+			{x2.Y.A0.String(), x2.Y.A1.String()},
+			{"1", "0"},
+		},
+		W: f.omega.String(),
+	}, polys
+}
+
+type plonkCircuitPolys struct {
+	qm, ql, qr, qo, qc poly
+	s                  [3]poly
+}
+
+// prove computes a PLONK proof following the rounds of `snarkjs plonk prove`
+// (without blinding, which does not change the verification).
+func (f *plonkFixture) prove(t *testing.T, tau fr.Element, vk *parser.CircomPlonkVerificationKey, c *plonkCircuitPolys) *parser.CircomPlonkProof {
+	commit := func(p poly) curve.G1Affine { return g1Mul(p.eval(tau)) }
+	tr := &testKeccakTranscript{}
+	for _, p := range [][]string{vk.Qm, vk.Ql, vk.Qr, vk.Qo, vk.Qc, vk.S1, vk.S2, vk.S3} {
+		tr.addPoint(stringsToG1(t, p))
+	}
+	publicInputs := make([]fr.Element, f.nPublic)
+	for i := range publicInputs {
+		publicInputs[i] = f.values[f.wires[0][i]]
+		tr.addScalar(publicInputs[i])
+	}
+
+	// Round 1: wire polynomials
+	wires := f.wirePolys()
+	var commitments [3]curve.G1Affine
+	for col := range wires {
+		commitments[col] = commit(wires[col])
+		tr.addPoint(commitments[col])
+	}
+	beta := tr.challenge()
+	tr.reset()
+	tr.addScalar(beta)
+	gamma := tr.challenge()
+
+	// Round 2: permutation polynomial
+	z := f.permutationPoly(beta, gamma)
+	zCommitment := commit(z)
+	tr.reset()
+	tr.addScalar(beta)
+	tr.addScalar(gamma)
+	tr.addPoint(zCommitment)
+	alpha := tr.challenge()
+
+	// Round 3: quotient polynomial
+	l1 := f.interpolate(unitVector(f.n, 0))
+	pi := f.publicPoly()
+	a, b, cc := wires[0], wires[1], wires[2]
+	gate := f.gateIdentity(c, wires)
+	perm := f.permutationIdentity(c, wires, z, beta, gamma)
+	var alpha2 fr.Element
+	alpha2.Square(&alpha)
+	num := gate.add(perm.scale(alpha)).add(z.sub(poly{fr.One()}).mul(l1).scale(alpha2))
+	quotient := num.divZH(t, f.n)
+	parts := [3]poly{quotient.slice(0, f.n), quotient.slice(f.n, 2*f.n), quotient.slice(2*f.n, 3*f.n)}
+	tr.reset()
+	tr.addScalar(alpha)
+	var tCommitments [3]curve.G1Affine
+	for i := range parts {
+		tCommitments[i] = commit(parts[i])
+		tr.addPoint(tCommitments[i])
+	}
+	xi := tr.challenge()
+
+	// Round 4: evaluations
+	var xiw fr.Element
+	xiw.Mul(&xi, &f.omega)
+	evalA, evalB, evalC := a.eval(xi), b.eval(xi), cc.eval(xi)
+	evalS1, evalS2 := c.s[0].eval(xi), c.s[1].eval(xi)
+	evalZw := z.eval(xiw)
+	tr.reset()
+	tr.addScalar(xi)
+	for _, e := range []fr.Element{evalA, evalB, evalC, evalS1, evalS2, evalZw} {
+		tr.addScalar(e)
+	}
+	v1 := tr.challenge()
+
+	// Round 5: linearisation polynomial and openings
+	var xin, zh, one fr.Element
+	one.SetOne()
+	xin.Exp(xi, big.NewInt(int64(f.n)))
+	zh.Sub(&xin, &one)
+	var ab, e3a, e3b, e3c, r0, tmp fr.Element
+	ab.Mul(&evalA, &evalB)
+	e3a.Mul(&beta, &evalS1).Add(&e3a, &evalA).Add(&e3a, &gamma)
+	e3b.Mul(&beta, &evalS2).Add(&e3b, &evalB).Add(&e3b, &gamma)
+	e3c.Add(&evalC, &gamma)
+	l1xi, piXi := l1.eval(xi), pi.eval(xi)
+	r0.Mul(&e3a, &e3b).Mul(&r0, &e3c).Mul(&r0, &evalZw).Mul(&r0, &alpha)
+	tmp.Mul(&l1xi, &alpha2)
+	r0.Add(&r0, &tmp).Sub(&piXi, &r0)
+
+	var betaxi, d2, d3, d2b, d2c fr.Element
+	betaxi.Mul(&beta, &xi)
+	d2.Add(&evalA, &betaxi).Add(&d2, &gamma)
+	d2b.Mul(&betaxi, &f.k1).Add(&d2b, &evalB).Add(&d2b, &gamma)
+	d2c.Mul(&betaxi, &f.k2).Add(&d2c, &evalC).Add(&d2c, &gamma)
+	d2.Mul(&d2, &d2b).Mul(&d2, &d2c).Mul(&d2, &alpha).Add(&d2, &tmp)
+	d3.Mul(&e3a, &e3b).Mul(&d3, &alpha).Mul(&d3, &beta).Mul(&d3, &evalZw)
+	var xin2 fr.Element
+	xin2.Square(&xin)
+	r := c.qm.scale(ab).add(c.ql.scale(evalA)).add(c.qr.scale(evalB)).add(c.qo.scale(evalC)).add(c.qc).
+		add(z.scale(d2)).sub(c.s[2].scale(d3)).
+		sub(parts[0].add(parts[1].scale(xin)).add(parts[2].scale(xin2)).scale(zh)).
+		add(poly{r0})
+
+	vPow := v1
+	wxi := r
+	for _, p := range []struct {
+		p    poly
+		eval fr.Element
+	}{{a, evalA}, {b, evalB}, {cc, evalC}, {c.s[0], evalS1}, {c.s[1], evalS2}} {
+		wxi = wxi.add(p.p.sub(poly{p.eval}).scale(vPow))
+		vPow.Mul(&vPow, &v1)
+	}
+	wxiCommitment := commit(wxi.divLinear(t, xi))
+	wxiwCommitment := commit(z.sub(poly{evalZw}).divLinear(t, xiw))
+
+	return &parser.CircomPlonkProof{
+		A:        g1ToStrings(commitments[0]),
+		B:        g1ToStrings(commitments[1]),
+		C:        g1ToStrings(commitments[2]),
+		Z:        g1ToStrings(zCommitment),
+		T1:       g1ToStrings(tCommitments[0]),
+		T2:       g1ToStrings(tCommitments[1]),
+		T3:       g1ToStrings(tCommitments[2]),
+		Wxi:      g1ToStrings(wxiCommitment),
+		Wxiw:     g1ToStrings(wxiwCommitment),
+		EvalA:    evalA.String(),
+		EvalB:    evalB.String(),
+		EvalC:    evalC.String(),
+		EvalS1:   evalS1.String(),
+		EvalS2:   evalS2.String(),
+		EvalZw:   evalZw.String(),
+		Protocol: "plonk",
+		Curve:    "bn128",
+	}
+}
+
+// wirePolys returns the polynomials of the A, B and C columns.
+func (f *plonkFixture) wirePolys() [3]poly {
+	var wires [3]poly
+	for col := range wires {
+		evals := make([]fr.Element, f.n)
+		for row := range evals {
+			evals[row] = f.values[f.wires[col][row]]
+		}
+		wires[col] = f.interpolate(evals)
+	}
+	return wires
+}
+
+// permutationPoly returns the grand product polynomial z of the copy
+// constraints.
+func (f *plonkFixture) permutationPoly(beta, gamma fr.Element) poly {
+	sigma := f.sigma()
+	zEvals := make([]fr.Element, f.n)
+	zEvals[0].SetOne()
+	for row := 0; row < f.n-1; row++ {
+		num, den := fr.One(), fr.One()
+		for col := 0; col < 3; col++ {
+			var tmp fr.Element
+			id := f.label(col, row)
+			tmp.Mul(&beta, &id).Add(&tmp, &f.values[f.wires[col][row]]).Add(&tmp, &gamma)
+			num.Mul(&num, &tmp)
+			tmp.Mul(&beta, &sigma[col][row]).Add(&tmp, &f.values[f.wires[col][row]]).Add(&tmp, &gamma)
+			den.Mul(&den, &tmp)
+		}
+		den.Inverse(&den)
+		zEvals[row+1].Mul(&zEvals[row], &num).Mul(&zEvals[row+1], &den)
+	}
+	return f.interpolate(zEvals)
+}
+
+// publicPoly returns PI(X) = -Σ wᵢ·Lᵢ(X).
+func (f *plonkFixture) publicPoly() poly {
+	pi := poly{}
+	for i := 0; i < f.nPublic; i++ {
+		var minus fr.Element
+		minus.Neg(&f.values[f.wires[0][i]])
+		pi = pi.add(f.interpolate(unitVector(f.n, i)).scale(minus))
+	}
+	return pi
+}
+
+// gateIdentity returns qM·a·b + qL·a + qR·b + qO·c + qC + PI, which vanishes
+// on the domain.
+func (f *plonkFixture) gateIdentity(c *plonkCircuitPolys, wires [3]poly) poly {
+	a, b, cc := wires[0], wires[1], wires[2]
+	return c.qm.mul(a).mul(b).add(c.ql.mul(a)).add(c.qr.mul(b)).add(c.qo.mul(cc)).add(c.qc).add(f.publicPoly())
+}
+
+// permutationIdentity returns
+// (a+βX+γ)(b+βk₁X+γ)(c+βk₂X+γ)·z(X) - (a+βS1+γ)(b+βS2+γ)(c+βS3+γ)·z(ωX),
+// which vanishes on the domain.
+func (f *plonkFixture) permutationIdentity(c *plonkCircuitPolys, wires [3]poly, z poly, beta, gamma fr.Element) poly {
+	a, b, cc := wires[0], wires[1], wires[2]
+	linear := func(p poly, k fr.Element) poly { // p + β·k·X + γ
+		var bk fr.Element
+		bk.Mul(&beta, &k)
+		return p.add(poly{gamma, bk})
+	}
+	perm1 := linear(a, fr.One()).mul(linear(b, f.k1)).mul(linear(cc, f.k2)).mul(z)
+	perm2 := a.add(c.s[0].scale(beta)).add(poly{gamma}).
+		mul(b.add(c.s[1].scale(beta)).add(poly{gamma})).
+		mul(cc.add(c.s[2].scale(beta)).add(poly{gamma})).
+		mul(z.shift(f.omega))
+	return perm1.sub(perm2)
+}
+
+// interpolate returns the coefficients of the polynomial taking the given
+// values on the domain.
+func (f *plonkFixture) interpolate(evals []fr.Element) poly {
+	coeffs := make(poly, f.n)
+	var omegaInv, nInv fr.Element
+	omegaInv.Inverse(&f.omega)
+	nInv.SetUint64(uint64(f.n)).Inverse(&nInv)
+	for j := range coeffs {
+		var w, wj, tmp fr.Element
+		w.Exp(omegaInv, big.NewInt(int64(j)))
+		wj.SetOne()
+		for i := range evals {
+			tmp.Mul(&evals[i], &wj)
+			coeffs[j].Add(&coeffs[j], &tmp)
+			wj.Mul(&wj, &w)
+		}
+		coeffs[j].Mul(&coeffs[j], &nInv)
+	}
+	return coeffs
+}
+
+func unitVector(n, i int) []fr.Element {
+	v := make([]fr.Element, n)
+	v[i].SetOne()
+	return v
+}
+
+// poly is a polynomial in coefficient form, lowest degree first.
+type poly []fr.Element
+
+func (p poly) eval(x fr.Element) fr.Element {
+	var res fr.Element
+	for i := len(p) - 1; i >= 0; i-- {
+		res.Mul(&res, &x).Add(&res, &p[i])
+	}
+	return res
+}
+
+func (p poly) add(q poly) poly {
+	res := make(poly, max(len(p), len(q)))
+	copy(res, p)
+	for i := range q {
+		res[i].Add(&res[i], &q[i])
+	}
+	return res
+}
+
+func (p poly) sub(q poly) poly {
+	res := make(poly, max(len(p), len(q)))
+	copy(res, p)
+	for i := range q {
+		res[i].Sub(&res[i], &q[i])
+	}
+	return res
+}
+
+func (p poly) scale(s fr.Element) poly {
+	res := make(poly, len(p))
+	for i := range p {
+		res[i].Mul(&p[i], &s)
+	}
+	return res
+}
+
+func (p poly) mul(q poly) poly {
+	res := make(poly, len(p)+len(q)-1)
+	var tmp fr.Element
+	for i := range p {
+		for j := range q {
+			tmp.Mul(&p[i], &q[j])
+			res[i+j].Add(&res[i+j], &tmp)
+		}
+	}
+	return res
+}
+
+// shift returns p(s·X).
+func (p poly) shift(s fr.Element) poly {
+	res := make(poly, len(p))
+	si := fr.One()
+	for i := range p {
+		res[i].Mul(&p[i], &si)
+		si.Mul(&si, &s)
+	}
+	return res
+}
+
+// slice returns the coefficients of degree from..to-1 as a polynomial.
+func (p poly) slice(from, to int) poly {
+	res := make(poly, to-from)
+	if from < len(p) {
+		copy(res, p[from:min(to, len(p))])
+	}
+	return res
+}
+
+// divZH divides p by Xⁿ-1, failing if the division is not exact.
+func (p poly) divZH(t *testing.T, n int) poly {
+	rem := append(poly(nil), p...)
+	q := make(poly, max(len(p)-n, 0))
+	for i := len(rem) - 1; i >= n; i-- {
+		q[i-n] = rem[i]
+		rem[i-n].Add(&rem[i-n], &rem[i])
+		rem[i].SetZero()
+	}
+	for i := range rem {
+		if !rem[i].IsZero() {
+			t.Fatalf("polynomial is not divisible by the vanishing polynomial")
+		}
+	}
+	return q
+}
+
+// divLinear divides p by X-z, failing if the division is not exact.
+func (p poly) divLinear(t *testing.T, z fr.Element) poly {
+	q := make(poly, len(p)-1)
+	var carry fr.Element
+	for i := len(p) - 1; i > 0; i-- {
+		carry.Mul(&carry, &z).Add(&carry, &p[i])
+		q[i-1] = carry
+	}
+	carry.Mul(&carry, &z).Add(&carry, &p[0])
+	if !carry.IsZero() {
+		t.Fatalf("polynomial does not vanish at the opening point")
+	}
+	return q
+}
+
+func g1ToStrings(p curve.G1Affine) []string {
+	return []string{p.X.String(), p.Y.String(), "1"}
+}
+
+func stringsToG1(t *testing.T, s []string) curve.G1Affine {
+	var p curve.G1Affine
+	if _, err := p.X.SetString(s[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Y.SetString(s[1]); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// testKeccakTranscript mirrors the Keccak256Transcript of SnarkJS.
+type testKeccakTranscript struct {
+	data []byte
+}
+
+func (tr *testKeccakTranscript) reset() { tr.data = nil }
+
+func (tr *testKeccakTranscript) addPoint(p curve.G1Affine) {
+	x, y := p.X.Bytes(), p.Y.Bytes()
+	if p.IsInfinity() { // Qr of the fixture
+		x[0] = 0x40
+	}
+	tr.data = append(append(tr.data, x[:]...), y[:]...)
+}
+
+func (tr *testKeccakTranscript) addScalar(s fr.Element) {
+	b := s.Bytes()
+	tr.data = append(tr.data, b[:]...)
+}
+
+func (tr *testKeccakTranscript) challenge() fr.Element {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(tr.data)
+	var c fr.Element
+	c.SetBytes(h.Sum(nil))
+	return c
+}
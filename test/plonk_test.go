@@ -0,0 +1,124 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestVerifyPlonkProof(t *testing.T) {
+	fixture := newPlonkMultiplierFixture()
+	var tau fr.Element
+	if _, err := tau.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	vk, polys := fixture.keys(tau)
+	proof := fixture.prove(t, tau, vk, polys)
+	publicSignals := fixture.publicSignals()
+	if len(publicSignals) != 2 || publicSignals[0] != "45" || publicSignals[1] != "2" {
+		t.Fatalf("unexpected public signals: %v", publicSignals)
+	}
+
+	ok, err := parser.VerifyPlonkProof(vk, proof, publicSignals)
+	if !ok || err != nil {
+		t.Fatalf("proof should verify: %v", err)
+	}
+
+	// JSON roundtrip
+	proofJSON, err := parser.MarshalCircomPlonkProofJSON(proof)
+	if err != nil {
+		t.Fatalf("failed to marshal proof: %v", err)
+	}
+	vkJSON, err := parser.MarshalCircomPlonkVerificationKeyJSON(vk)
+	if err != nil {
+		t.Fatalf("failed to marshal verification key: %v", err)
+	}
+	proof2, err := parser.UnmarshalCircomPlonkProofJSON(proofJSON)
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	vk2, err := parser.UnmarshalCircomPlonkVerificationKeyJSON(vkJSON)
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	if ok, err := parser.VerifyPlonkProof(vk2, proof2, publicSignals); !ok || err != nil {
+		t.Errorf("proof should verify after a JSON roundtrip: %v", err)
+	}
+
+	// Invalid public signals
+	if ok, _ := parser.VerifyPlonkProof(vk, proof, []string{"46", "2"}); ok {
+		t.Errorf("proof should not verify with wrong public signals")
+	}
+	if _, err := parser.VerifyPlonkProof(vk, proof, publicSignals[:1]); err == nil {
+		t.Errorf("expected error for a wrong number of public signals")
+	}
+
+	// Tampered evaluation
+	tampered := *proof
+	tampered.EvalA = "4"
+	if ok, _ := parser.VerifyPlonkProof(vk, &tampered, publicSignals); ok {
+		t.Errorf("proof should not verify with a tampered evaluation")
+	}
+	tampered = *proof
+	tampered.EvalZw = fr.Modulus().String()
+	if _, err := parser.VerifyPlonkProof(vk, &tampered, publicSignals); err == nil {
+		t.Errorf("expected error for an unreduced evaluation")
+	}
+
+	// Curve names are normalized as for Groth16
+	for _, name := range []string{"bn254", "BN-128"} {
+		namedVk := *vk
+		namedVk.Curve = name
+		if ok, err := parser.VerifyPlonkProof(&namedVk, proof, publicSignals); !ok || err != nil {
+			t.Errorf("proof should verify with curve %q: %v", name, err)
+		}
+	}
+	blsVk := *vk
+	blsVk.Curve = "bls12381"
+	if _, err := parser.VerifyPlonkProof(&blsVk, proof, publicSignals); !errors.Is(err, parser.ErrWrongCurve) {
+		t.Errorf("expected wrong curve error, got %v", err)
+	}
+
+	// The root of unity must match the domain
+	wrongW := *vk
+	wrongW.W = "1"
+	if _, err := parser.VerifyPlonkProof(&wrongW, proof, publicSignals); !errors.Is(err, parser.ErrInvalidScalar) {
+		t.Errorf("expected error for a wrong root of unity, got %v", err)
+	}
+
+	// Wrong protocol
+	groth16Vk := *vk
+	groth16Vk.Protocol = "groth16"
+	if _, err := parser.VerifyPlonkProof(&groth16Vk, proof, publicSignals); err == nil {
+		t.Errorf("expected error for a groth16 verification key")
+	}
+	tampered = *proof
+	tampered.Protocol = "fflonk"
+	if _, err := parser.VerifyPlonkProof(vk, &tampered, publicSignals); err == nil {
+		t.Errorf("expected error for a fflonk proof")
+	}
+}
+
+// TestVerifyPlonkProofSnarkjs verifies a proof of snarkjs plonk prove.
+func TestVerifyPlonkProofSnarkjs(t *testing.T) {
+	vk, err := parser.UnmarshalCircomPlonkVerificationKeyJSON(loadSnarkjsFile(t, "plonk_vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	proof, err := parser.UnmarshalCircomPlonkProofJSON(loadSnarkjsFile(t, "plonk_proof.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	publicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadSnarkjsFile(t, "plonk_public.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+	if ok, err := parser.VerifyPlonkProof(vk, proof, publicSignals); !ok || err != nil {
+		t.Fatalf("proof should verify: %v", err)
+	}
+	if ok, _ := parser.VerifyPlonkProof(vk, proof, []string{"46", "2"}); ok {
+		t.Errorf("proof should not verify with wrong public signals")
+	}
+}
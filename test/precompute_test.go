@@ -0,0 +1,126 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func loadCircomData(tb testing.TB) (*parser.CircomVerificationKey, *parser.CircomProof, []string) {
+	tb.Helper()
+	vk, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(tb, "circom_data/vkey.json"))
+	if err != nil {
+		tb.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	proof, err := parser.UnmarshalCircomProofJSON(loadFile(tb, "circom_data/proof.json"))
+	if err != nil {
+		tb.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	publicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadFile(tb, "circom_data/public_signals.json"))
+	if err != nil {
+		tb.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+	return vk, proof, publicSignals
+}
+
+func TestPrecomputedVerifyingKey(t *testing.T) {
+	vk, proof, publicSignals := loadCircomData(t)
+	gnarkProof, err := parser.ConvertCircomToGnark(vk, proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	pvk, err := parser.ConvertPrecomputedVerificationKey(vk)
+	if err != nil {
+		t.Fatalf("failed to precompute verification key: %v", err)
+	}
+
+	// The precomputed key can be used many times
+	for i := 0; i < 3; i++ {
+		if ok, err := pvk.Verify(gnarkProof.Proof, gnarkProof.PublicInputs); !ok || err != nil {
+			t.Fatalf("proof should verify: %v", err)
+		}
+	}
+
+	// Same results as VerifyProof
+	wrongInputs := append(gnarkProof.PublicInputs[:0:0], gnarkProof.PublicInputs...)
+	wrongInputs[0].SetUint64(1)
+	if ok, err := pvk.Verify(gnarkProof.Proof, wrongInputs); ok || !errors.Is(err, parser.ErrVerificationFailed) {
+		t.Errorf("expected verification failure, got %v", err)
+	}
+	wrongProof := *gnarkProof.Proof
+	wrongProof.Ar, wrongProof.Krs = wrongProof.Krs, wrongProof.Ar
+	if ok, err := pvk.Verify(&wrongProof, gnarkProof.PublicInputs); ok || !errors.Is(err, parser.ErrVerificationFailed) {
+		t.Errorf("expected verification failure, got %v", err)
+	}
+	if ok, err := pvk.Verify(gnarkProof.Proof, gnarkProof.PublicInputs[1:]); ok || !errors.Is(err, parser.ErrNPublicMismatch) {
+		t.Errorf("expected public signals mismatch, got %v", err)
+	}
+	if ok, err := pvk.Verify(nil, gnarkProof.PublicInputs); ok || err == nil {
+		t.Errorf("expected error for a nil proof")
+	}
+	if _, err := parser.NewPrecomputedVerifyingKey(nil); err == nil {
+		t.Errorf("expected error for a nil key")
+	}
+}
+
+func BenchmarkVerifyProof(b *testing.B) {
+	vk, proof, publicSignals := loadCircomData(b)
+	gnarkProof, err := parser.ConvertCircomToGnark(vk, proof, publicSignals)
+	if err != nil {
+		b.Fatalf("failed to convert proof: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := parser.VerifyProof(gnarkProof); !ok {
+			b.Fatalf("proof should verify: %v", err)
+		}
+	}
+}
+
+func BenchmarkVerifyProofPrecomputed(b *testing.B) {
+	vk, proof, publicSignals := loadCircomData(b)
+	gnarkProof, err := parser.ConvertCircomToGnark(vk, proof, publicSignals)
+	if err != nil {
+		b.Fatalf("failed to convert proof: %v", err)
+	}
+	pvk, err := parser.NewPrecomputedVerifyingKey(gnarkProof.VerifyingKey)
+	if err != nil {
+		b.Fatalf("failed to precompute verification key: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := pvk.Verify(gnarkProof.Proof, gnarkProof.PublicInputs); !ok {
+			b.Fatalf("proof should verify: %v", err)
+		}
+	}
+}
+
+func BenchmarkConvertAndVerifyProof(b *testing.B) {
+	vk, proof, publicSignals := loadCircomData(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gnarkProof, err := parser.ConvertCircomToGnark(vk, proof, publicSignals)
+		if err != nil {
+			b.Fatalf("failed to convert proof: %v", err)
+		}
+		if ok, err := parser.VerifyProof(gnarkProof); !ok {
+			b.Fatalf("proof should verify: %v", err)
+		}
+	}
+}
+
+func BenchmarkRegistryVerify(b *testing.B) {
+	vk, proof, publicSignals := loadCircomData(b)
+	registry := parser.NewVerifierRegistry(0)
+	id, err := registry.Register(vk)
+	if err != nil {
+		b.Fatalf("failed to register verification key: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, err := registry.Verify(id, proof, publicSignals); !ok {
+			b.Fatalf("proof should verify: %v", err)
+		}
+	}
+}
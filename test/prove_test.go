@@ -0,0 +1,135 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestProveCircom(t *testing.T) {
+	fixture := newMultiplierFixture()
+	zkey, err := parser.UnmarshalCircomZKey(fixture.zkey(newToxicWaste(t)))
+	if err != nil {
+		t.Fatalf("failed to unmarshal zkey: %v", err)
+	}
+	wtns := &parser.CircomWitness{Values: fixture.witness}
+
+	proof, publicSignals, err := parser.ProveCircom(zkey, wtns)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	if proof.Protocol != "groth16" || proof.Curve != "bn128" {
+		t.Errorf("unexpected proof protocol %q and curve %q", proof.Protocol, proof.Curve)
+	}
+	if len(publicSignals) != 2 || publicSignals[0] != "45" || publicSignals[1] != "2" {
+		t.Errorf("unexpected public signals: %v", publicSignals)
+	}
+
+	// Verify with gnark.
+	gnarkProof, err := parser.ConvertCircomToGnark(zkey.CircomVerificationKey, proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if ok, err := parser.VerifyProof(gnarkProof); !ok || err != nil {
+		t.Errorf("proof should verify with gnark: %v", err)
+	}
+
+	// Verify with go-snark, from the JSON files.
+	dir := t.TempDir()
+	proofJSON, err := parser.MarshalCircomProofJSON(proof)
+	if err != nil {
+		t.Fatalf("failed to marshal proof: %v", err)
+	}
+	if !strings.Contains(string(proofJSON), `"curve": "bn128"`) {
+		t.Errorf("proof JSON should hold the curve: %s", proofJSON)
+	}
+	vkJSON, err := parser.MarshalCircomVerificationKeyJSON(zkey.CircomVerificationKey)
+	if err != nil {
+		t.Fatalf("failed to marshal verification key: %v", err)
+	}
+	publicJSON, err := parser.MarshalCircomPublicSignalsJSON(publicSignals)
+	if err != nil {
+		t.Fatalf("failed to marshal public signals: %v", err)
+	}
+	for name, data := range map[string][]byte{"proof.json": proofJSON, "vkey.json": vkJSON, "public.json": publicJSON} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ok, err := parser.VerifyCircomProof(filepath.Join(dir, "proof.json"), filepath.Join(dir, "vkey.json"), filepath.Join(dir, "public.json"))
+	if !ok || err != nil {
+		t.Errorf("proof should verify with go-snark: %v", err)
+	}
+
+	// A witness that does not satisfy the constraints gives an invalid proof.
+	bad := &parser.CircomWitness{Values: append([]fr.Element(nil), fixture.witness...)}
+	bad.Values[1].SetUint64(46)
+	proof, publicSignals, err = parser.ProveCircom(zkey, bad)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	gnarkProof, err = parser.ConvertCircomToGnark(zkey.CircomVerificationKey, proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if ok, _ := parser.VerifyProof(gnarkProof); ok {
+		t.Errorf("proof of an invalid witness should not verify")
+	}
+
+	if _, _, err := parser.ProveCircom(zkey, &parser.CircomWitness{Values: fixture.witness[:3]}); err == nil {
+		t.Errorf("expected error for a witness of the wrong size")
+	}
+}
+
+// TestProveCircomSnarkjs proves with a zkey and a witness from snarkjs, and
+// verifies the proof against the verification key exported by snarkjs.
+func TestProveCircomSnarkjs(t *testing.T) {
+	zkey, err := parser.UnmarshalCircomZKey(loadSnarkjsFile(t, "groth16.zkey"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal zkey: %v", err)
+	}
+	wtns, err := parser.UnmarshalCircomWitness(loadSnarkjsFile(t, "witness.wtns"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal witness: %v", err)
+	}
+	vk, err := parser.UnmarshalCircomVerificationKeyJSON(loadSnarkjsFile(t, "groth16_vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	snarkjsPublicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadSnarkjsFile(t, "groth16_public.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+
+	proof, publicSignals, err := parser.ProveCircom(zkey, wtns)
+	if err != nil {
+		t.Fatalf("failed to prove: %v", err)
+	}
+	if strings.Join(publicSignals, ",") != strings.Join(snarkjsPublicSignals, ",") {
+		t.Errorf("public signals %v do not match the ones of snarkjs %v", publicSignals, snarkjsPublicSignals)
+	}
+	gnarkProof, err := parser.ConvertCircomToGnark(vk, proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if ok, err := parser.VerifyProof(gnarkProof); !ok || err != nil {
+		t.Errorf("proof should verify against the snarkjs verification key: %v", err)
+	}
+
+	// The proof of snarkjs verifies against the same key.
+	snarkjsProof, err := parser.UnmarshalCircomProofJSON(loadSnarkjsFile(t, "groth16_proof.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	gnarkProof, err = parser.ConvertCircomToGnark(vk, snarkjsProof, snarkjsPublicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if ok, err := parser.VerifyProof(gnarkProof); !ok || err != nil {
+		t.Errorf("snarkjs proof should verify: %v", err)
+	}
+}
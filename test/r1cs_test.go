@@ -0,0 +1,134 @@
+package test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestUnmarshalCircomR1CS(t *testing.T) {
+	fixture := newMultiplierFixture()
+
+	r1cs, err := parser.UnmarshalCircomR1CS(fixture.r1cs())
+	if err != nil {
+		t.Fatalf("failed to unmarshal r1cs: %v", err)
+	}
+	if r1cs.NWires != fixture.nWires || r1cs.NPublic() != fixture.nPublic() ||
+		r1cs.NPrivateInputs != fixture.nPrvInputs || len(r1cs.Constraints) != len(fixture.constraints) {
+		t.Fatalf("unexpected header: %+v", r1cs)
+	}
+
+	// Cross-check against the verification key of the same circuit.
+	zkey, err := parser.UnmarshalCircomZKey(fixture.zkey(newToxicWaste(t)))
+	if err != nil {
+		t.Fatalf("failed to unmarshal zkey: %v", err)
+	}
+	if err := r1cs.CheckVerificationKey(zkey.CircomVerificationKey); err != nil {
+		t.Errorf("verification key check failed: %v", err)
+	}
+	zkey.CircomVerificationKey.NPublic++
+	if err := r1cs.CheckVerificationKey(zkey.CircomVerificationKey); err == nil {
+		t.Errorf("expected nPublic mismatch error")
+	}
+
+	ccs, err := r1cs.ConstraintSystem()
+	if err != nil {
+		t.Fatalf("failed to build constraint system: %v", err)
+	}
+	if ccs.GetNbConstraints() != len(fixture.constraints) || ccs.GetNbPublicVariables() != fixture.nPublic()+1 {
+		t.Fatalf("unexpected constraint system: %d constraints, %d public variables",
+			ccs.GetNbConstraints(), ccs.GetNbPublicVariables())
+	}
+
+	// The full witness (without the constant one) must satisfy the constraints.
+	newWitness := func(values []fr.Element) witness.Witness {
+		w, err := witness.New(fr.Modulus())
+		if err != nil {
+			t.Fatalf("failed to create witness: %v", err)
+		}
+		ch := make(chan any, len(values))
+		for _, v := range values {
+			ch <- v
+		}
+		close(ch)
+		if err := w.Fill(fixture.nPublic(), len(values)-fixture.nPublic(), ch); err != nil {
+			t.Fatalf("failed to fill witness: %v", err)
+		}
+		return w
+	}
+	if err := ccs.IsSolved(newWitness(fixture.witness[1:])); err != nil {
+		t.Errorf("witness should satisfy the constraints: %v", err)
+	}
+	bad := append([]fr.Element{}, fixture.witness[1:]...)
+	bad[0].SetUint64(46)
+	if err := ccs.IsSolved(newWitness(bad)); err == nil {
+		t.Errorf("invalid witness should not satisfy the constraints")
+	}
+
+	data := fixture.r1cs()
+	for _, size := range []int{0, 11, 60, len(data) - 1} {
+		if _, err := parser.UnmarshalCircomR1CS(data[:size]); err == nil {
+			t.Errorf("expected error for r1cs truncated to %d bytes", size)
+		}
+	}
+
+	// Without the wire to label map, the number of wires is bounded by the
+	// constraints. The header content starts at byte 24 and nWires follows
+	// the prime.
+	data = fixture.r1cs()
+	data = data[:len(data)-12-8*fixture.nWires]
+	binary.LittleEndian.PutUint32(data[8:], 2)
+	if _, err := parser.UnmarshalCircomR1CS(data); err != nil {
+		t.Fatalf("failed to unmarshal r1cs without wire to label map: %v", err)
+	}
+	binary.LittleEndian.PutUint32(data[24+4+fr.Bytes:], 1<<30)
+	if _, err := parser.UnmarshalCircomR1CS(data); err == nil {
+		t.Errorf("expected error for a number of wires larger than the constraints")
+	}
+}
+
+func TestUnmarshalCircomR1CSCircom(t *testing.T) {
+	r1cs, err := parser.UnmarshalCircomR1CS(loadSnarkjsFile(t, "circuit.r1cs"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal r1cs: %v", err)
+	}
+	if r1cs.NOutputs != 1 || r1cs.NPublicInputs != 1 || r1cs.NPrivateInputs != 2 || len(r1cs.WireToLabel) != r1cs.NWires {
+		t.Fatalf("unexpected header: %+v", r1cs)
+	}
+	zkey, err := parser.UnmarshalCircomZKey(loadSnarkjsFile(t, "groth16.zkey"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal zkey: %v", err)
+	}
+	if zkey.NVars != r1cs.NWires {
+		t.Errorf("zkey has %d variables, but the circuit has %d wires", zkey.NVars, r1cs.NWires)
+	}
+	if err := r1cs.CheckVerificationKey(zkey.CircomVerificationKey); err != nil {
+		t.Errorf("verification key check failed: %v", err)
+	}
+
+	// The wires are the constant one, out, x, a, b and t, as in the fixture.
+	ccs, err := r1cs.ConstraintSystem()
+	if err != nil {
+		t.Fatalf("failed to build constraint system: %v", err)
+	}
+	fixture := newMultiplierFixture()
+	w, err := witness.New(fr.Modulus())
+	if err != nil {
+		t.Fatalf("failed to create witness: %v", err)
+	}
+	values := fixture.witness[1:]
+	ch := make(chan any, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	close(ch)
+	if err := w.Fill(fixture.nPublic(), len(values)-fixture.nPublic(), ch); err != nil {
+		t.Fatalf("failed to fill witness: %v", err)
+	}
+	if err := ccs.IsSolved(w); err != nil {
+		t.Errorf("witness should satisfy the constraints: %v", err)
+	}
+}
@@ -204,3 +204,99 @@ func TestRecursionToSolidity(t *testing.T) {
 	fd.Close()
 	t.Logf("Solidity verifier written to vkey.sol\n")
 }
+
+// proveOuterCircuit proves the outer circuit of TestRecursionToSolidity for
+// the Solidity verifier.
+func proveOuterCircuit(t *testing.T) (groth16.Proof, groth16.VerifyingKey, witness.Witness) {
+	innerCcs, innerVK, innerPubWitness, innerProof := computeInnerProof(ecc.BN254.ScalarField(), ecc.BN254.ScalarField())
+	circuitVK, err := stdgroth16.ValueOfVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](innerVK)
+	if err != nil {
+		t.Fatalf("failed to convert inner VK: %v", err)
+	}
+	circuitWitness, err := stdgroth16.ValueOfWitness[sw_bn254.ScalarField](innerPubWitness)
+	if err != nil {
+		t.Fatalf("failed to convert inner witness: %v", err)
+	}
+	circuitProof, err := stdgroth16.ValueOfProof[sw_bn254.G1Affine, sw_bn254.G2Affine](innerProof)
+	if err != nil {
+		t.Fatalf("failed to convert inner proof: %v", err)
+	}
+	outerAssignment := &OuterCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]{
+		InnerWitness: circuitWitness,
+		Proof:        circuitProof,
+		VerifyingKey: circuitVK,
+		DummyInput1:  1,
+		DummyInput2:  1,
+		DummyInput3:  1,
+	}
+	outerCircuit := &OuterCircuit[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]{
+		InnerWitness: stdgroth16.PlaceholderWitness[sw_bn254.ScalarField](innerCcs),
+		VerifyingKey: stdgroth16.PlaceholderVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](innerCcs),
+	}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, outerCircuit)
+	if err != nil {
+		t.Fatalf("failed to compile outer circuit: %v", err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatalf("outer circuit setup failed: %v", err)
+	}
+	fullWitness, err := frontend.NewWitness(outerAssignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("failed to create witness: %v", err)
+	}
+	proof, err := groth16.Prove(ccs, pk, fullWitness, solidity.WithProverTargetSolidityVerifier(backend.GROTH16))
+	if err != nil {
+		t.Fatalf("outer proving failed: %v", err)
+	}
+	return proof, vk, fullWitness
+}
+
+func TestRecursionToSolidityProof(t *testing.T) {
+	outerProof, vk, fullWitness := proveOuterCircuit(t)
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatalf("failed to get public witness: %v", err)
+	}
+	if err := groth16.Verify(outerProof, vk, publicWitness, solidity.WithVerifierTargetSolidityVerifier(backend.GROTH16)); err != nil {
+		t.Fatalf("outer proof verification failed: %v", err)
+	}
+
+	// Groth16SolidityProof holds the commitment and the public inputs
+	var solProof parser.Groth16SolidityProof
+	if err := solProof.FromGnarkProof(outerProof, fullWitness); err != nil {
+		t.Fatalf("failed to convert outer proof to Solidity: %v", err)
+	}
+	if len(solProof.Commitments) != 2 {
+		t.Fatalf("expected one commitment, got %d coordinates", len(solProof.Commitments))
+	}
+	if got := solProof.PublicSignals(); len(got) != 3 || got[0] != "1" || got[1] != "1" || got[2] != "1" {
+		t.Errorf("expected public inputs [1 1 1], got %v", got)
+	}
+
+	// It agrees with Groth16CommitmentProof on the proof and the commitment
+	var cmtProof parser.Groth16CommitmentProof
+	if err := cmtProof.FromGnarkProof(outerProof); err != nil {
+		t.Fatalf("failed to convert outer proof to Groth16CommitmentProof: %v", err)
+	}
+	if cmtProof.Commitments[0].Cmp(solProof.Commitments[0]) != 0 || cmtProof.Commitments[1].Cmp(solProof.Commitments[1]) != 0 {
+		t.Errorf("commitments differ: %v and %v", cmtProof.Commitments, solProof.Commitments)
+	}
+
+	// The calldata decodes back to a proof that verifies
+	calldata, err := solProof.ABIEncodeCall()
+	if err != nil {
+		t.Fatalf("failed to encode proof: %v", err)
+	}
+	var decoded parser.Groth16SolidityProof
+	if err := decoded.ABIDecode(calldata, 1); err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	gnarkProof, err := decoded.ToGnarkProof()
+	if err != nil {
+		t.Fatalf("failed to convert decoded proof: %v", err)
+	}
+	if err := groth16.Verify(gnarkProof, vk, publicWitness, solidity.WithVerifierTargetSolidityVerifier(backend.GROTH16)); err != nil {
+		t.Errorf("decoded proof verification failed: %v", err)
+	}
+}
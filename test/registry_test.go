@@ -0,0 +1,106 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestVerifierRegistry(t *testing.T) {
+	vk, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(t, "circom_data/vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	proof, err := parser.UnmarshalCircomProofJSON(loadFile(t, "circom_data/proof.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	publicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadFile(t, "circom_data/public_signals.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+
+	registry := parser.NewVerifierRegistry(1)
+	id, err := registry.Register(vk)
+	if err != nil {
+		t.Fatalf("failed to register verification key: %v", err)
+	}
+	if fp, err := vk.Fingerprint(parser.FingerprintSHA256); err != nil || fp != id {
+		t.Errorf("the ID should be the SHA-256 fingerprint: %v", err)
+	}
+	if again, err := registry.Register(vk); err != nil || again != id || registry.Len() != 1 {
+		t.Errorf("registering the same key twice should return the same ID: %v", err)
+	}
+
+	// Concurrent verifications
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := registry.Verify(id, proof, publicSignals); !ok || err != nil {
+				t.Errorf("proof should verify: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Invalid proofs and signals
+	if ok, err := registry.Verify(id, proof, []string{"1", "2"}); ok || !errors.Is(err, parser.ErrNPublicMismatch) {
+		t.Errorf("expected public signals mismatch, got %v", err)
+	}
+	wrong := append([]string(nil), publicSignals...)
+	wrong[0] = "1"
+	if ok, err := registry.Verify(id, proof, wrong); ok || !errors.Is(err, parser.ErrVerificationFailed) {
+		t.Errorf("expected verification failure, got %v", err)
+	}
+	bad := *proof
+	bad.PiA = []string{"1", "3", "1"}
+	if ok, err := registry.Verify(id, &bad, publicSignals); ok || !errors.Is(err, parser.ErrInvalidPoint) {
+		t.Errorf("expected invalid point, got %v", err)
+	}
+
+	// A second key evicts the least recently used one
+	var circuit Circuit
+	cs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+	if err != nil {
+		t.Fatalf("failed to compile circuit: %v", err)
+	}
+	_, gnarkVk, err := groth16.Setup(cs)
+	if err != nil {
+		t.Fatalf("groth16 setup failed: %v", err)
+	}
+	otherID, err := registry.RegisterVerifyingKey(gnarkVk.(*groth16_bn254.VerifyingKey))
+	if err != nil {
+		t.Fatalf("failed to register verifying key: %v", err)
+	}
+	if got, ok := registry.Get(otherID); !ok || registry.Len() != 1 {
+		t.Errorf("expected only the second key, got %d keys", registry.Len())
+	} else if got == gnarkVk.(*groth16_bn254.VerifyingKey) {
+		t.Errorf("the registry should store a copy of the key")
+	}
+	if _, err := registry.RegisterVerifyingKey(commitGnarkProof(t, 1).VerifyingKey); err == nil {
+		t.Errorf("expected error for a key with commitments")
+	}
+	if ok, err := registry.Verify(id, proof, publicSignals); ok || !errors.Is(err, parser.ErrUnknownKey) {
+		t.Errorf("expected unknown key, got %v", err)
+	}
+	registry.Remove(otherID)
+	if registry.Len() != 0 {
+		t.Errorf("expected an empty registry, got %d keys", registry.Len())
+	}
+
+	// Keys on other curves are rejected
+	blsVk := *vk
+	blsVk.Curve = "bls12381"
+	if _, err := registry.Register(&blsVk); err == nil {
+		t.Errorf("expected error for a BLS12-381 key")
+	}
+}
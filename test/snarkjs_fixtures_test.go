@@ -0,0 +1,363 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+)
+
+// snarkjsDir holds the artifacts that generate.sh builds with circom and
+// snarkjs from circuit.circom, the multiplier described below.
+const snarkjsDir = "circom_data/multiplier"
+
+// loadSnarkjsFile reads an artifact of snarkjsDir, and skips the test if it has
+// not been generated.
+func loadSnarkjsFile(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(snarkjsDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skipf("%s is missing, run %s/generate.sh", name, snarkjsDir)
+	}
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return data
+}
+
+// circomFixture describes a small Circom circuit, in the wire order used by
+// Circom: the constant one, the outputs, the public inputs, the private
+// inputs and then the internal signals. It mimics:
+//
+//	template Multiplier() {
+//	    signal input x;        // public
+//	    signal input a, b;     // private
+//	    signal output out;
+//	    signal t;
+//	    t <== a * b;
+//	    out <== t * (x + 1);
+//	}
+type circomFixture struct {
+	nWires, nOutputs, nPubInputs, nPrvInputs int
+	// constraints[i] holds the A, B and C linear combinations of constraint i,
+	// as maps from wire index to coefficient.
+	constraints [][3]map[int]*big.Int
+	witness     []fr.Element
+}
+
+func newMultiplierFixture() *circomFixture {
+	one := big.NewInt(1)
+	minusOne := new(big.Int).Sub(fr.Modulus(), one)
+	// wires: 0:one 1:out 2:x 3:a 4:b 5:t
+	f := &circomFixture{
+		nWires:     6,
+		nOutputs:   1,
+		nPubInputs: 1,
+		nPrvInputs: 2,
+		constraints: [][3]map[int]*big.Int{
+			// a * b - t = 0, written by circom as (-a) * b = -t
+			{{3: minusOne}, {4: one}, {5: minusOne}},
+			// t * (x + 1) = out
+			{{5: one}, {0: one, 2: one}, {1: one}},
+		},
+	}
+	x, a, b := int64(2), int64(3), int64(5)
+	values := []int64{1, a * b * (x + 1), x, a, b, a * b}
+	f.witness = make([]fr.Element, len(values))
+	for i, v := range values {
+		f.witness[i].SetInt64(v)
+	}
+	return f
+}
+
+func (f *circomFixture) nPublic() int {
+	return f.nOutputs + f.nPubInputs
+}
+
+// toxicWaste holds the secret values of a trusted setup.
+type toxicWaste struct {
+	tau, alpha, beta, gamma, delta fr.Element
+}
+
+func newToxicWaste(t *testing.T) *toxicWaste {
+	var tw toxicWaste
+	for _, e := range []*fr.Element{&tw.tau, &tw.alpha, &tw.beta, &tw.gamma, &tw.delta} {
+		if _, err := e.SetRandom(); err != nil {
+			t.Fatalf("failed to sample toxic waste: %v", err)
+		}
+	}
+	return &tw
+}
+
+// lagrangeAt evaluates the n Lagrange polynomials over the n-th roots of
+// unity at x: Lᵢ(x) = ωⁱ·(xⁿ-1) / (n·(x-ωⁱ)).
+func lagrangeAt(x fr.Element, n int) []fr.Element {
+	domain := fft.NewDomain(uint64(n))
+	var xn, one, nInv fr.Element
+	one.SetOne()
+	xn.Exp(x, big.NewInt(int64(n))).Sub(&xn, &one)
+	nInv.SetUint64(uint64(n)).Inverse(&nInv)
+	res := make([]fr.Element, n)
+	wi := fr.One()
+	for i := range res {
+		var den fr.Element
+		den.Sub(&x, &wi).Inverse(&den)
+		res[i].Mul(&wi, &xn).Mul(&res[i], &den).Mul(&res[i], &nInv)
+		wi.Mul(&wi, &domain.Generator)
+	}
+	return res
+}
+
+// domainSize returns the size of the evaluation domain used by SnarkJS, which
+// adds one constraint per public signal (and for the constant one).
+func (f *circomFixture) domainSize() int {
+	n := 1
+	for n < len(f.constraints)+f.nPublic()+1 {
+		n <<= 1
+	}
+	return n
+}
+
+// zkey builds a .zkey file for the fixture following the layout of
+// `snarkjs groth16 setup`.
+func (f *circomFixture) zkey(tw *toxicWaste) []byte {
+	n := f.domainSize()
+	m := len(f.constraints)
+	nPublic := f.nPublic()
+	lagrange := lagrangeAt(tw.tau, n)
+
+	// evaluate the QAP polynomials of each wire at τ
+	a := make([]fr.Element, f.nWires)
+	b := make([]fr.Element, f.nWires)
+	c := make([]fr.Element, f.nWires)
+	var tmp fr.Element
+	for i, constraint := range f.constraints {
+		for k, evals := range [][]fr.Element{a, b, c} {
+			for w, coeff := range constraint[k] {
+				tmp.SetBigInt(coeff).Mul(&tmp, &lagrange[i])
+				evals[w].Add(&evals[w], &tmp)
+			}
+		}
+	}
+	for i := 0; i <= nPublic; i++ {
+		a[i].Add(&a[i], &lagrange[m+i])
+	}
+
+	var gammaInv, deltaInv fr.Element
+	gammaInv.Inverse(&tw.gamma)
+	deltaInv.Inverse(&tw.delta)
+	ic := make([]fr.Element, nPublic+1)
+	pointsC := make([]fr.Element, f.nWires-nPublic-1)
+	for i := 0; i < f.nWires; i++ {
+		var k fr.Element
+		k.Mul(&tw.beta, &a[i])
+		tmp.Mul(&tw.alpha, &b[i])
+		k.Add(&k, &tmp).Add(&k, &c[i])
+		if i <= nPublic {
+			ic[i].Mul(&k, &gammaInv)
+		} else {
+			pointsC[i-nPublic-1].Mul(&k, &deltaInv)
+		}
+	}
+	lagrange2n := lagrangeAt(tw.tau, 2*n)
+	h := make([]fr.Element, n)
+	for i := range h {
+		h[i].Mul(&lagrange2n[2*i+1], &deltaInv)
+	}
+
+	var buf bytes.Buffer
+	w := &binFileWriter{buf: &buf}
+	w.header("zkey", 1, 9)
+
+	w.section(1, func(s *bytes.Buffer) { putU32(s, 1) })
+	w.section(2, func(s *bytes.Buffer) {
+		putU32(s, fp.Bytes)
+		s.Write(leBytes(fp.Modulus(), fp.Bytes))
+		putU32(s, fr.Bytes)
+		s.Write(leBytes(fr.Modulus(), fr.Bytes))
+		putU32(s, uint32(f.nWires))
+		putU32(s, uint32(nPublic))
+		putU32(s, uint32(n))
+		putG1Mont(s, g1Mul(tw.alpha))
+		putG1Mont(s, g1Mul(tw.beta))
+		putG2Mont(s, g2Mul(tw.beta))
+		putG2Mont(s, g2Mul(tw.gamma))
+		putG1Mont(s, g1Mul(tw.delta))
+		putG2Mont(s, g2Mul(tw.delta))
+	})
+	w.section(3, func(s *bytes.Buffer) { putG1sMont(s, ic) })
+	w.section(4, func(s *bytes.Buffer) { f.writeCoefs(s) })
+	w.section(5, func(s *bytes.Buffer) { putG1sMont(s, a) })
+	w.section(6, func(s *bytes.Buffer) { putG1sMont(s, b) })
+	w.section(7, func(s *bytes.Buffer) {
+		for i := range b {
+			putG2Mont(s, g2Mul(b[i]))
+		}
+	})
+	w.section(8, func(s *bytes.Buffer) { putG1sMont(s, pointsC) })
+	w.section(9, func(s *bytes.Buffer) { putG1sMont(s, h) })
+	return buf.Bytes()
+}
+
+// r1cs builds the .r1cs file of the fixture, as written by circom.
+func (f *circomFixture) r1cs() []byte {
+	var buf bytes.Buffer
+	w := &binFileWriter{buf: &buf}
+	w.header("r1cs", 1, 3)
+	w.section(1, func(s *bytes.Buffer) {
+		putU32(s, fr.Bytes)
+		s.Write(leBytes(fr.Modulus(), fr.Bytes))
+		putU32(s, uint32(f.nWires))
+		putU32(s, uint32(f.nOutputs))
+		putU32(s, uint32(f.nPubInputs))
+		putU32(s, uint32(f.nPrvInputs))
+		putU64(s, uint64(f.nWires))
+		putU32(s, uint32(len(f.constraints)))
+	})
+	w.section(2, func(s *bytes.Buffer) {
+		for _, constraint := range f.constraints {
+			for _, lc := range constraint {
+				putU32(s, uint32(len(lc)))
+				for wire := 0; wire < f.nWires; wire++ {
+					if coeff, ok := lc[wire]; ok {
+						putU32(s, uint32(wire))
+						s.Write(leBytes(coeff, fr.Bytes))
+					}
+				}
+			}
+		}
+	})
+	w.section(3, func(s *bytes.Buffer) {
+		for wire := 0; wire < f.nWires; wire++ {
+			putU64(s, uint64(wire))
+		}
+	})
+	return buf.Bytes()
+}
+
+// writeCoefs writes the non-zero entries of the A and B matrices, including
+// the extra constraints SnarkJS adds for the public signals. Values are
+// stored multiplied by R² (R = 2²⁵⁶), as SnarkJS does.
+func (f *circomFixture) writeCoefs(s *bytes.Buffer) {
+	r := new(big.Int).Lsh(big.NewInt(1), 256)
+	var r2 fr.Element
+	r2.SetBigInt(r).Square(&r2)
+
+	type coef struct {
+		matrix, constraint, signal int
+		value                      fr.Element
+	}
+	var coefs []coef
+	for i, constraint := range f.constraints {
+		for matrix := 0; matrix < 2; matrix++ {
+			for w := 0; w < f.nWires; w++ {
+				if v, ok := constraint[matrix][w]; ok {
+					var e fr.Element
+					e.SetBigInt(v)
+					coefs = append(coefs, coef{matrix, i, w, e})
+				}
+			}
+		}
+	}
+	for i := 0; i <= f.nPublic(); i++ {
+		coefs = append(coefs, coef{0, len(f.constraints) + i, i, fr.One()})
+	}
+	putU32(s, uint32(len(coefs)))
+	for _, c := range coefs {
+		putU32(s, uint32(c.matrix))
+		putU32(s, uint32(c.constraint))
+		putU32(s, uint32(c.signal))
+		var v fr.Element
+		v.Mul(&c.value, &r2)
+		s.Write(leBytes(v.BigInt(new(big.Int)), fr.Bytes))
+	}
+}
+
+// binFileWriter writes files in the iden3 binary container format.
+type binFileWriter struct {
+	buf *bytes.Buffer
+}
+
+func (w *binFileWriter) header(magic string, version, nSections uint32) {
+	w.buf.WriteString(magic)
+	putU32(w.buf, version)
+	putU32(w.buf, nSections)
+}
+
+func (w *binFileWriter) section(sType uint32, content func(*bytes.Buffer)) {
+	var s bytes.Buffer
+	content(&s)
+	putU32(w.buf, sType)
+	putU64(w.buf, uint64(s.Len()))
+	w.buf.Write(s.Bytes())
+}
+
+func putU64(b *bytes.Buffer, v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	b.Write(buf[:])
+}
+
+func putU32(b *bytes.Buffer, v uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	b.Write(buf[:])
+}
+
+func leBytes(v *big.Int, size int) []byte {
+	be := v.FillBytes(make([]byte, size))
+	le := make([]byte, size)
+	for i := range be {
+		le[size-1-i] = be[i]
+	}
+	return le
+}
+
+func g1Mul(s fr.Element) curve.G1Affine {
+	var p curve.G1Affine
+	p.ScalarMultiplicationBase(s.BigInt(new(big.Int)))
+	return p
+}
+
+func g2Mul(s fr.Element) curve.G2Affine {
+	var p curve.G2Affine
+	p.ScalarMultiplicationBase(s.BigInt(new(big.Int)))
+	return p
+}
+
+// putFpMont writes the raw Montgomery limbs of e, which is how SnarkJS stores
+// point coordinates.
+func putFpMont(b *bytes.Buffer, e fp.Element) {
+	for _, limb := range e {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], limb)
+		b.Write(buf[:])
+	}
+}
+
+func putG1Mont(b *bytes.Buffer, p curve.G1Affine) {
+	putFpMont(b, p.X)
+	putFpMont(b, p.Y)
+}
+
+func putG2Mont(b *bytes.Buffer, p curve.G2Affine) {
+	putFpMont(b, p.X.A0)
+	putFpMont(b, p.X.A1)
+	putFpMont(b, p.Y.A0)
+	putFpMont(b, p.Y.A1)
+}
+
+func putG1sMont(b *bytes.Buffer, scalars []fr.Element) {
+	for i := range scalars {
+		putG1Mont(b, g1Mul(scalars[i]))
+	}
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/vocdoni/circom2gnark/parser"
+	"golang.org/x/crypto/sha3"
+)
+
+// snarkjsCalldata is the output of `snarkjs zkey export soliditycalldata` for
+// the proof in circom_data.
+const snarkjsCalldata = `["0x01eb04f55f319c5350d98006a00b08f2f5445cdebfeffa0537f36162762acfc1", "0x16b0ddb7cb5185cd452b339e5b60f84e16c75ce4aa80a77dbca939181895ea69"],` +
+	`[["0x14d0bc2e2736a6132538e5502190db0b1be6b0eaa6481c395935641f7e69e9fd", "0x2fdd5d63f1b32b25445f0651e0b73ad6fc13982de1b75dca354118cbf2b99c68"],` +
+	`["0x0b37c2294ebcaa1248582f054e025f631dab13a6fd703d922469e502d5e96935", "0x0102c52ffd69fd18cc82ebe139e90b6a8a772faebd57d1ce1479fe80bcd6b129"]],` +
+	`["0x221414685a12783434cc7e448a386390d8a70cf51796caaa2930e7f8e42570b7", "0x034b22f206f783061b36dbba90da225c8fd4ec0140dac140f4772e138eed75e8"],` +
+	`["0x033171d0cce5ae6815065b152cf8473b577deb51d478fee94ce0a2674e595397"]`
+
+func TestSolidityCalldata(t *testing.T) {
+	_, proof, publicSignals := loadCircomData(t)
+	calldata, err := parser.ConvertCircomToSolidityCalldata(proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if got := calldata.String(); got != snarkjsCalldata {
+		t.Errorf("unexpected textual calldata:\n got %s\nwant %s", got, snarkjsCalldata)
+	}
+
+	// All the arguments are static: a, b (imaginary part first), c and the
+	// public inputs as 32-byte words.
+	p := calldata.Proof
+	words := []*big.Int{p.Ar[0], p.Ar[1], p.Bs[0][0], p.Bs[0][1], p.Bs[1][0], p.Bs[1][1], p.Krs[0], p.Krs[1]}
+	words = append(words, calldata.PublicInputs...)
+	var want []byte
+	for _, w := range words {
+		want = append(want, w.FillBytes(make([]byte, 32))...)
+	}
+	encoded, err := calldata.ABIEncode()
+	if err != nil {
+		t.Fatalf("failed to encode calldata: %v", err)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("unexpected ABI encoding %x", encoded)
+	}
+	if p.Bs[0][0].String() != proof.PiB[0][1] || p.Bs[0][1].String() != proof.PiB[0][0] {
+		t.Errorf("b is not in the swapped Fp2 order")
+	}
+
+	call, err := calldata.ABIEncodeCall()
+	if err != nil {
+		t.Fatalf("failed to encode call: %v", err)
+	}
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte("verifyProof(uint256[2],uint256[2][2],uint256[2],uint256[1])"))
+	selector := hasher.Sum(nil)[:4]
+	if !bytes.Equal(call[:4], selector) || !bytes.Equal(call[4:], want) {
+		t.Errorf("unexpected call encoding %x", call)
+	}
+
+	// Public signals must be canonical scalars
+	if _, err := parser.ConvertCircomToSolidityCalldata(proof, []string{"-1"}); !errors.Is(err, parser.ErrInvalidScalar) ||
+		errorPath(err) != "publicSignals[0]" {
+		t.Errorf("expected invalid scalar at publicSignals[0], got %v", err)
+	}
+	bad := *proof
+	bad.PiA = []string{"1", "3", "1"}
+	if _, err := parser.ConvertCircomToSolidityCalldata(&bad, publicSignals); err == nil {
+		t.Errorf("expected error for a point not on the curve")
+	}
+}
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+// compressedCircomProof is the proof in circom_data as compressed by the
+// compressProof function of the gnark Solidity verifier.
+var compressedCircomProof = [4]string{
+	"1735112262007167329288382236905390433057220225649544646718220458322528935811",
+	"9415059933739635581431810791033023350825797826438005718041188106672825559549",
+	"86599286566513930856228981586693632053948886745621206728026686319801416446368",
+	"30828229282747436534348582272850606538214968939509492022614910074405998223726",
+}
+
+func TestCompressSolidityProof(t *testing.T) {
+	_, proof, publicSignals := loadCircomData(t)
+	gnarkProof, err := parser.ConvertProof(proof)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	var solProof parser.SolidityProof
+	if err := solProof.FromGnarkProof(gnarkProof); err != nil {
+		t.Fatalf("failed to convert to a Solidity proof: %v", err)
+	}
+	compressed, err := solProof.Compress()
+	if err != nil {
+		t.Fatalf("failed to compress proof: %v", err)
+	}
+	for i := range compressed {
+		if compressed[i].String() != compressedCircomProof[i] {
+			t.Errorf("compressed[%d] = %s, want %s", i, compressed[i], compressedCircomProof[i])
+		}
+	}
+	decompressed, err := parser.DecompressSolidityProof(compressed)
+	if err != nil {
+		t.Fatalf("failed to decompress proof: %v", err)
+	}
+	if !reflect.DeepEqual(decompressed, &solProof) {
+		t.Errorf("decompressed proof does not match")
+	}
+
+	// Calldata of verifyCompressedProof(uint256[4], uint256[1]) for the
+	// Circom verifier exported with the gnark interface.
+	calldata, err := parser.ConvertCircomToSolidityCalldata(proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	compressedCalldata, err := calldata.Compress()
+	if err != nil {
+		t.Fatalf("failed to compress calldata: %v", err)
+	}
+	args, err := compressedCalldata.ABIEncode()
+	if err != nil {
+		t.Fatalf("failed to encode compressed proof: %v", err)
+	}
+	uncompressed, err := calldata.ABIEncode()
+	if err != nil {
+		t.Fatalf("failed to encode calldata: %v", err)
+	}
+	if len(args) != 5*32 || len(uncompressed) != 9*32 {
+		t.Errorf("unexpected sizes %d and %d", len(args), len(uncompressed))
+	}
+	var decoded parser.CompressedSolidityProof
+	if err := decoded.ABIDecode(args, 0); err != nil {
+		t.Fatalf("failed to decode compressed proof: %v", err)
+	}
+	for _, nbCommitments := range []int{-1, 6, math.MaxInt / 16, math.MaxInt} {
+		if err := decoded.ABIDecode(args, nbCommitments); !errors.Is(err, parser.ErrMalformedBinary) || errorPath(err) != "calldata" {
+			t.Errorf("expected malformed binary data at calldata for %d commitments, got %v", nbCommitments, err)
+		}
+	}
+	if err := decoded.ABIDecode(args[:3*32], 0); !errors.Is(err, parser.ErrMalformedBinary) {
+		t.Errorf("expected malformed binary data for a truncated proof, got %v", err)
+	}
+	roundTrip, err := decoded.Decompress()
+	if err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	if !reflect.DeepEqual(roundTrip.Proof, calldata.Proof) || !reflect.DeepEqual(roundTrip.PublicSignals(), publicSignals) {
+		t.Errorf("decompressed calldata does not match")
+	}
+
+	// Invalid compressed points
+	tests := []struct {
+		name  string
+		index int
+		value *big.Int
+	}{
+		{"x not on the curve", 0, big.NewInt(8)}, // 4³ + 3 = 67 is not a square
+		{"unreduced x", 3, new(big.Int).Lsh(big.NewInt(1), 255)},
+		{"wrong hint", 2, new(big.Int).Xor(compressed[2], big.NewInt(2))},
+	}
+	for _, tc := range tests {
+		bad := compressed
+		bad[tc.index] = tc.value
+		if _, err := parser.DecompressSolidityProof(bad); !errors.Is(err, parser.ErrInvalidPoint) {
+			t.Errorf("%s: expected invalid point, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestCompressGroth16SolidityProof(t *testing.T) {
+	for _, nbCommitments := range []int{0, 1, 2} {
+		t.Run(fmt.Sprintf("commitments=%d", nbCommitments), func(t *testing.T) {
+			proof, vk, w := proveCommitCircuit(t, nbCommitments)
+			var solProof parser.Groth16SolidityProof
+			if err := solProof.FromGnarkProof(proof, w); err != nil {
+				t.Fatalf("failed to convert proof: %v", err)
+			}
+			compressed, err := solProof.Compress()
+			if err != nil {
+				t.Fatalf("failed to compress proof: %v", err)
+			}
+			call, err := compressed.ABIEncodeCall()
+			if err != nil {
+				t.Fatalf("failed to encode call: %v", err)
+			}
+
+			// The arguments match the verifyCompressedProof function of the
+			// exported verifier.
+			var contract bytes.Buffer
+			if err := vk.ExportSolidity(&contract); err != nil {
+				t.Fatalf("failed to export verifier: %v", err)
+			}
+			signature := "function verifyCompressedProof(\n        uint256[4] calldata compressedProof,\n"
+			if nbCommitments > 0 {
+				signature += fmt.Sprintf("        uint256[%d] calldata compressedCommitments,\n", nbCommitments) +
+					"        uint256 compressedCommitmentPok,\n"
+			}
+			signature += "        uint256[2] calldata input\n"
+			if !bytes.Contains(contract.Bytes(), []byte(signature)) {
+				t.Errorf("exported verifier does not have the verifyCompressedProof function:\n%s", signature)
+			}
+			size := 4 + 32*(4+2)
+			if nbCommitments > 0 {
+				size += 32 * (nbCommitments + 1)
+			}
+			if len(call) != size {
+				t.Errorf("unexpected call size %d, want %d", len(call), size)
+			}
+
+			var decoded parser.CompressedSolidityProof
+			if err := decoded.ABIDecode(call, nbCommitments); err != nil {
+				t.Fatalf("failed to decode compressed proof: %v", err)
+			}
+			foreign := append([]byte(nil), call...)
+			foreign[3] ^= 1
+			if err := decoded.ABIDecode(foreign, nbCommitments); !errors.Is(err, parser.ErrMalformedBinary) {
+				t.Errorf("expected malformed binary data for a foreign selector, got %v", err)
+			}
+			decompressed, err := decoded.Decompress()
+			if err != nil {
+				t.Fatalf("failed to decompress proof: %v", err)
+			}
+			want, err := solProof.ABIEncode()
+			if err != nil {
+				t.Fatalf("failed to encode proof: %v", err)
+			}
+			if got, err := decompressed.ABIEncode(); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("decompressed proof does not match: %v", err)
+			}
+			gnarkProof, err := decompressed.ToGnarkProof()
+			if err != nil {
+				t.Fatalf("failed to convert decompressed proof: %v", err)
+			}
+			publicWitness, err := w.Public()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := groth16.Verify(gnarkProof, vk, publicWitness,
+				solidity.WithVerifierTargetSolidityVerifier(backend.GROTH16)); err != nil {
+				t.Errorf("decompressed proof should verify: %v", err)
+			}
+		})
+	}
+}
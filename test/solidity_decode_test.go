@@ -0,0 +1,271 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestDecodeSolidityCalldata(t *testing.T) {
+	vk, proof, publicSignals := loadCircomData(t)
+	calldata, err := parser.ConvertCircomToSolidityCalldata(proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	call, err := calldata.ABIEncodeCall()
+	if err != nil {
+		t.Fatalf("failed to encode call: %v", err)
+	}
+	args, err := calldata.ABIEncode()
+	if err != nil {
+		t.Fatalf("failed to encode calldata: %v", err)
+	}
+
+	// With and without the function selector
+	for _, data := range [][]byte{call, args} {
+		decoded, err := parser.DecodeSolidityCalldata(data)
+		if err != nil {
+			t.Fatalf("failed to decode calldata: %v", err)
+		}
+		if decoded.String() != calldata.String() {
+			t.Errorf("unexpected decoded calldata %s", decoded)
+		}
+		circomProof, err := decoded.ToCircomProof()
+		if err != nil {
+			t.Fatalf("failed to convert to a Circom proof: %v", err)
+		}
+		signals := decoded.PublicSignals()
+		if !reflect.DeepEqual(signals, publicSignals) {
+			t.Errorf("unexpected public signals %v", signals)
+		}
+		gnarkProof, err := parser.ConvertCircomToGnark(vk, circomProof, signals)
+		if err != nil {
+			t.Fatalf("failed to convert decoded proof: %v", err)
+		}
+		if ok, err := parser.VerifyProof(gnarkProof); !ok || err != nil {
+			t.Errorf("decoded proof should verify: %v", err)
+		}
+	}
+
+	// The first 256 bytes are the (a, b, c) seal
+	sealProof, err := parser.DecodeSolidityProof(args[:256])
+	if err != nil {
+		t.Fatalf("failed to decode seal: %v", err)
+	}
+	got, err := sealProof.ToGnarkProof()
+	if err != nil {
+		t.Fatalf("failed to convert seal: %v", err)
+	}
+	want, err := parser.ConvertProof(proof)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	if !got.Ar.Equal(&want.Ar) || !got.Bs.Equal(&want.Bs) || !got.Krs.Equal(&want.Krs) {
+		t.Errorf("decoded seal does not match the proof")
+	}
+
+	// Malformed data
+	word := func(data []byte, i int, v []byte) []byte {
+		out := append([]byte(nil), data...)
+		copy(out[32*i:32*(i+1)], v)
+		return out
+	}
+	modulus := fp.Modulus().FillBytes(make([]byte, 32))
+	one := make([]byte, 32)
+	one[31] = 1
+	tests := []struct {
+		name string
+		data []byte
+		kind error
+		path string
+	}{
+		{"unreduced coordinate", word(args, 0, modulus), parser.ErrInvalidPoint, "proof.pi_a[0]"},
+		{"not on the curve", word(args, 7, one), parser.ErrInvalidPoint, "proof.pi_c"},
+		{"twisted coordinates", word(args, 2, one), parser.ErrInvalidPoint, "proof.pi_b"},
+		{"unreduced public input", word(args, 8, fr.Modulus().FillBytes(make([]byte, 32))), parser.ErrInvalidScalar, "publicSignals[0]"},
+	}
+	for _, tc := range tests {
+		if _, err := parser.DecodeSolidityCalldata(tc.data); !errors.Is(err, tc.kind) || errorPath(err) != tc.path {
+			t.Errorf("%s: expected %v at %s, got %v", tc.name, tc.kind, tc.path, err)
+		}
+	}
+	for _, data := range [][]byte{args[:255], args[:224], append(args, 0)} {
+		if _, err := parser.DecodeSolidityCalldata(data); !errors.Is(err, parser.ErrMalformedBinary) {
+			t.Errorf("expected malformed binary data for %d bytes of calldata, got %v", len(data), err)
+		}
+	}
+	if _, err := parser.DecodeSolidityProof(args); !errors.Is(err, parser.ErrMalformedBinary) || errorPath(err) != "proof" {
+		t.Errorf("expected malformed binary data at proof for a seal with public inputs, got %v", err)
+	}
+
+	// The function selector must be the one of verifyProof
+	foreign := append([]byte(nil), call...)
+	foreign[0] ^= 1
+	if _, err := parser.DecodeSolidityCalldata(foreign); !errors.Is(err, parser.ErrMalformedBinary) || errorPath(err) != "selector" {
+		t.Errorf("expected malformed binary data at selector, got %v", err)
+	}
+}
+
+func TestGroth16SolidityProofDecode(t *testing.T) {
+	for _, nbCommitments := range []int{0, 1, 2} {
+		t.Run(fmt.Sprintf("commitments=%d", nbCommitments), func(t *testing.T) {
+			proof, vk, w := proveCommitCircuit(t, nbCommitments)
+			var solProof parser.Groth16SolidityProof
+			if err := solProof.FromGnarkProof(proof, w); err != nil {
+				t.Fatalf("failed to convert proof: %v", err)
+			}
+			call, err := solProof.ABIEncodeCall()
+			if err != nil {
+				t.Fatalf("failed to encode call: %v", err)
+			}
+
+			var decoded parser.Groth16SolidityProof
+			if err := decoded.ABIDecode(call, nbCommitments); err != nil {
+				t.Fatalf("failed to decode proof: %v", err)
+			}
+			// The selector tells the number of commitments apart
+			if nbCommitments > 0 {
+				if err := decoded.ABIDecode(call, 0); !errors.Is(err, parser.ErrMalformedBinary) {
+					t.Errorf("expected malformed binary data for a call with commitments, got %v", err)
+				}
+			}
+			if !reflect.DeepEqual(decoded.PublicSignals(), []string{"3", "21"}) {
+				t.Errorf("unexpected public signals %v", decoded.PublicSignals())
+			}
+			gnarkProof, err := decoded.ToGnarkProof()
+			if err != nil {
+				t.Fatalf("failed to convert decoded proof: %v", err)
+			}
+			publicWitness, err := w.Public()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := groth16.Verify(gnarkProof, vk, publicWitness,
+				solidity.WithVerifierTargetSolidityVerifier(backend.GROTH16)); err != nil {
+				t.Errorf("decoded proof should verify: %v", err)
+			}
+
+			// Circom proofs have no commitments
+			circomProof, err := decoded.ToCircomProof()
+			if nbCommitments > 0 {
+				if err == nil {
+					t.Errorf("expected error for a Circom proof with commitments")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to convert to a Circom proof: %v", err)
+			}
+			_, circomVk, _, err := parser.ConvertGnarkToCircom(proof, vk, publicWitness)
+			if err != nil {
+				t.Fatalf("failed to convert verifying key: %v", err)
+			}
+			circomGnarkProof, err := parser.ConvertCircomToGnark(circomVk, circomProof, decoded.PublicSignals())
+			if err != nil {
+				t.Fatalf("failed to convert Circom proof: %v", err)
+			}
+			if ok, err := parser.VerifyProof(circomGnarkProof); !ok || err != nil {
+				t.Errorf("decoded Circom proof should verify: %v", err)
+			}
+		})
+	}
+
+	var decoded parser.Groth16SolidityProof
+	for _, nbCommitments := range []int{-1, 9, math.MaxInt / 16, math.MaxInt} {
+		if err := decoded.ABIDecode(make([]byte, 256), nbCommitments); !errors.Is(err, parser.ErrMalformedBinary) || errorPath(err) != "calldata" {
+			t.Errorf("expected malformed binary data at calldata for %d commitments, got %v", nbCommitments, err)
+		}
+	}
+	if err := decoded.ABIDecode(make([]byte, 256), 1); !errors.Is(err, parser.ErrMalformedBinary) {
+		t.Errorf("expected malformed binary data for missing commitments, got %v", err)
+	}
+}
+
+func TestGroth16CommitmentProofDecode(t *testing.T) {
+	proof, vk, w := proveCommitCircuit(t, 1)
+	var cmtProof parser.Groth16CommitmentProof
+	if err := cmtProof.FromGnarkProof(proof); err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	encoded, err := cmtProof.ABIEncode()
+	if err != nil {
+		t.Fatalf("failed to encode proof: %v", err)
+	}
+
+	var decoded parser.Groth16CommitmentProof
+	if err := decoded.ABIDecode(encoded); err != nil {
+		t.Fatalf("failed to decode proof: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, cmtProof) {
+		t.Errorf("decoded proof %v differs from %v", decoded, cmtProof)
+	}
+	reencoded, err := decoded.ABIEncode()
+	if err != nil {
+		t.Fatalf("failed to encode decoded proof: %v", err)
+	}
+	if !reflect.DeepEqual(reencoded, encoded) {
+		t.Errorf("unexpected ABI encoding %x", reencoded)
+	}
+
+	// The decoded proof verifies with the public inputs
+	var solProof parser.Groth16SolidityProof
+	if err := solProof.FromGnarkProof(proof, w); err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	solProof.Proof = decoded.Proof
+	solProof.Commitments = decoded.Commitments[:]
+	solProof.CommitmentPok = decoded.CommitmentPok
+	gnarkProof, err := solProof.ToGnarkProof()
+	if err != nil {
+		t.Fatalf("failed to convert decoded proof: %v", err)
+	}
+	publicWitness, err := w.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(gnarkProof, vk, publicWitness,
+		solidity.WithVerifierTargetSolidityVerifier(backend.GROTH16)); err != nil {
+		t.Errorf("decoded proof should verify: %v", err)
+	}
+
+	// Invalid sizes and points
+	if err := decoded.ABIDecode(encoded[:len(encoded)-32]); !errors.Is(err, parser.ErrMalformedBinary) || errorPath(err) != "proof" {
+		t.Errorf("expected malformed binary data at proof for a truncated proof, got %v", err)
+	}
+	twist := twistG2NotInSubgroup(t)
+	for _, tc := range []struct {
+		word int
+		set  func(b []byte)
+		path string
+	}{
+		{0, func(b []byte) { fp.Modulus().FillBytes(b[:32]) }, "proof.pi_a[0]"},
+		{9, func(b []byte) { b[31] ^= 1 }, "proof.commitments[0]"},
+		{11, func(b []byte) { b[31] ^= 1 }, "proof.commitment_pok"},
+		{2, func(b []byte) {
+			twist.X.A1.BigInt(new(big.Int)).FillBytes(b[:32])
+			twist.X.A0.BigInt(new(big.Int)).FillBytes(b[32:64])
+			twist.Y.A1.BigInt(new(big.Int)).FillBytes(b[64:96])
+			twist.Y.A0.BigInt(new(big.Int)).FillBytes(b[96:128])
+		}, "proof.pi_b"},
+	} {
+		bad := append([]byte(nil), encoded...)
+		tc.set(bad[32*tc.word:])
+		before := decoded
+		if err := decoded.ABIDecode(bad); !errors.Is(err, parser.ErrInvalidPoint) || errorPath(err) != tc.path {
+			t.Errorf("expected invalid point at %s, got %v", tc.path, err)
+		}
+		if !reflect.DeepEqual(decoded, before) {
+			t.Errorf("%s: proof changed on error", tc.path)
+		}
+	}
+}
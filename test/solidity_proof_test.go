@@ -0,0 +1,185 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+// commitCircuit checks that Y = X·Z, with NbCommitments Pedersen commitments
+// to Z.
+type commitCircuit struct {
+	X, Y frontend.Variable `gnark:",public"`
+	Z    frontend.Variable
+
+	NbCommitments int `gnark:"-"`
+}
+
+func (c *commitCircuit) Define(api frontend.API) error {
+	for i := 0; i < c.NbCommitments; i++ {
+		cmt, err := api.(frontend.Committer).Commit(c.Z, i)
+		if err != nil {
+			return err
+		}
+		api.AssertIsDifferent(cmt, 0)
+	}
+	api.AssertIsEqual(c.Y, api.Mul(c.X, c.Z))
+	return nil
+}
+
+// proveCommitCircuit proves commitCircuit with nbCommitments commitments for
+// the Solidity verifier.
+func proveCommitCircuit(t *testing.T, nbCommitments int) (groth16.Proof, groth16.VerifyingKey, witness.Witness) {
+	t.Helper()
+	return proveCommitCircuitWith(t, nbCommitments, solidity.WithProverTargetSolidityVerifier(backend.GROTH16))
+}
+
+// proveCommitCircuitWith proves commitCircuit with nbCommitments commitments
+// and the given prover options.
+func proveCommitCircuitWith(t *testing.T, nbCommitments int, opts ...backend.ProverOption) (groth16.Proof, groth16.VerifyingKey, witness.Witness) {
+	t.Helper()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &commitCircuit{NbCommitments: nbCommitments})
+	if err != nil {
+		t.Fatalf("failed to compile circuit: %v", err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	w, err := frontend.NewWitness(&commitCircuit{X: 3, Y: 21, Z: 7}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("failed to create witness: %v", err)
+	}
+	proof, err := groth16.Prove(ccs, pk, w, opts...)
+	if err != nil {
+		t.Fatalf("proving failed: %v", err)
+	}
+	return proof, vk, w
+}
+
+// commitGnarkProof proves commitCircuit with nbCommitments commitments for
+// VerifyProof.
+func commitGnarkProof(t *testing.T, nbCommitments int) *parser.GnarkProof {
+	t.Helper()
+	proof, vk, w := proveCommitCircuitWith(t, nbCommitments)
+	publicWitness, err := w.Public()
+	if err != nil {
+		t.Fatalf("failed to get public witness: %v", err)
+	}
+	return &parser.GnarkProof{
+		Proof:        proof.(*groth16_bn254.Proof),
+		VerifyingKey: vk.(*groth16_bn254.VerifyingKey),
+		PublicInputs: publicWitness.Vector().(fr.Vector),
+	}
+}
+
+func TestGroth16SolidityProof(t *testing.T) {
+	for _, nbCommitments := range []int{0, 1, 2} {
+		t.Run(fmt.Sprintf("commitments=%d", nbCommitments), func(t *testing.T) {
+			proof, vk, w := proveCommitCircuit(t, nbCommitments)
+
+			var solProof parser.Groth16SolidityProof
+			if err := solProof.FromGnarkProof(proof, w); err != nil {
+				t.Fatalf("failed to convert proof: %v", err)
+			}
+			if len(solProof.Commitments) != 2*nbCommitments {
+				t.Errorf("expected %d commitment coordinates, got %d", 2*nbCommitments, len(solProof.Commitments))
+			}
+			if len(solProof.PublicInputs) != 2 || solProof.PublicInputs[0].Int64() != 3 || solProof.PublicInputs[1].Int64() != 21 {
+				t.Errorf("unexpected public inputs %v", solProof.PublicInputs)
+			}
+
+			// The arguments match the verifyProof function of the exported verifier
+			var contract bytes.Buffer
+			if err := vk.ExportSolidity(&contract); err != nil {
+				t.Fatalf("failed to export verifier: %v", err)
+			}
+			signature := "function verifyProof(\n        uint256[8] calldata proof,\n"
+			if nbCommitments > 0 {
+				signature += fmt.Sprintf("        uint256[%d] calldata commitments,\n", 2*nbCommitments) +
+					"        uint256[2] calldata commitmentPok,\n"
+			}
+			signature += "        uint256[2] calldata input\n"
+			if !strings.Contains(contract.String(), signature) {
+				t.Errorf("exported verifier does not have the verifyProof function:\n%s", signature)
+			}
+
+			// All the arguments are static, so they are packed as 32-byte words
+			p := solProof.Proof
+			words := []*big.Int{p.Ar[0], p.Ar[1], p.Bs[0][0], p.Bs[0][1], p.Bs[1][0], p.Bs[1][1], p.Krs[0], p.Krs[1]}
+			if nbCommitments > 0 {
+				words = append(append(words, solProof.Commitments...), solProof.CommitmentPok[:]...)
+			}
+			words = append(words, solProof.PublicInputs...)
+			var want []byte
+			for _, x := range words {
+				want = append(want, x.FillBytes(make([]byte, 32))...)
+			}
+			encoded, err := solProof.ABIEncode()
+			if err != nil {
+				t.Fatalf("failed to encode proof: %v", err)
+			}
+			if !bytes.Equal(encoded, want) {
+				t.Errorf("unexpected ABI encoding %x", encoded)
+			}
+			call, err := solProof.ABIEncodeCall()
+			if err != nil {
+				t.Fatalf("failed to encode call: %v", err)
+			}
+			if len(call) != 4+len(want) || !bytes.Equal(call[4:], want) {
+				t.Errorf("unexpected call encoding %x", call)
+			}
+		})
+	}
+
+	var solProof parser.Groth16SolidityProof
+	if err := solProof.FromGnarkProof(nil, nil); err == nil {
+		t.Errorf("expected error for a nil proof")
+	}
+}
+
+func TestGroth16CommitmentProof(t *testing.T) {
+	for _, nbCommitments := range []int{0, 2} {
+		proof, _, _ := proveCommitCircuit(t, nbCommitments)
+		var cmtProof parser.Groth16CommitmentProof
+		if err := cmtProof.FromGnarkProof(proof); err == nil {
+			t.Errorf("expected error for %d commitments", nbCommitments)
+		}
+	}
+
+	// With one commitment it matches Groth16SolidityProof
+	proof, _, w := proveCommitCircuit(t, 1)
+	var cmtProof parser.Groth16CommitmentProof
+	if err := cmtProof.FromGnarkProof(proof); err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	var solProof parser.Groth16SolidityProof
+	if err := solProof.FromGnarkProof(proof, w); err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	encoded, err := cmtProof.ABIEncode()
+	if err != nil {
+		t.Fatalf("failed to encode proof: %v", err)
+	}
+	want, err := solProof.ABIEncode()
+	if err != nil {
+		t.Fatalf("failed to encode proof: %v", err)
+	}
+	// The public inputs are the last two words
+	if !bytes.Equal(encoded, want[:len(want)-64]) {
+		t.Errorf("unexpected ABI encoding %x", encoded)
+	}
+}
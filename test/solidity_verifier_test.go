@@ -0,0 +1,396 @@
+package test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestExportCircomSolidityVerifier(t *testing.T) {
+	vk, proof, publicSignals := loadCircomData(t)
+	calldata, err := parser.ConvertCircomToSolidityCalldata(proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	wrongCalldata := *calldata
+	wrongCalldata.PublicInputs = []*big.Int{new(big.Int).Add(calldata.PublicInputs[0], big.NewInt(1))}
+
+	var buf bytes.Buffer
+	if err := parser.ExportCircomSolidityVerifier(vk, &buf); err != nil {
+		t.Fatalf("failed to export verifier: %v", err)
+	}
+	contract := buf.String()
+	// The constants are the decimal coordinates of the key, with the G2
+	// coordinates imaginary part first as in the SnarkJS verifiers.
+	want := []string{
+		"pragma solidity >=0.7.0 <0.9.0;",
+		"contract Groth16Verifier {",
+		"function verifyProof(uint[2] calldata _pA, uint[2][2] calldata _pB, uint[2] calldata _pC, uint[1] calldata _pubSignals) public view returns (bool)",
+		fmt.Sprintf("uint256 constant alphax  = %s;", vk.VkAlpha1[0]),
+		fmt.Sprintf("uint256 constant betax1  = %s;", vk.VkBeta2[0][1]),
+		fmt.Sprintf("uint256 constant betay2  = %s;", vk.VkBeta2[1][0]),
+		fmt.Sprintf("uint256 constant gammax2 = %s;", vk.VkGamma2[0][0]),
+		fmt.Sprintf("uint256 constant deltay1 = %s;", vk.VkDelta2[1][1]),
+		fmt.Sprintf("uint256 constant IC1y = %s;", vk.IC[1][1]),
+		"g1_mulAccC(_pVk, IC1x, IC1y, calldataload(add(pubSignals, 0)))",
+		"checkField(calldataload(add(_pubSignals, 0)))",
+	}
+	for _, s := range want {
+		if !strings.Contains(contract, s) {
+			t.Errorf("contract does not contain %q", s)
+		}
+	}
+	if strings.Contains(contract, "IC2x") || strings.Contains(contract, "verifyCompressedProof") {
+		t.Errorf("unexpected content in the SnarkJS verifier")
+	}
+	checkSolidityBraces(t, contract)
+	// The pairing check of the contract, with its constants
+	if !snarkjsContractVerify(t, contract, calldata) {
+		t.Errorf("the SnarkJS verifier should accept the proof")
+	}
+	if snarkjsContractVerify(t, contract, &wrongCalldata) {
+		t.Errorf("the SnarkJS verifier should reject wrong public signals")
+	}
+
+	buf.Reset()
+	if err := parser.ExportCircomSolidityVerifier(vk, &buf, parser.WithGnarkInterface(),
+		parser.WithSolidityPragmaVersion("0.8.24")); err != nil {
+		t.Fatalf("failed to export gnark verifier: %v", err)
+	}
+	contract = buf.String()
+	want = []string{
+		"pragma solidity 0.8.24;",
+		"contract Verifier {",
+		"function verifyProof(\n        uint256[8] calldata proof,\n        uint256[1] calldata input",
+		"function verifyCompressedProof(",
+		"function compressProof(",
+		"uint256[1] calldata _pubSignals\n    ) public view returns (bool)",
+		// The wrapper calls the 2-argument overload with the gnark encoding
+		"try this.verifyProof(proof, _pubSignals) {",
+		fmt.Sprintf("uint256 constant ALPHA_X = %s;", vk.VkAlpha1[0]),
+	}
+	for _, s := range want {
+		if !strings.Contains(contract, s) {
+			t.Errorf("gnark contract does not contain %q", s)
+		}
+	}
+	if !strings.HasSuffix(contract, "    }\n}\n") {
+		t.Errorf("the SnarkJS interface should be inside the contract")
+	}
+	checkSolidityBraces(t, contract)
+	if !gnarkContractVerify(t, contract, calldata) {
+		t.Errorf("the gnark verifier should accept the proof through the SnarkJS interface")
+	}
+	if gnarkContractVerify(t, contract, &wrongCalldata) {
+		t.Errorf("the gnark verifier should reject wrong public signals")
+	}
+
+	wrongCurve := *vk
+	wrongCurve.Curve = "bls12381"
+	if err := parser.ExportCircomSolidityVerifier(&wrongCurve, &buf); err == nil {
+		t.Errorf("expected error for a BLS12-381 key")
+	}
+	// uint256[0] is not a valid Solidity type
+	noPublic := *vk
+	noPublic.NPublic, noPublic.IC = 0, vk.IC[:1]
+	for _, opts := range [][]parser.SolidityExportOption{nil, {parser.WithGnarkInterface()}} {
+		if err := parser.ExportCircomSolidityVerifier(&noPublic, &buf, opts...); !errors.Is(err, parser.ErrNPublicMismatch) {
+			t.Errorf("expected public signals mismatch for a key without public signals, got %v", err)
+		}
+	}
+}
+
+// checkSolidityBraces checks that the braces of a contract are balanced.
+func checkSolidityBraces(t *testing.T, contract string) {
+	t.Helper()
+	depth := 0
+	for _, r := range contract {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+		if depth < 0 {
+			break
+		}
+	}
+	if depth != 0 {
+		t.Errorf("unbalanced braces in the contract")
+	}
+}
+
+var solidityConstantRegexp = regexp.MustCompile(`uint256 constant (\w+)\s*=\s*([0-9]+);`)
+
+// solidityConstants returns the decimal uint256 constants of a contract.
+func solidityConstants(t *testing.T, contract string) map[string]*big.Int {
+	t.Helper()
+	constants := make(map[string]*big.Int)
+	for _, m := range solidityConstantRegexp.FindAllStringSubmatch(contract, -1) {
+		v, ok := new(big.Int).SetString(m[2], 10)
+		if !ok {
+			t.Fatalf("invalid constant %s = %s", m[1], m[2])
+		}
+		constants[m[1]] = v
+	}
+	return constants
+}
+
+// precompileG1 and precompileG2 decode points as the EVM precompiles do: G2
+// coordinates are written imaginary part first.
+func precompileG1(t *testing.T, x, y *big.Int) curve.G1Affine {
+	t.Helper()
+	if x == nil || y == nil {
+		t.Fatalf("missing G1 constant")
+	}
+	var p curve.G1Affine
+	p.X.SetBigInt(x)
+	p.Y.SetBigInt(y)
+	if !p.IsOnCurve() {
+		t.Fatalf("G1 point (%s, %s) is not on the curve", x, y)
+	}
+	return p
+}
+
+func precompileG2(t *testing.T, x1, x0, y1, y0 *big.Int) curve.G2Affine {
+	t.Helper()
+	if x1 == nil || x0 == nil || y1 == nil || y0 == nil {
+		t.Fatalf("missing G2 constant")
+	}
+	var p curve.G2Affine
+	p.X.A1.SetBigInt(x1)
+	p.X.A0.SetBigInt(x0)
+	p.Y.A1.SetBigInt(y1)
+	p.Y.A0.SetBigInt(y0)
+	if !p.IsOnCurve() || !p.IsInSubGroup() {
+		t.Fatalf("G2 point is not on the curve")
+	}
+	return p
+}
+
+// snarkjsContractVerify runs the pairing check of the verifyProof function
+// of the SnarkJS template, with the constants of the contract:
+// e(-A, B)·e(α, β)·e(vk_x, γ)·e(C, δ) = 1.
+func snarkjsContractVerify(t *testing.T, contract string, calldata *parser.SolidityCalldata) bool {
+	t.Helper()
+	c := solidityConstants(t, contract)
+	for _, s := range calldata.PublicInputs {
+		if s.Cmp(fr.Modulus()) >= 0 {
+			return false
+		}
+	}
+	vkX := precompileG1(t, c["IC0x"], c["IC0y"])
+	for i, s := range calldata.PublicInputs {
+		ic := precompileG1(t, c[fmt.Sprintf("IC%dx", i+1)], c[fmt.Sprintf("IC%dy", i+1)])
+		var term curve.G1Affine
+		term.ScalarMultiplication(&ic, s)
+		vkX.Add(&vkX, &term)
+	}
+	p := calldata.Proof
+	a := precompileG1(t, p.Ar[0], new(big.Int).Mod(new(big.Int).Sub(fp.Modulus(), p.Ar[1]), fp.Modulus()))
+	b := precompileG2(t, p.Bs[0][0], p.Bs[0][1], p.Bs[1][0], p.Bs[1][1])
+	cPoint := precompileG1(t, p.Krs[0], p.Krs[1])
+	ok, err := curve.PairingCheck(
+		[]curve.G1Affine{a, precompileG1(t, c["alphax"], c["alphay"]), vkX, cPoint},
+		[]curve.G2Affine{
+			b,
+			precompileG2(t, c["betax1"], c["betax2"], c["betay1"], c["betay2"]),
+			precompileG2(t, c["gammax1"], c["gammax2"], c["gammay1"], c["gammay2"]),
+			precompileG2(t, c["deltax1"], c["deltax2"], c["deltay1"], c["deltay2"]),
+		})
+	if err != nil {
+		t.Fatalf("pairing check failed: %v", err)
+	}
+	return ok
+}
+
+// gnarkContractVerify runs the SnarkJS interface of the gnark verifier: the
+// proof is rearranged as by the wrapper, and checked as by the gnark
+// verifyProof function: e(A, B)·e(C, -δ)·e(α, -β)·e(L, -γ) = 1, with
+// L = CONSTANT + Σ s_i·PUB_i.
+func gnarkContractVerify(t *testing.T, contract string, calldata *parser.SolidityCalldata) bool {
+	t.Helper()
+	c := solidityConstants(t, contract)
+	p := calldata.Proof
+	// The elements of the uint256[8] proof array of the wrapper
+	start := strings.Index(contract, "uint256[8] memory proof = [")
+	end := strings.Index(contract[start:], "];")
+	if start < 0 || end < 0 {
+		t.Fatalf("the wrapper does not build the gnark proof")
+	}
+	args := map[string]*big.Int{
+		"_pA[0]": p.Ar[0], "_pA[1]": p.Ar[1],
+		"_pB[0][0]": p.Bs[0][0], "_pB[0][1]": p.Bs[0][1], "_pB[1][0]": p.Bs[1][0], "_pB[1][1]": p.Bs[1][1],
+		"_pC[0]": p.Krs[0], "_pC[1]": p.Krs[1],
+	}
+	var proof []*big.Int
+	for _, e := range strings.Split(contract[start+len("uint256[8] memory proof = ["):start+end], ",") {
+		v, ok := args[strings.TrimSpace(e)]
+		if !ok {
+			t.Fatalf("unexpected proof element %q", e)
+		}
+		proof = append(proof, v)
+	}
+	if len(proof) != 8 {
+		t.Fatalf("expected 8 proof elements, got %d", len(proof))
+	}
+	l := precompileG1(t, c["CONSTANT_X"], c["CONSTANT_Y"])
+	for i, s := range calldata.PublicInputs {
+		if s.Cmp(fr.Modulus()) >= 0 {
+			return false
+		}
+		pub := precompileG1(t, c[fmt.Sprintf("PUB_%d_X", i)], c[fmt.Sprintf("PUB_%d_Y", i)])
+		var term curve.G1Affine
+		term.ScalarMultiplication(&pub, s)
+		l.Add(&l, &term)
+	}
+	ok, err := curve.PairingCheck(
+		[]curve.G1Affine{
+			precompileG1(t, proof[0], proof[1]),
+			precompileG1(t, proof[6], proof[7]),
+			precompileG1(t, c["ALPHA_X"], c["ALPHA_Y"]),
+			l,
+		},
+		[]curve.G2Affine{
+			precompileG2(t, proof[2], proof[3], proof[4], proof[5]),
+			precompileG2(t, c["DELTA_NEG_X_1"], c["DELTA_NEG_X_0"], c["DELTA_NEG_Y_1"], c["DELTA_NEG_Y_0"]),
+			precompileG2(t, c["BETA_NEG_X_1"], c["BETA_NEG_X_0"], c["BETA_NEG_Y_1"], c["BETA_NEG_Y_0"]),
+			precompileG2(t, c["GAMMA_NEG_X_1"], c["GAMMA_NEG_X_0"], c["GAMMA_NEG_Y_1"], c["GAMMA_NEG_Y_0"]),
+		})
+	if err != nil {
+		t.Fatalf("pairing check failed: %v", err)
+	}
+	return ok
+}
+
+// TestCircomSolidityVerifierEVM compiles both exported verifiers with solc
+// and runs them in the go-ethereum EVM. It is skipped when solc is not in the
+// PATH.
+func TestCircomSolidityVerifierEVM(t *testing.T) {
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Skip("solc not found in PATH")
+	}
+	vk, proof, publicSignals := loadCircomData(t)
+	calldata, err := parser.ConvertCircomToSolidityCalldata(proof, publicSignals)
+	if err != nil {
+		t.Fatalf("failed to convert proof: %v", err)
+	}
+	wrongCalldata := *calldata
+	wrongCalldata.PublicInputs = []*big.Int{new(big.Int).Add(calldata.PublicInputs[0], big.NewInt(1))}
+
+	for _, tc := range []struct {
+		name string
+		opts []parser.SolidityExportOption
+	}{
+		{"snarkjs", nil},
+		{"gnark", []parser.SolidityExportOption{parser.WithGnarkInterface()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := parser.ExportCircomSolidityVerifier(vk, &buf, tc.opts...); err != nil {
+				t.Fatalf("failed to export verifier: %v", err)
+			}
+			cfg, address := deploySolidity(t, solc, buf.Bytes())
+			// The SnarkJS interface returns whether the proof is valid
+			for _, c := range []struct {
+				calldata *parser.SolidityCalldata
+				valid    bool
+			}{{calldata, true}, {&wrongCalldata, false}} {
+				input, err := c.calldata.ABIEncodeCall()
+				if err != nil {
+					t.Fatalf("failed to encode call: %v", err)
+				}
+				ret, _, err := runtime.Call(address, input, cfg)
+				if err != nil {
+					t.Fatalf("verifyProof reverted: %v", err)
+				}
+				if len(ret) != 32 {
+					t.Fatalf("unexpected verifyProof result %x", ret)
+				}
+				if valid := ret[31] == 1; valid != c.valid {
+					t.Errorf("verifyProof returned %v, want %v", valid, c.valid)
+				}
+			}
+			if tc.opts == nil {
+				return
+			}
+			// The gnark interface reverts on invalid proofs
+			for _, c := range []struct {
+				calldata *parser.SolidityCalldata
+				valid    bool
+			}{{calldata, true}, {&wrongCalldata, false}} {
+				gnarkProof := &parser.Groth16SolidityProof{Proof: c.calldata.Proof, PublicInputs: c.calldata.PublicInputs}
+				input, err := gnarkProof.ABIEncodeCall()
+				if err != nil {
+					t.Fatalf("failed to encode call: %v", err)
+				}
+				if _, _, err := runtime.Call(address, input, cfg); (err == nil) != c.valid {
+					t.Errorf("gnark verifyProof: got error %v, valid proof %v", err, c.valid)
+				}
+			}
+		})
+	}
+}
+
+// deploySolidity compiles a contract with solc and deploys it in an
+// in-memory EVM. Istanbul is the latest fork of the go-ethereum version of the
+// module, so the contract is compiled for it.
+func deploySolidity(t *testing.T, solc string, contract []byte) (*runtime.Config, common.Address) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "Verifier.sol")
+	if err := os.WriteFile(path, contract, 0o644); err != nil {
+		t.Fatalf("failed to write contract: %v", err)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(solc, "--evm-version", "istanbul", "--combined-json", "bin", path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("failed to compile contract: %v\n%s", err, stderr.String())
+	}
+	var compiled struct {
+		Contracts map[string]struct {
+			Bin string `json:"bin"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(out, &compiled); err != nil {
+		t.Fatalf("failed to parse solc output: %v", err)
+	}
+	var bins []string
+	for _, c := range compiled.Contracts {
+		if c.Bin != "" {
+			bins = append(bins, c.Bin)
+		}
+	}
+	if len(bins) != 1 {
+		t.Fatalf("expected one deployable contract, got %d", len(bins))
+	}
+	code, err := hex.DecodeString(bins[0])
+	if err != nil {
+		t.Fatalf("failed to decode bytecode: %v", err)
+	}
+	cfg := &runtime.Config{ChainConfig: params.AllEthashProtocolChanges}
+	_, address, _, err := runtime.Create(code, cfg)
+	if err != nil {
+		t.Fatalf("failed to deploy contract: %v", err)
+	}
+	return cfg, address
+}
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestValidate(t *testing.T) {
+	vk, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(t, "circom_data/vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	proof, err := parser.UnmarshalCircomProofJSON(loadFile(t, "circom_data/proof.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal proof: %v", err)
+	}
+	publicSignals, err := parser.UnmarshalCircomPublicSignalsJSON(loadFile(t, "circom_data/public_signals.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal public signals: %v", err)
+	}
+	if err := parser.ValidateTriple(vk, proof, publicSignals); err != nil {
+		t.Fatalf("valid inputs should pass: %v", err)
+	}
+
+	// Verification keys
+	for _, tc := range []struct {
+		name   string
+		tamper func(vk *parser.CircomVerificationKey)
+		kind   error
+		path   string
+	}{
+		{"protocol", func(vk *parser.CircomVerificationKey) { vk.Protocol = "plonk" }, parser.ErrWrongProtocol, "vk.protocol"},
+		{"curve", func(vk *parser.CircomVerificationKey) { vk.Curve = "secp256k1" }, parser.ErrWrongCurve, "vk.curve"},
+		{"IC", func(vk *parser.CircomVerificationKey) { vk.IC = vk.IC[:1] }, parser.ErrNPublicMismatch, "vk.nPublic"},
+		{"IC shape", func(vk *parser.CircomVerificationKey) { vk.IC[0] = vk.IC[0][:2] }, parser.ErrInvalidPoint, "vk.IC[0]"},
+		{"gamma shape", func(vk *parser.CircomVerificationKey) { vk.VkGamma2[2] = nil }, parser.ErrInvalidPoint, "vk.vk_gamma_2[2]"},
+		{"alphabeta shape", func(vk *parser.CircomVerificationKey) { vk.VkAlphabeta12[0] = vk.VkAlphabeta12[0][:2] }, parser.ErrInvalidPoint, "vk.vk_alphabeta_12[0]"},
+	} {
+		bad, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(t, "circom_data/vkey.json"))
+		if err != nil {
+			t.Fatalf("failed to unmarshal verification key: %v", err)
+		}
+		tc.tamper(bad)
+		if err := bad.Validate(); !errors.Is(err, tc.kind) || errorPath(err) != tc.path {
+			t.Errorf("%s: expected %v at %s, got %v", tc.name, tc.kind, tc.path, err)
+		}
+		if _, err := parser.ConvertVerificationKey(bad); err == nil {
+			t.Errorf("%s: conversion should fail", tc.name)
+		}
+	}
+
+	// The value of vk_alphabeta_12 is only checked on demand
+	if err := vk.ValidateAlphabeta12(); err != nil {
+		t.Errorf("valid vk_alphabeta_12 should pass: %v", err)
+	}
+	badAlphabeta, err := parser.UnmarshalCircomVerificationKeyJSON(loadFile(t, "circom_data/vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	badAlphabeta.VkAlphabeta12[1][2] = []string{badAlphabeta.VkAlphabeta12[1][2][1], badAlphabeta.VkAlphabeta12[1][2][0]}
+	if err := badAlphabeta.Validate(); err != nil {
+		t.Errorf("Validate should not compute vk_alphabeta_12: %v", err)
+	}
+	if err := badAlphabeta.ValidateAlphabeta12(); !errors.Is(err, parser.ErrInvalidPoint) || errorPath(err) != "vk.vk_alphabeta_12" {
+		t.Errorf("expected invalid point at vk.vk_alphabeta_12, got %v", err)
+	}
+	if _, err := parser.NewVerifierRegistry(0).Register(badAlphabeta); !errors.Is(err, parser.ErrInvalidPoint) {
+		t.Errorf("expected the registry to reject vk_alphabeta_12, got %v", err)
+	}
+
+	// vk_alphabeta_12 is optional
+	noAlphabeta := *vk
+	noAlphabeta.VkAlphabeta12 = nil
+	if err := noAlphabeta.ValidateAlphabeta12(); err != nil {
+		t.Errorf("a key without vk_alphabeta_12 should pass: %v", err)
+	}
+
+	// An empty protocol stands for groth16, as for keys and proofs built in Go
+	noProtocol := *vk
+	noProtocol.Protocol = ""
+	noProtocolProof := *proof
+	noProtocolProof.Protocol = ""
+	if err := parser.ValidateTriple(&noProtocol, &noProtocolProof, publicSignals); err != nil {
+		t.Errorf("an empty protocol should pass: %v", err)
+	}
+
+	// Proofs
+	bad := *proof
+	bad.Protocol = "fflonk"
+	if err := bad.Validate(); !errors.Is(err, parser.ErrWrongProtocol) {
+		t.Errorf("expected wrong protocol, got %v", err)
+	}
+	bad = *proof
+	bad.PiB = bad.PiB[:2]
+	if err := bad.Validate(); !errors.Is(err, parser.ErrInvalidPoint) || errorPath(err) != "proof.pi_b" {
+		t.Errorf("expected invalid point at proof.pi_b, got %v", err)
+	}
+
+	// Triples
+	bad = *proof
+	bad.Curve = "bls12381"
+	if err := parser.ValidateTriple(vk, &bad, publicSignals); !errors.Is(err, parser.ErrWrongCurve) {
+		t.Errorf("expected wrong curve, got %v", err)
+	}
+	if err := parser.ValidateTriple(vk, proof, nil); !errors.Is(err, parser.ErrNPublicMismatch) {
+		t.Errorf("expected public signals mismatch, got %v", err)
+	}
+	if err := parser.ValidateTriple(vk, proof, []string{"-1"}); !errors.Is(err, parser.ErrInvalidScalar) {
+		t.Errorf("expected invalid scalar, got %v", err)
+	}
+	if err := parser.ValidateTriple(nil, proof, publicSignals); err == nil {
+		t.Errorf("expected error for a missing verification key")
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+// acceptAll is a broken verifier that accepts every proof.
+type acceptAll struct{}
+
+func (acceptAll) Name() string { return "accept-all" }
+
+func (acceptAll) Verify(*parser.CircomVerificationKey, *parser.CircomProof, []string) (bool, error) {
+	return true, nil
+}
+
+func TestVerifiers(t *testing.T) {
+	vk, proof, publicSignals := loadCircomData(t)
+	wrongSignals := append([]string(nil), publicSignals...)
+	wrongSignals[0] = "1"
+
+	verifiers := []parser.Verifier{parser.GnarkVerifier{}, parser.GoSnarkVerifier{}, parser.NewDifferentialVerifier()}
+	for _, v := range verifiers {
+		if ok, err := v.Verify(vk, proof, publicSignals); !ok || err != nil {
+			t.Errorf("%s: proof should verify: %v", v.Name(), err)
+		}
+		if ok, err := v.Verify(vk, proof, wrongSignals); ok || !errors.Is(err, parser.ErrVerificationFailed) {
+			t.Errorf("%s: expected verification failure, got %v", v.Name(), err)
+		}
+		if ok, err := v.Verify(vk, proof, []string{"-1"}); ok || err == nil {
+			t.Errorf("%s: expected error for malformed public signals", v.Name())
+		}
+	}
+
+	// Disagreements are reported
+	d := parser.NewDifferentialVerifier(parser.GnarkVerifier{}, acceptAll{})
+	if ok, err := d.Verify(vk, proof, publicSignals); !ok || err != nil {
+		t.Errorf("%s: proof should verify: %v", d.Name(), err)
+	}
+	if ok, err := d.Verify(vk, proof, wrongSignals); ok || !errors.Is(err, parser.ErrVerifierMismatch) {
+		t.Errorf("%s: expected mismatch, got %v", d.Name(), err)
+	}
+	if _, err := (&parser.DifferentialVerifier{}).Verify(vk, proof, publicSignals); err == nil {
+		t.Errorf("expected error without verifiers")
+	}
+
+	// Self-check of ConvertGnarkToCircom
+	var circuit Circuit
+	cs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+	if err != nil {
+		t.Fatalf("failed to compile circuit: %v", err)
+	}
+	pk, gnarkVk, err := groth16.Setup(cs)
+	if err != nil {
+		t.Fatalf("groth16 setup failed: %v", err)
+	}
+	witnessFull, err := frontend.NewWitness(&Circuit{X: 2, E: 12, Y: 4096}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("failed to create witness: %v", err)
+	}
+	publicWitness, err := witnessFull.Public()
+	if err != nil {
+		t.Fatalf("failed to get public witness: %v", err)
+	}
+	gnarkProof, err := groth16.Prove(cs, pk, witnessFull)
+	if err != nil {
+		t.Fatalf("groth16 proving failed: %v", err)
+	}
+	circomProof, circomVk, circomPub, err := parser.ConvertGnarkToCircom(gnarkProof, gnarkVk, publicWitness)
+	if err != nil {
+		t.Fatalf("conversion to Circom format failed: %v", err)
+	}
+	if ok, err := parser.NewDifferentialVerifier().Verify(circomVk, circomProof, circomPub); !ok || err != nil {
+		t.Errorf("converted proof should verify: %v", err)
+	}
+
+	// go-snark parses the inputs itself, and its panics are reported as errors
+	shortB := *proof
+	shortB.PiB = [][]string{{"1"}, proof.PiB[1], proof.PiB[2]}
+	if ok, err := (parser.GoSnarkVerifier{}).Verify(vk, &shortB, publicSignals); ok || !errors.Is(err, parser.ErrInternal) {
+		t.Errorf("expected internal error for a malformed proof, got %v", err)
+	}
+	if ok, err := (parser.GoSnarkVerifier{}).Verify(vk, proof, []string{"0x"}); ok || !errors.Is(err, parser.ErrInvalidScalar) {
+		t.Errorf("expected invalid scalar, got %v", err)
+	}
+
+	// go-snark only supports bn128
+	blsVk := *vk
+	blsVk.Curve = "bls12381"
+	if ok, err := (parser.GoSnarkVerifier{}).Verify(&blsVk, proof, publicSignals); ok || err == nil {
+		t.Errorf("expected error for a BLS12-381 key")
+	}
+}
@@ -0,0 +1,380 @@
+package test
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/circom2gnark/witnesscalc"
+)
+
+func TestWitnessCalculator(t *testing.T) {
+	calc, err := witnesscalc.New(echoCircuitWasm(), witnesscalc.WithSanityCheck())
+	if err != nil {
+		t.Fatalf("failed to load circuit: %v", err)
+	}
+	defer calc.Close()
+
+	inputs, err := witnesscalc.ParseInputsJSON([]byte(`{"a": 3, "b": ["0x5", "7"]}`))
+	if err != nil {
+		t.Fatalf("failed to parse inputs: %v", err)
+	}
+	w, err := calc.CalculateWitness(inputs)
+	if err != nil {
+		t.Fatalf("failed to calculate witness: %v", err)
+	}
+	if len(w.Values) != 4 {
+		t.Fatalf("expected 4 values, got %d", len(w.Values))
+	}
+	for i, expected := range []uint64{1, 3, 5, 7} {
+		if e := fr.NewElement(expected); !w.Values[i].Equal(&e) {
+			t.Errorf("value %d: expected %d, got %s", i, expected, w.Values[i].String())
+		}
+	}
+
+	// Negative values are reduced modulo the scalar field.
+	gw, publicSignals, err := calc.CalculateGnarkWitness(map[string]any{"a": -1, "b": []int{5, 7}}, 1)
+	if err != nil {
+		t.Fatalf("failed to calculate gnark witness: %v", err)
+	}
+	var minusOne fr.Element
+	minusOne.SetInt64(-1)
+	if len(publicSignals) != 1 || publicSignals[0] != new(big.Int).Sub(fr.Modulus(), big.NewInt(1)).String() {
+		t.Errorf("unexpected public signals %v", publicSignals)
+	}
+	if vec := gw.Vector().(fr.Vector); len(vec) != 3 || !vec[0].Equal(&minusOne) {
+		t.Errorf("unexpected gnark witness %v", vec)
+	}
+
+	for name, tc := range map[string]struct {
+		inputs map[string]any
+		err    string
+	}{
+		"assert":        {map[string]any{"a": 0, "b": []int{5, 7}}, "assert failed"},
+		"unknown":       {map[string]any{"a": 3, "b": []int{5, 7}, "c": 1}, "not found"},
+		"too many":      {map[string]any{"a": 3, "b": []int{5, 7, 9}}, "expects 2 values"},
+		"missing":       {map[string]any{"b": []int{5, 7}}, "not all inputs"},
+		"invalid value": {map[string]any{"a": "three", "b": []int{5, 7}}, "invalid input a"},
+		"octal":         {map[string]any{"a": "0o7", "b": []int{5, 7}}, "invalid input a"},
+		"binary":        {map[string]any{"a": "0b1", "b": []int{5, 7}}, "invalid input a"},
+		"underscore":    {map[string]any{"a": "1_0", "b": []int{5, 7}}, "invalid input a"},
+		"signed hex":    {map[string]any{"a": "0x-1", "b": []int{5, 7}}, "invalid input a"},
+		"hex prefix":    {map[string]any{"a": "0X0a", "b": []int{5, 7}}, "invalid input a"},
+		"NaN":           {map[string]any{"a": math.NaN(), "b": []int{5, 7}}, "invalid input a"},
+		"infinity":      {map[string]any{"a": math.Inf(1), "b": []int{5, 7}}, "invalid input a"},
+		"fraction":      {map[string]any{"a": 1.5, "b": []int{5, 7}}, "invalid input a"},
+	} {
+		if _, err := calc.CalculateWitness(tc.inputs); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.err, err)
+		}
+	}
+
+	// Leading zeros don't make decimal numbers octal, as in snarkjs.
+	w, err = calc.CalculateWitness(map[string]any{"a": "010", "b": []string{"0x0a", "7"}})
+	if err != nil {
+		t.Fatalf("failed to calculate witness: %v", err)
+	}
+	for i, expected := range []uint64{1, 10, 10, 7} {
+		if e := fr.NewElement(expected); !w.Values[i].Equal(&e) {
+			t.Errorf("value %d: expected %d, got %s", i, expected, w.Values[i].String())
+		}
+	}
+
+	if _, err := witnesscalc.New([]byte("\x00asm\x01\x00\x00\x00")); err == nil {
+		t.Errorf("expected error for a module without the circom exports")
+	}
+
+	// A witness size that does not fit in the memory of the module is
+	// rejected before allocating the witness.
+	huge, err := witnesscalc.New(echoCircuitWasmWithSize(1 << 30))
+	if err != nil {
+		t.Fatalf("failed to load circuit: %v", err)
+	}
+	defer huge.Close()
+	if _, err := huge.CalculateWitness(map[string]any{"a": 3, "b": []int{5, 7}}); err == nil || !strings.Contains(err.Error(), "invalid witness size") {
+		t.Errorf("expected error for a huge witness size, got %v", err)
+	}
+}
+
+// TestWitnessCalculatorCircom runs a circuit.wasm compiled by circom 2, the
+// iden3 auth circuit from the go-rapidsnark test data, which checks a
+// Poseidon Merkle proof and an EdDSA signature of the challenge.
+func TestWitnessCalculatorCircom(t *testing.T) {
+	calc, err := witnesscalc.New(loadFile(t, "circom_data/auth/circuit.wasm"), witnesscalc.WithSanityCheck())
+	if err != nil {
+		t.Fatalf("failed to load circuit: %v", err)
+	}
+	defer calc.Close()
+
+	inputs, err := witnesscalc.ParseInputsJSON(loadFile(t, "circom_data/auth/input.json"))
+	if err != nil {
+		t.Fatalf("failed to parse inputs: %v", err)
+	}
+	w, err := calc.CalculateWitness(inputs)
+	if err != nil {
+		t.Fatalf("failed to calculate witness: %v", err)
+	}
+	if len(w.Values) != 23854 {
+		t.Fatalf("expected 23854 values, got %d", len(w.Values))
+	}
+	// The constant one, followed by the public inputs challenge, userState
+	// and userID.
+	publicSignals, err := w.PublicSignals(3)
+	if err != nil {
+		t.Fatalf("failed to get public signals: %v", err)
+	}
+	expected := []string{
+		"1",
+		"18656147546666944484453899241916469544090258810192803949522794490493271005313",
+		"379949150130214723420589610911161895495647789006649785264738141299135414272",
+	}
+	if !w.Values[0].IsOne() || strings.Join(publicSignals, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected public signals %v", publicSignals)
+	}
+
+	// The signature is no longer valid for another challenge.
+	inputs["challenge"] = "2"
+	if _, err := calc.CalculateWitness(inputs); err == nil || !strings.Contains(err.Error(), "assert failed") {
+		t.Errorf("expected an assert failure for a wrong challenge, got %v", err)
+	}
+}
+
+// TestWitnessCalculatorMultiplier runs the circuit.wasm that circom 2
+// compiles from circom_data/multiplier/circuit.circom.
+func TestWitnessCalculatorMultiplier(t *testing.T) {
+	calc, err := witnesscalc.New(loadSnarkjsFile(t, "circuit.wasm"), witnesscalc.WithSanityCheck())
+	if err != nil {
+		t.Fatalf("failed to load circuit: %v", err)
+	}
+	defer calc.Close()
+
+	inputs, err := witnesscalc.ParseInputsJSON(loadFile(t, "circom_data/multiplier/input.json"))
+	if err != nil {
+		t.Fatalf("failed to parse inputs: %v", err)
+	}
+	w, err := calc.CalculateWitness(inputs)
+	if err != nil {
+		t.Fatalf("failed to calculate witness: %v", err)
+	}
+	// The constant one, out, x, a, b and t, as in the fixture.
+	fixture := newMultiplierFixture()
+	if len(w.Values) != len(fixture.witness) {
+		t.Fatalf("expected %d values, got %d", len(fixture.witness), len(w.Values))
+	}
+	for i := range w.Values {
+		if !w.Values[i].Equal(&fixture.witness[i]) {
+			t.Errorf("value %d: expected %s, got %s", i, fixture.witness[i].String(), w.Values[i].String())
+		}
+	}
+	publicSignals, err := w.PublicSignals(fixture.nPublic())
+	if err != nil {
+		t.Fatalf("failed to get public signals: %v", err)
+	}
+	if strings.Join(publicSignals, ",") != "45,2" {
+		t.Errorf("unexpected public signals %v", publicSignals)
+	}
+
+	delete(inputs, "b")
+	if _, err := calc.CalculateWitness(inputs); err == nil {
+		t.Errorf("expected error for a missing input")
+	}
+}
+
+// echoCircuitWasm assembles a minimal module implementing the circom 2
+// witness calculator interface for a circuit with the input signals a and
+// b[2], whose witness is [1, a, b[0], b[1]] and which asserts a != 0.
+func echoCircuitWasm() []byte {
+	return echoCircuitWasmWithSize(4)
+}
+
+// echoCircuitWasmWithSize is echoCircuitWasm with a module reporting the
+// given witness size.
+func echoCircuitWasmWithSize(witnessSize int64) []byte {
+	const (
+		i32 = 0x7f
+
+		opUnreachable = 0x00
+		opIf          = 0x04
+		opElse        = 0x05
+		opEnd         = 0x0b
+		opReturn      = 0x0f
+		opCall        = 0x10
+		opLocalGet    = 0x20
+		opLocalSet    = 0x21
+		opLoad        = 0x28
+		opStore       = 0x36
+		opConst       = 0x41
+		opEqz         = 0x45
+		opEq          = 0x46
+		opAdd         = 0x6a
+		opMul         = 0x6c
+		opAnd         = 0x71
+		opShl         = 0x74
+
+		signalsOffset = 64
+	)
+	sleb := func(v int64) []byte {
+		var b []byte
+		for {
+			c := byte(v & 0x7f)
+			v >>= 7
+			if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+				return append(b, c)
+			}
+			b = append(b, c|0x80)
+		}
+	}
+	uleb := func(v int) []byte { return binary.AppendUvarint(nil, uint64(v)) }
+	vec := func(items ...[]byte) []byte {
+		b := uleb(len(items))
+		for _, item := range items {
+			b = append(b, item...)
+		}
+		return b
+	}
+	str := func(s string) []byte { return append(uleb(len(s)), s...) }
+	i32Const := func(v int64) []byte { return append([]byte{opConst}, sleb(v)...) }
+	hashIs := func(name string) []byte {
+		h := fnv.New64a()
+		h.Write([]byte(name))
+		sum := h.Sum64()
+		var code []byte
+		code = append(code, opLocalGet, 0)
+		code = append(code, i32Const(int64(int32(sum>>32)))...)
+		code = append(code, opEq, opLocalGet, 1)
+		code = append(code, i32Const(int64(int32(sum)))...)
+		return append(code, opEq, opAnd)
+	}
+	// copyField copies the 8 words at the address computed by from to the
+	// address computed by to.
+	copyField := func(to, from []byte) []byte {
+		var code []byte
+		for k := 0; k < 8; k++ {
+			code = append(code, to...)
+			code = append(code, from...)
+			code = append(code, opLoad, 2, byte(4*k), opStore, 2, byte(4*k))
+		}
+		return code
+	}
+	signalAddr := func(local byte) []byte {
+		code := []byte{opLocalGet, local}
+		code = append(code, i32Const(32)...)
+		code = append(code, opMul)
+		code = append(code, i32Const(signalsOffset)...)
+		return append(code, opAdd)
+	}
+	raise := func(code int64) []byte {
+		return append(i32Const(code), opCall, 0, opUnreachable)
+	}
+
+	var getRawPrime []byte
+	for k, word := range fr.Modulus().Bits() {
+		getRawPrime = append(getRawPrime, i32Const(0)...)
+		getRawPrime = append(getRawPrime, i32Const(int64(int32(uint32(word))))...)
+		getRawPrime = append(getRawPrime, opStore, 2, byte(8*k))
+		getRawPrime = append(getRawPrime, i32Const(0)...)
+		getRawPrime = append(getRawPrime, i32Const(int64(int32(uint32(word>>32))))...)
+		getRawPrime = append(getRawPrime, opStore, 2, byte(8*k+4))
+	}
+
+	getInputSignalSize := hashIs("a")
+	getInputSignalSize = append(getInputSignalSize, opIf, 0x40)
+	getInputSignalSize = append(getInputSignalSize, i32Const(1)...)
+	getInputSignalSize = append(getInputSignalSize, opReturn, opEnd)
+	getInputSignalSize = append(getInputSignalSize, hashIs("b")...)
+	getInputSignalSize = append(getInputSignalSize, opIf, 0x40)
+	getInputSignalSize = append(getInputSignalSize, i32Const(2)...)
+	getInputSignalSize = append(getInputSignalSize, opReturn, opEnd)
+	getInputSignalSize = append(getInputSignalSize, i32Const(-1)...)
+
+	setInputSignal := hashIs("a")
+	setInputSignal = append(setInputSignal, opIf, 0x40)
+	setInputSignal = append(setInputSignal, i32Const(0)...)
+	setInputSignal = append(setInputSignal, opLoad, 2, 0, opEqz, opIf, 0x40)
+	setInputSignal = append(setInputSignal, raise(4)...)
+	setInputSignal = append(setInputSignal, opEnd, opLocalGet, 2)
+	setInputSignal = append(setInputSignal, i32Const(1)...)
+	setInputSignal = append(setInputSignal, opAdd, opLocalSet, 3, opElse)
+	setInputSignal = append(setInputSignal, hashIs("b")...)
+	setInputSignal = append(setInputSignal, opIf, 0x40, opLocalGet, 2)
+	setInputSignal = append(setInputSignal, i32Const(2)...)
+	setInputSignal = append(setInputSignal, opAdd, opLocalSet, 3, opElse)
+	setInputSignal = append(setInputSignal, raise(1)...)
+	setInputSignal = append(setInputSignal, opEnd, opEnd)
+	setInputSignal = append(setInputSignal, copyField(signalAddr(3), i32Const(0))...)
+
+	init := i32Const(signalsOffset)
+	init = append(init, i32Const(1)...)
+	init = append(init, opStore, 2, 0)
+
+	sharedAddr := func() []byte {
+		code := []byte{opLocalGet, 0}
+		code = append(code, i32Const(2)...)
+		return append(code, opShl)
+	}
+
+	// Function types, referenced by index below.
+	types := [][]byte{
+		{0x60, 0, 0},                // () -> ()
+		{0x60, 1, i32, 0},           // (i32) -> ()
+		{0x60, 0, 1, i32},           // () -> i32
+		{0x60, 1, i32, 1, i32},      // (i32) -> i32
+		{0x60, 2, i32, i32, 0},      // (i32, i32) -> ()
+		{0x60, 2, i32, i32, 1, i32}, // (i32, i32) -> i32
+		{0x60, 3, i32, i32, i32, 0}, // (i32, i32, i32) -> ()
+	}
+	funcs := []struct {
+		name   string
+		typ    byte
+		locals int
+		code   []byte
+	}{
+		{"getVersion", 2, 0, i32Const(2)},
+		{"getFieldNumLen32", 2, 0, i32Const(8)},
+		{"getRawPrime", 0, 0, getRawPrime},
+		{"getInputSize", 2, 0, i32Const(3)},
+		{"getWitnessSize", 2, 0, i32Const(witnessSize)},
+		{"init", 1, 0, init},
+		{"readSharedRWMemory", 3, 0, append(sharedAddr(), opLoad, 2, 0)},
+		{"writeSharedRWMemory", 4, 0, append(sharedAddr(), opLocalGet, 1, opStore, 2, 0)},
+		{"getInputSignalSize", 5, 0, getInputSignalSize},
+		{"setInputSignal", 6, 1, setInputSignal},
+		{"getWitness", 1, 0, copyField(i32Const(0), signalAddr(0))},
+	}
+
+	var funcTypes, exports, bodies [][]byte
+	for i, f := range funcs {
+		funcTypes = append(funcTypes, []byte{f.typ})
+		exports = append(exports, append(str(f.name), 0, byte(i+1))) // function 0 is the import
+		body := []byte{0}
+		if f.locals > 0 {
+			body = vec([]byte{byte(f.locals), i32})
+		}
+		body = append(body, f.code...)
+		body = append(body, opEnd)
+		bodies = append(bodies, append(uleb(len(body)), body...))
+	}
+	imports := [][]byte{append(append(str("runtime"), str("exceptionHandler")...), 0, 1)}
+
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	for _, s := range []struct {
+		id   byte
+		data []byte
+	}{
+		{1, vec(types...)},
+		{2, vec(imports...)},
+		{3, vec(funcTypes...)},
+		{5, vec([]byte{0, 1})}, // one memory of one page
+		{7, vec(exports...)},
+		{10, vec(bodies...)},
+	} {
+		module = append(module, s.id)
+		module = append(module, uleb(len(s.data))...)
+		module = append(module, s.data...)
+	}
+	return module
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestCircomWitnessRoundtrip(t *testing.T) {
+	fixture := newMultiplierFixture()
+
+	data, err := parser.MarshalCircomWitness(&parser.CircomWitness{Values: fixture.witness})
+	if err != nil {
+		t.Fatalf("failed to marshal witness: %v", err)
+	}
+	wtns, err := parser.UnmarshalCircomWitness(data)
+	if err != nil {
+		t.Fatalf("failed to unmarshal witness: %v", err)
+	}
+	if !reflect.DeepEqual(wtns.Values, fixture.witness) {
+		t.Fatalf("witness values mismatch")
+	}
+
+	// Public signals, in the format of public.json.
+	publicSignals, err := wtns.PublicSignals(fixture.nPublic())
+	if err != nil {
+		t.Fatalf("failed to extract public signals: %v", err)
+	}
+	if !reflect.DeepEqual(publicSignals, []string{"45", "2"}) {
+		t.Errorf("unexpected public signals: %v", publicSignals)
+	}
+
+	// The gnark witness must satisfy the imported constraint system.
+	r1cs, err := parser.UnmarshalCircomR1CS(fixture.r1cs())
+	if err != nil {
+		t.Fatalf("failed to unmarshal r1cs: %v", err)
+	}
+	ccs, err := r1cs.ConstraintSystem()
+	if err != nil {
+		t.Fatalf("failed to build constraint system: %v", err)
+	}
+	fullWitness, err := wtns.ToGnarkWitness(r1cs.NPublic())
+	if err != nil {
+		t.Fatalf("failed to convert witness: %v", err)
+	}
+	if err := ccs.IsSolved(fullWitness); err != nil {
+		t.Errorf("witness should satisfy the constraints: %v", err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatalf("failed to get public witness: %v", err)
+	}
+	if n := len(publicWitness.Vector().(fr.Vector)); n != fixture.nPublic() {
+		t.Errorf("expected %d public values, got %d", fixture.nPublic(), n)
+	}
+
+	// And back to a .wtns file.
+	back, err := parser.CircomWitnessFromGnark(fullWitness)
+	if err != nil {
+		t.Fatalf("failed to convert gnark witness: %v", err)
+	}
+	data2, err := parser.MarshalCircomWitness(back)
+	if err != nil {
+		t.Fatalf("failed to marshal witness: %v", err)
+	}
+	if !bytes.Equal(data, data2) {
+		t.Errorf("wtns roundtrip mismatch")
+	}
+}
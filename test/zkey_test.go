@@ -0,0 +1,113 @@
+package test
+
+import (
+	"bytes"
+	"math/big"
+	"math/bits"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+func TestUnmarshalCircomZKey(t *testing.T) {
+	fixture := newMultiplierFixture()
+	tw := newToxicWaste(t)
+
+	zkey, err := parser.UnmarshalCircomZKey(fixture.zkey(tw))
+	if err != nil {
+		t.Fatalf("failed to unmarshal zkey: %v", err)
+	}
+	if zkey.NVars != fixture.nWires || zkey.NPublic != fixture.nPublic() || zkey.DomainSize != fixture.domainSize() {
+		t.Fatalf("unexpected header: nVars=%d nPublic=%d domainSize=%d", zkey.NVars, zkey.NPublic, zkey.DomainSize)
+	}
+
+	// The verification key must match the toxic waste.
+	vk := zkey.VerifyingKey
+	alpha := g1Mul(tw.alpha)
+	gamma := g2Mul(tw.gamma)
+	if !vk.G1.Alpha.Equal(&alpha) || !vk.G2.Gamma.Equal(&gamma) {
+		t.Errorf("verifying key does not match the setup")
+	}
+	circomVk := zkey.CircomVerificationKey
+	if circomVk.NPublic != fixture.nPublic() || len(circomVk.IC) != fixture.nPublic()+1 {
+		t.Errorf("unexpected circom verification key: nPublic=%d, len(IC)=%d", circomVk.NPublic, len(circomVk.IC))
+	}
+	if _, err := parser.ConvertVerificationKey(circomVk); err != nil {
+		t.Errorf("failed to convert exported verification key: %v", err)
+	}
+
+	// pk.G1.Z must hold [τⁱ·(τⁿ-1)/δ]₁ in bit-reversed order.
+	pk := zkey.ProvingKey
+	n := zkey.DomainSize
+	if len(pk.G1.Z) != n-1 {
+		t.Fatalf("expected %d Z points, got %d", n-1, len(pk.G1.Z))
+	}
+	var tn, deltaInv, one fr.Element
+	one.SetOne()
+	deltaInv.Inverse(&tw.delta)
+	tn.Exp(tw.tau, big.NewInt(int64(n))).Sub(&tn, &one).Mul(&tn, &deltaInv)
+	logN := bits.TrailingZeros(uint(n))
+	for i := range pk.G1.Z {
+		var s fr.Element
+		s.Exp(tw.tau, big.NewInt(int64(bits.Reverse64(uint64(i))>>(64-logN)))).Mul(&s, &tn)
+		expected := g1Mul(s)
+		if !pk.G1.Z[i].Equal(&expected) {
+			t.Errorf("Z[%d] mismatch", i)
+		}
+	}
+
+	// out (wire 1) only appears in C, so its B points are at infinity.
+	if pk.NbInfinityA != uint64(len(pk.InfinityA)-len(pk.G1.A)) || len(pk.G1.B) != len(pk.G2.B) {
+		t.Errorf("inconsistent infinity filtering")
+	}
+	if !pk.InfinityB[1] || pk.InfinityB[0] {
+		t.Errorf("unexpected points at infinity in B")
+	}
+
+	// Truncated files must be rejected without panicking.
+	data := fixture.zkey(tw)
+	for _, size := range []int{0, 4, 12, 40, len(data) / 2, len(data) - 1} {
+		if _, err := parser.UnmarshalCircomZKey(data[:size]); err == nil {
+			t.Errorf("expected error for zkey truncated to %d bytes", size)
+		}
+	}
+
+	// A vk_gamma_2 outside of the prime order subgroup must be rejected. The
+	// groth16 header starts at byte 40, and vk_gamma_2 follows the sizes,
+	// alpha1, beta1 and beta2.
+	var bad bytes.Buffer
+	putG2Mont(&bad, twistG2NotInSubgroup(t))
+	data = fixture.zkey(tw)
+	copy(data[40+84+2*64+128:], bad.Bytes())
+	if _, err := parser.UnmarshalCircomZKey(data); err == nil {
+		t.Errorf("expected error for vk_gamma_2 outside of the subgroup")
+	}
+}
+
+func TestUnmarshalCircomZKeySnarkjs(t *testing.T) {
+	zkey, err := parser.UnmarshalCircomZKey(loadSnarkjsFile(t, "groth16.zkey"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal zkey: %v", err)
+	}
+	if zkey.NPublic != 2 {
+		t.Errorf("expected 2 public signals, got %d", zkey.NPublic)
+	}
+
+	// The verification key must be the one of snarkjs zkey export verificationkey.
+	vk, err := parser.UnmarshalCircomVerificationKeyJSON(loadSnarkjsFile(t, "groth16_vkey.json"))
+	if err != nil {
+		t.Fatalf("failed to unmarshal verification key: %v", err)
+	}
+	want, err := vk.Fingerprint(parser.FingerprintSHA256)
+	if err != nil {
+		t.Fatalf("failed to fingerprint verification key: %v", err)
+	}
+	got, err := zkey.CircomVerificationKey.Fingerprint(parser.FingerprintSHA256)
+	if err != nil {
+		t.Fatalf("failed to fingerprint verification key: %v", err)
+	}
+	if got != want || zkey.CircomVerificationKey.NPublic != vk.NPublic {
+		t.Errorf("verification key does not match the one exported by snarkjs")
+	}
+}
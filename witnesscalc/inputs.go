@@ -0,0 +1,102 @@
+package witnesscalc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// ParseInputsJSON parses a circom input.json file, which maps each input
+// signal name to a value or a (nested) array of values. Numbers are kept as
+// json.Number, so they don't lose precision.
+func ParseInputsJSON(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var inputs map[string]any
+	if err := dec.Decode(&inputs); err != nil {
+		return nil, fmt.Errorf("failed to parse inputs JSON: %v", err)
+	}
+	return inputs, nil
+}
+
+// flattenInput flattens a (nested) array of input values in row-major order,
+// which is how circom lays out signal arrays.
+func flattenInput(v any) ([]*big.Int, error) {
+	if n, err := inputToBigInt(v); err == nil {
+		return []*big.Int{n}, nil
+	} else if rv := reflect.ValueOf(v); rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, err
+	}
+	rv := reflect.ValueOf(v)
+	var values []*big.Int
+	for i := 0; i < rv.Len(); i++ {
+		elems, err := flattenInput(rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, elems...)
+	}
+	return values, nil
+}
+
+// inputToBigInt converts a single input value to a big.Int. Strings and
+// JSON numbers may be decimal or 0x-prefixed hexadecimal, as accepted by
+// snarkjs.
+func inputToBigInt(v any) (*big.Int, error) {
+	switch v := v.(type) {
+	case *big.Int:
+		return v, nil
+	case string:
+		return stringToBigInt(v)
+	case json.Number:
+		return stringToBigInt(v.String())
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("value %v is not an integer", v)
+		}
+		n, accuracy := big.NewFloat(v).Int(nil)
+		if accuracy != big.Exact {
+			return nil, fmt.Errorf("value %v is not an integer", v)
+		}
+		return n, nil
+	case bool:
+		if v {
+			return big.NewInt(1), nil
+		}
+		return big.NewInt(0), nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case int32:
+		return big.NewInt(int64(v)), nil
+	case uint32:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+// stringToBigInt parses a decimal number, which may be negative, or a
+// 0x-prefixed hexadecimal number. Unlike big.Int.SetString with base 0, the
+// base is never inferred from other prefixes, so "010" is ten rather than
+// octal eight, and "0X", "0b", "0o" and underscores are rejected.
+func stringToBigInt(s string) (*big.Int, error) {
+	digits, base, charset := strings.TrimPrefix(s, "-"), 10, "0123456789"
+	if strings.HasPrefix(s, "0x") {
+		digits, base, charset = s[2:], 16, "0123456789abcdefABCDEF"
+	}
+	if digits == "" || strings.Trim(digits, charset) != "" {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	if base == 16 {
+		s = digits
+	}
+	n, _ := new(big.Int).SetString(s, base)
+	return n, nil
+}
@@ -0,0 +1,406 @@
+// Package witnesscalc computes the witness of a Circom circuit by running the
+// circuit.wasm generated by circom 2 on a pure-Go WebAssembly runtime
+// (wazero), so no Node.js, snarkjs or cgo is required.
+package witnesscalc
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"sort"
+	"strings"
+
+	bn254fr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/vocdoni/circom2gnark/parser"
+)
+
+// Functions exported by the circuit.wasm files generated by circom 2.
+var requiredExports = []string{
+	"getVersion",
+	"getFieldNumLen32",
+	"getRawPrime",
+	"getInputSize",
+	"getWitnessSize",
+	"init",
+	"readSharedRWMemory",
+	"writeSharedRWMemory",
+	"setInputSignal",
+	"getWitness",
+}
+
+// Error codes passed by the circuit to the exceptionHandler import.
+var exceptionMessages = map[int32]string{
+	1: "signal not found",
+	2: "too many signals set",
+	3: "signal already set",
+	4: "assert failed",
+	5: "not enough memory",
+	6: "input signal array access exceeds the size",
+}
+
+// Calculator computes witnesses for a compiled circom 2 circuit. It is safe
+// for concurrent use, as each calculation runs on a fresh module instance.
+type Calculator struct {
+	runtime     wazero.Runtime
+	compiled    wazero.CompiledModule
+	sanityCheck bool
+	logf        func(msg string)
+}
+
+// Option configures a Calculator.
+type Option func(*Calculator)
+
+// WithSanityCheck enables the sanity checks of the circuit, which make the
+// witness calculation fail if some input signal is not set.
+func WithSanityCheck() Option {
+	return func(c *Calculator) {
+		c.sanityCheck = true
+	}
+}
+
+// WithLogger sets the function receiving the messages printed by the
+// circuit with the circom log() function. They are discarded by default.
+func WithLogger(logf func(msg string)) Option {
+	return func(c *Calculator) {
+		c.logf = logf
+	}
+}
+
+// New compiles the given circuit.wasm and returns a Calculator for it. Only
+// circuits compiled by circom 2 over bn254 are supported. The Calculator
+// must be closed to release the runtime.
+func New(wasm []byte, opts ...Option) (*Calculator, error) {
+	c := &Calculator{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	ctx := context.Background()
+	c.runtime = wazero.NewRuntime(ctx)
+	if err := c.init(ctx, wasm); err != nil {
+		c.runtime.Close(ctx)
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Calculator) init(ctx context.Context, wasm []byte) error {
+	var err error
+	c.compiled, err = c.runtime.CompileModule(ctx, wasm)
+	if err != nil {
+		return fmt.Errorf("failed to compile circuit wasm: %w", err)
+	}
+	exports := c.compiled.ExportedFunctions()
+	for _, name := range requiredExports {
+		if _, ok := exports[name]; !ok {
+			return fmt.Errorf("invalid circuit wasm: missing export %s, only circom 2 circuits are supported", name)
+		}
+	}
+	if _, err := c.runtime.NewHostModuleBuilder("runtime").
+		NewFunctionBuilder().WithFunc(exceptionHandler).Export("exceptionHandler").
+		NewFunctionBuilder().WithFunc(printErrorMessage).Export("printErrorMessage").
+		NewFunctionBuilder().WithFunc(writeBufferMessage).Export("writeBufferMessage").
+		NewFunctionBuilder().WithFunc(showSharedRWMemory).Export("showSharedRWMemory").
+		NewFunctionBuilder().WithFunc(func() {}).Export("log").
+		Instantiate(ctx); err != nil {
+		return fmt.Errorf("failed to instantiate circom runtime: %w", err)
+	}
+	return nil
+}
+
+// Close releases the resources of the Calculator.
+func (c *Calculator) Close() error {
+	return c.runtime.Close(context.Background())
+}
+
+// CalculateWitness runs the circuit over the given inputs and returns the
+// full Circom witness, starting with the constant one. Inputs map signal
+// names to values or (nested) arrays of values, as in the input.json files
+// read by ParseInputsJSON. Values may be *big.Int, integers, decimal or hex
+// strings, and are reduced modulo the scalar field.
+func (c *Calculator) CalculateWitness(inputs map[string]any) (*parser.CircomWitness, error) {
+	inst := &instance{logf: c.logf}
+	ctx := context.WithValue(context.Background(), instanceKey{}, inst)
+	if err := c.instantiate(ctx, inst); err != nil {
+		return nil, err
+	}
+	defer inst.mod.Close(ctx)
+
+	if err := inst.setInputs(ctx, inputs, c.sanityCheck); err != nil {
+		return nil, err
+	}
+	size, err := inst.call(ctx, "getWitnessSize")
+	if err != nil {
+		return nil, err
+	}
+	// circom keeps every signal of the witness in the memory of the module, so
+	// a larger size is bogus and must not be allocated.
+	if int32(size) < 0 || uint64(size)*bn254fr.Bytes > uint64(inst.mod.Memory().Size()) {
+		return nil, fmt.Errorf("invalid witness size %d", int32(size))
+	}
+	w := &parser.CircomWitness{Values: make([]bn254fr.Element, int32(size))}
+	for i := range w.Values {
+		if _, err := inst.call(ctx, "getWitness", uint64(i)); err != nil {
+			return nil, err
+		}
+		v, err := inst.readSharedRWMemory(ctx)
+		if err != nil {
+			return nil, err
+		}
+		w.Values[i].SetBigInt(v)
+	}
+	return w, nil
+}
+
+// CalculateGnarkWitness runs the circuit over the given inputs and returns
+// the full gnark witness and the public signals of a circuit with nPublic
+// public signals, as returned by CircomWitness.ToGnarkWitness and
+// CircomWitness.PublicSignals.
+func (c *Calculator) CalculateGnarkWitness(inputs map[string]any, nPublic int) (witness.Witness, []string, error) {
+	w, err := c.CalculateWitness(inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicSignals, err := w.PublicSignals(nPublic)
+	if err != nil {
+		return nil, nil, err
+	}
+	gw, err := w.ToGnarkWitness(nPublic)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gw, publicSignals, nil
+}
+
+type instanceKey struct{}
+
+// instance is a module instance running a single witness calculation, with
+// the state collected by the runtime imports.
+type instance struct {
+	mod       api.Module
+	n32       int
+	exception error
+	errMsg    strings.Builder
+	logMsg    strings.Builder
+	logf      func(msg string)
+}
+
+// instantiate creates the module instance of inst and checks its prime. The
+// context must hold inst, so the runtime imports can find it.
+func (c *Calculator) instantiate(ctx context.Context, inst *instance) error {
+	// Anonymous modules can be instantiated many times concurrently.
+	mod, err := c.runtime.InstantiateModule(ctx, c.compiled, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return fmt.Errorf("failed to instantiate circuit wasm: %w", err)
+	}
+	inst.mod = mod
+	if err := inst.checkPrime(ctx); err != nil {
+		mod.Close(ctx)
+		return err
+	}
+	return nil
+}
+
+func (inst *instance) checkPrime(ctx context.Context) error {
+	n32, err := inst.call(ctx, "getFieldNumLen32")
+	if err != nil {
+		return err
+	}
+	inst.n32 = int(int32(n32))
+	if inst.n32 != bn254fr.Limbs*2 {
+		return fmt.Errorf("unsupported circuit field size of %d words, only bn254 is supported", inst.n32)
+	}
+	if _, err := inst.call(ctx, "getRawPrime"); err != nil {
+		return err
+	}
+	prime, err := inst.readSharedRWMemory(ctx)
+	if err != nil {
+		return err
+	}
+	if prime.Cmp(bn254fr.Modulus()) != 0 {
+		return fmt.Errorf("unsupported circuit prime %s, only bn254 is supported", prime)
+	}
+	return nil
+}
+
+// call calls an exported function of the circuit, returning its first result
+// if any. Exceptions raised by the circuit take precedence over the trap
+// that follows them.
+func (inst *instance) call(ctx context.Context, name string, params ...uint64) (uint64, error) {
+	res, err := inst.mod.ExportedFunction(name).Call(ctx, params...)
+	if inst.exception != nil {
+		return 0, inst.exception
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to call %s: %w", name, err)
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	return res[0], nil
+}
+
+func (inst *instance) setInputs(ctx context.Context, inputs map[string]any, sanityCheck bool) error {
+	var sanity uint64
+	if sanityCheck {
+		sanity = 1
+	}
+	if _, err := inst.call(ctx, "init", sanity); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	getInputSignalSize := inst.mod.ExportedFunction("getInputSignalSize")
+	count := 0
+	for _, name := range names {
+		values, err := flattenInput(inputs[name])
+		if err != nil {
+			return fmt.Errorf("invalid input %s: %w", name, err)
+		}
+		hMSB, hLSB := fnvHash(name)
+		// getInputSignalSize is only exported since circom 2.0.7.
+		if getInputSignalSize != nil {
+			size, err := inst.call(ctx, "getInputSignalSize", hMSB, hLSB)
+			if err != nil {
+				return err
+			}
+			switch n := int(int32(size)); {
+			case n < 0:
+				return fmt.Errorf("input signal %s not found", name)
+			case len(values) != n:
+				return fmt.Errorf("input signal %s expects %d values, got %d", name, n, len(values))
+			}
+		}
+		for i, v := range values {
+			if err := inst.writeSharedRWMemory(ctx, v); err != nil {
+				return err
+			}
+			if _, err := inst.call(ctx, "setInputSignal", hMSB, hLSB, uint64(i)); err != nil {
+				return fmt.Errorf("failed to set input signal %s[%d]: %w", name, i, err)
+			}
+			count++
+		}
+	}
+	inputSize, err := inst.call(ctx, "getInputSize")
+	if err != nil {
+		return err
+	}
+	if count < int(int32(inputSize)) {
+		return fmt.Errorf("not all inputs have been set: only %d out of %d", count, int32(inputSize))
+	}
+	return nil
+}
+
+// readSharedRWMemory reads a field element from the shared memory of the
+// circuit, stored as n32 little-endian 32-bit words.
+func (inst *instance) readSharedRWMemory(ctx context.Context) (*big.Int, error) {
+	fn := inst.mod.ExportedFunction("readSharedRWMemory")
+	v, word := new(big.Int), new(big.Int)
+	for j := inst.n32 - 1; j >= 0; j-- {
+		res, err := fn.Call(ctx, uint64(j))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read shared memory: %w", err)
+		}
+		v.Lsh(v, 32).Or(v, word.SetUint64(uint64(uint32(res[0]))))
+	}
+	return v, nil
+}
+
+// writeSharedRWMemory writes a field element to the shared memory of the
+// circuit, as n32 little-endian 32-bit words.
+func (inst *instance) writeSharedRWMemory(ctx context.Context, v *big.Int) error {
+	fn := inst.mod.ExportedFunction("writeSharedRWMemory")
+	v = new(big.Int).Mod(v, bn254fr.Modulus())
+	mask := big.NewInt(0xffffffff)
+	word := new(big.Int)
+	for j := 0; j < inst.n32; j++ {
+		word.And(v, mask)
+		if _, err := fn.Call(ctx, uint64(j), word.Uint64()); err != nil {
+			return fmt.Errorf("failed to write shared memory: %w", err)
+		}
+		v.Rsh(v, 32)
+	}
+	return nil
+}
+
+// message reads the message being printed by the circuit, one char at a time.
+func (inst *instance) message(ctx context.Context) string {
+	fn := inst.mod.ExportedFunction("getMessageChar")
+	if fn == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for {
+		res, err := fn.Call(ctx)
+		if err != nil || res[0] == 0 {
+			return sb.String()
+		}
+		sb.WriteByte(byte(res[0]))
+	}
+}
+
+// fnvHash returns the 64-bit FNV-1a hash of a signal name split into its
+// (MSB, LSB) 32-bit halves, which is how the circuit identifies signals.
+func fnvHash(name string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	sum := h.Sum64()
+	return sum >> 32, sum & 0xffffffff
+}
+
+// Imports of the "runtime" module. The calculation state is found in the
+// context, as the host module is shared by all the instances.
+
+func exceptionHandler(ctx context.Context, code int32) {
+	inst := ctx.Value(instanceKey{}).(*instance)
+	msg, ok := exceptionMessages[code]
+	if !ok {
+		msg = fmt.Sprintf("unknown error %d", code)
+	}
+	if inst.errMsg.Len() > 0 {
+		msg += ": " + strings.TrimSpace(inst.errMsg.String())
+	}
+	inst.exception = fmt.Errorf("circuit error: %s", msg)
+}
+
+func printErrorMessage(ctx context.Context) {
+	inst := ctx.Value(instanceKey{}).(*instance)
+	inst.errMsg.WriteString(inst.message(ctx) + "\n")
+}
+
+func writeBufferMessage(ctx context.Context) {
+	inst := ctx.Value(instanceKey{}).(*instance)
+	msg := inst.message(ctx)
+	// Every log() call ends with a newline.
+	if msg == "\n" {
+		if inst.logf != nil {
+			inst.logf(inst.logMsg.String())
+		}
+		inst.logMsg.Reset()
+		return
+	}
+	inst.appendLog(msg)
+}
+
+func showSharedRWMemory(ctx context.Context) {
+	inst := ctx.Value(instanceKey{}).(*instance)
+	v, err := inst.readSharedRWMemory(ctx)
+	if err != nil {
+		return
+	}
+	inst.appendLog(v.String())
+}
+
+func (inst *instance) appendLog(msg string) {
+	if inst.logMsg.Len() > 0 {
+		inst.logMsg.WriteString(" ")
+	}
+	inst.logMsg.WriteString(msg)
+}